@@ -1,21 +1,37 @@
 package main
 
 import (
+	"context"
+	"example/hello/internal/account"
+	"example/hello/internal/apikey"
+	"example/hello/internal/audit"
+	"example/hello/internal/auth"
 	"example/hello/internal/book"
 	"example/hello/internal/handler"
+	"example/hello/internal/loginguard"
+	"example/hello/internal/mail"
 	"example/hello/internal/match"
+	"example/hello/internal/mfa"
+	"example/hello/internal/middleware"
+	"example/hello/internal/oauth"
+	"example/hello/internal/outbox"
+	"example/hello/internal/password"
+	"example/hello/internal/phone"
 	"example/hello/internal/realtime"
 	"example/hello/internal/route"
+	"example/hello/internal/session"
 	"example/hello/internal/short"
 	"example/hello/internal/user"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -28,8 +44,17 @@ func main() {
 		log.Println("Peringatan: Gagal memuat file .env")
 	}
 
+	// Kunci JWT (HS256, RS256 atau EdDSA) dibaca dari environment, lihat README
+	keyProvider, err := auth.NewKeyProvider(auth.KeyConfigFromEnv())
+	if err != nil {
+		log.Fatalf("Gagal memuat kunci JWT: %v", err)
+	}
+	auth.SetKeyProvider(keyProvider)
+
 	dsn := os.Getenv("DB_DSN")
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	// TranslateError mengubah error MySQL seperti duplicate key (1062) menjadi
+	// gorm.ErrDuplicatedKey, sehingga service tidak bergantung pada driver.
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{TranslateError: true})
 
 	if err != nil {
 		panic("failed to connect database")
@@ -37,23 +62,88 @@ func main() {
 
 	fmt.Println("Connected to the database successfully")
 
-	db.AutoMigrate(&book.Book{})
+	db.AutoMigrate(&book.Book{}, &book.Author{}, &book.Genre{}, &book.Publisher{}, &book.Review{})
 	db.AutoMigrate(&user.User{})
+	db.AutoMigrate(&user.Identity{})
+	db.AutoMigrate(&user.PasswordHistory{})
+	db.AutoMigrate(&phone.OTP{})
 	db.AutoMigrate(&short.Short{})
 	db.AutoMigrate(&realtime.Message{})
 	db.AutoMigrate(&match.Match{})
+	db.AutoMigrate(&session.RefreshToken{})
+	db.AutoMigrate(&session.RevokedToken{})
+	db.AutoMigrate(&session.Session{})
+	db.AutoMigrate(&outbox.Email{})
+	db.AutoMigrate(&loginguard.Attempt{})
+	db.AutoMigrate(&mfa.RecoveryCode{})
+	db.AutoMigrate(&audit.Event{})
+	db.AutoMigrate(&apikey.APIKey{})
+	db.AutoMigrate(&oauth.PendingState{})
 
 	// === Dependency Injection Setup ===
 	// Inisialisasi semua dependency di satu tempat (Composition Root)
 
+	// Audit log: login, reset password, perubahan akun dan profil match
+	auditService := audit.NewService(audit.NewRepository(db))
+	auditHandler := handler.NewAuditHandler(auditService)
+
+	// Session Dependencies (refresh token & pencabutan jti)
+	sessionRepository := session.NewRepository(db)
+	sessionService := session.NewService(sessionRepository, auditService)
+	apiKeyService := apikey.NewService(apikey.NewRepository(db), auditService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	authMiddleware := middleware.AuthMiddleware(sessionService, apiKeyService)
+	sessionHandler := handler.NewSessionHandler(sessionService, apiKeyService)
+
+	// Mail Dependencies
+	mailRenderer, err := mail.NewRenderer()
+	if err != nil {
+		log.Fatalf("Gagal memuat template email: %v", err)
+	}
+	mailer := newMailer()
+
+	// Email Outbox: email disimpan ke tabel email_outbox lalu dikirim worker di background
+	outboxRepository := outbox.NewRepository(db)
+	outboxService := outbox.NewService(outboxRepository)
+	outboxHandler := handler.NewOutboxHandler(outboxService)
+	outboxWorker := outbox.NewWorker(outboxRepository, mailer, 10*time.Second)
+	go outboxWorker.Run()
+
+	// Login Guard: counter login gagal. Pakai LOGIN_GUARD_STORE=database jika server
+	// berjalan di lebih dari satu instance agar counter dibagi lewat database.
+	var loginGuardRepository loginguard.Repository = loginguard.NewMemoryRepository()
+	if os.Getenv("LOGIN_GUARD_STORE") == "database" {
+		loginGuardRepository = loginguard.NewRepository(db)
+	}
+	loginGuardService := loginguard.NewService(loginGuardRepository)
+
+	// Kebijakan password. PASSWORD_BREACH_DIR berisi file prefix hash Pwned Passwords
+	// (misal "5BAA6.txt") untuk menolak password yang pernah bocor tanpa akses jaringan.
+	var breachList password.BreachList = password.NopBreachList{}
+	if dir := os.Getenv("PASSWORD_BREACH_DIR"); dir != "" {
+		breachList = password.NewFileBreachList(dir)
+	}
+	passwordChecker := password.NewChecker(password.PolicyFromEnv(), breachList)
+
+	// Verifikasi nomor telepon lewat OTP SMS
+	phoneService := phone.NewService(phone.NewRepository(db), newSMSSender())
+
 	// User Dependencies
 	userRepository := user.NewRepository(db)
-	userService := user.NewService(userRepository)
+	userService := user.NewService(userRepository, sessionService, loginGuardService, mfa.NewRepository(db), auditService, mailRenderer, passwordChecker, phoneService, apiKeyService)
 	userHandler := handler.NewUserHandler(userService)
+	verifiedMiddleware := middleware.RequireVerified(userService)
+
+	// Bootstrap admin pertama dari environment (hanya berjalan jika belum ada admin)
+	if adminEmail := os.Getenv("ADMIN_EMAIL"); adminEmail != "" {
+		if err := userService.EnsureAdmin(adminEmail, os.Getenv("ADMIN_NAME"), os.Getenv("ADMIN_PASSWORD")); err != nil {
+			log.Printf("Gagal membuat admin pertama: %v", err)
+		}
+	}
 
 	// Book Dependencies
 	bookRepository := book.NewRepository(db)
-	bookService := book.NewService(bookRepository)
+	bookService := book.NewService(bookRepository, book.NewCoverStore(filepath.Join("assets", "covers")))
 	bookHandler := handler.NewBookHandler(bookService)
 
 	// Short URL Dependencies
@@ -63,20 +153,28 @@ func main() {
 
 	// Match Profile Dependencies
 	matchRepository := match.NewRepository(db)
-	matchService := match.NewService(matchRepository)
+	matchService := match.NewService(matchRepository, auditService)
 	matchHandler := handler.NewMatchHandler(matchService)
 
-	// Konfigurasi Google OAuth2
-	googleOauthConfig := &oauth2.Config{
-		RedirectURL:  os.Getenv("GOOGLE_REDIRECT_URL"),
-		ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
-		ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
-		Scopes:       []string{"https://www.googleapis.com/auth/userinfo.email", "https://www.googleapis.com/auth/userinfo.profile"},
-		Endpoint:     google.Endpoint,
+	// Ekspor data dan penghapusan permanen akun setelah masa tunggu habis
+	accountService := account.NewService(account.NewRepository(db), filepath.Join("assets", "images"), auditService)
+	accountHandler := handler.NewAccountHandler(accountService)
+	purgeWorker := account.NewPurgeWorker(accountService, time.Hour)
+	go purgeWorker.Run()
+
+	// Provider login OAuth2/OIDC (Google, GitHub, issuer OIDC lain)
+	oauthProviders := newOAuthRegistry()
+
+	// Cookie state OAuth dan halaman SPA yang boleh menerima hasil login
+	cookieSecure, _ := strconv.ParseBool(os.Getenv("OAUTH_COOKIE_SECURE"))
+	oauthOptions := handler.OAuthOptions{
+		CookieDomain:      os.Getenv("OAUTH_COOKIE_DOMAIN"),
+		CookieSecure:      cookieSecure,
+		RedirectAllowlist: oauth.ParseRedirectAllowlist(os.Getenv("OAUTH_REDIRECT_URIS")),
 	}
 
 	// Inisialisasi Auth Handler
-	authHandler := handler.NewAuthHandler(googleOauthConfig, userService)
+	authHandler := handler.NewAuthHandler(oauthProviders, oauth.NewStateStore(oauth.NewRepository(db)), oauthOptions, userService, sessionService, keyProvider)
 
 	// Buat dan jalankan Hub real-time dalam goroutine terpisah
 	messageRepository := realtime.NewRepository(db)
@@ -92,12 +190,95 @@ func main() {
 	r.Static("/assets", "./assets")
 
 	// Setup routes dengan menyuntikkan handler yang sudah dibuat
-	route.SetupRoutes(r, authHandler, userHandler, bookHandler, shortHandler, webSocketHandler, matchHandler)
+	route.SetupRoutes(r, authMiddleware, verifiedMiddleware, authHandler, userHandler, bookHandler, shortHandler, webSocketHandler, matchHandler, outboxHandler, auditHandler, accountHandler, sessionHandler, apiKeyHandler)
 
 	// Start the server on port 8080
 	r.Run(":8080")
 }
 
+// newSMSSender memilih implementasi SMSSender berdasarkan SMS_DRIVER:
+// "log" (default, kode OTP ditulis ke log) atau "memory".
+func newSMSSender() phone.SMSSender {
+	switch os.Getenv("SMS_DRIVER") {
+	case "memory":
+		return phone.NewMemorySender()
+	default:
+		return phone.NewLogSender()
+	}
+}
+
+// newMailer memilih implementasi Mailer berdasarkan MAIL_DRIVER:
+// "smtp" (default jika SMTP_HOST di-set), "log" (tulis ke log dan MAIL_OUTBOX_DIR) atau "memory".
+func newMailer() mail.Mailer {
+	driver := os.Getenv("MAIL_DRIVER")
+	if driver == "" {
+		driver = "log"
+		if os.Getenv("SMTP_HOST") != "" {
+			driver = "smtp"
+		}
+	}
+
+	switch driver {
+	case "smtp":
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USER"),
+			Password: os.Getenv("SMTP_PASS"),
+			From:     os.Getenv("SMTP_SENDER_EMAIL"),
+		})
+	case "memory":
+		return mail.NewMemoryMailer()
+	default:
+		return mail.NewLogMailer(os.Getenv("MAIL_OUTBOX_DIR"))
+	}
+}
+
+// newOAuthRegistry mendaftarkan provider login yang dikonfigurasi:
+// Google (GOOGLE_*), GitHub (GITHUB_*) dan issuer OIDC di OIDC_PROVIDERS.
+// Untuk setiap nama di OIDC_PROVIDERS (misal "keycloak") dibaca OIDC_KEYCLOAK_ISSUER,
+// OIDC_KEYCLOAK_CLIENT_ID, OIDC_KEYCLOAK_CLIENT_SECRET dan OIDC_KEYCLOAK_REDIRECT_URL.
+func newOAuthRegistry() *oauth.Registry {
+	registry := oauth.NewRegistry()
+
+	if clientID := os.Getenv("GOOGLE_CLIENT_ID"); clientID != "" {
+		registry.Register(oauth.NewGoogleProvider(clientID, os.Getenv("GOOGLE_CLIENT_SECRET"), os.Getenv("GOOGLE_REDIRECT_URL")))
+	}
+
+	if clientID := os.Getenv("GITHUB_CLIENT_ID"); clientID != "" {
+		registry.Register(oauth.NewGitHubProvider(oauth.GitHubConfig{
+			ClientID:     clientID,
+			ClientSecret: os.Getenv("GITHUB_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("GITHUB_REDIRECT_URL"),
+		}))
+	}
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(strings.ToLower(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		provider, err := oauth.Discover(ctx, oauth.OIDCConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+		})
+		cancel()
+		if err != nil {
+			// Provider yang gagal discovery dilewati agar login lain tetap berjalan.
+			log.Printf("Provider OIDC %s tidak aktif: %v", name, err)
+			continue
+		}
+		registry.Register(provider)
+	}
+
+	return registry
+}
+
 // main
 // handler
 // service
@@ -0,0 +1,185 @@
+package account
+
+import (
+	"errors"
+	"example/hello/internal/apikey"
+	"example/hello/internal/book"
+	"example/hello/internal/match"
+	"example/hello/internal/mfa"
+	"example/hello/internal/outbox"
+	"example/hello/internal/phone"
+	"example/hello/internal/realtime"
+	"example/hello/internal/session"
+	"example/hello/internal/short"
+	"example/hello/internal/user"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNotDueForPurge berarti akun tidak lagi dijadwalkan dihapus (misal pemiliknya
+// baru saja login) atau jadwalnya belum jatuh tempo.
+var ErrNotDueForPurge = errors.New("akun tidak lagi dijadwalkan untuk dihapus")
+
+// AnonymizedContent menggantikan isi pesan chat milik user yang sudah dihapus.
+// Pesan tidak dihapus agar riwayat room lawan bicara tetap utuh.
+const AnonymizedContent = "[pesan dihapus]"
+
+// Repository membaca dan menghapus semua data milik satu user lintas tabel.
+type Repository interface {
+	FindUser(userID int) (user.User, error)
+	FindIdentities(userID int) ([]user.Identity, error)
+	FindMatches(userID int) ([]match.Match, error)
+	FindMessages(userID int) ([]realtime.Message, error)
+	FindShorts(userID int) ([]short.Short, error)
+	FindReviews(userID int) ([]book.Review, error)
+	FindDueForPurge(now time.Time, limit int) ([]user.User, error)
+	// Purge menghapus user beserta datanya dalam satu transaksi, setelah
+	// memastikan penghapusannya masih terjadwal dan sudah jatuh tempo pada now.
+	// ErrNotDueForPurge berarti penghapusan dibatalkan sejak user dipilih.
+	Purge(userID int, now time.Time) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) FindUser(userID int) (user.User, error) {
+	var u user.User
+	if err := r.db.First(&u, userID).Error; err != nil {
+		return user.User{}, err
+	}
+	return u, nil
+}
+
+func (r *repository) FindIdentities(userID int) ([]user.Identity, error) {
+	var identities []user.Identity
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (r *repository) FindMatches(userID int) ([]match.Match, error) {
+	var matches []match.Match
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&matches).Error; err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+func (r *repository) FindMessages(userID int) ([]realtime.Message, error) {
+	var messages []realtime.Message
+	if err := r.db.Where("sender_id = ?", userID).Order("id").Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *repository) FindShorts(userID int) ([]short.Short, error) {
+	var shorts []short.Short
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&shorts).Error; err != nil {
+		return nil, err
+	}
+	return shorts, nil
+}
+
+func (r *repository) FindReviews(userID int) ([]book.Review, error) {
+	var reviews []book.Review
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&reviews).Error; err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+func (r *repository) FindDueForPurge(now time.Time, limit int) ([]user.User, error) {
+	var users []user.User
+	err := r.db.Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", now).
+		Order("deletion_scheduled_at").
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *repository) Purge(userID int, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Kunci baris user agar login yang membatalkan penghapusan di saat yang
+		// sama menunggu transaksi ini, lalu cek ulang jadwalnya.
+		var u user.User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", userID, now).
+			First(&u).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotDueForPurge
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&match.Match{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&short.Short{}).Error; err != nil {
+			return err
+		}
+		// Ulasan dihapus dan ringkasan rating buku yang diulas dihitung ulang.
+		var reviewedBookIDs []int
+		if err := tx.Model(&book.Review{}).Where("user_id = ?", userID).Pluck("book_id", &reviewedBookIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&book.Review{}).Error; err != nil {
+			return err
+		}
+		for _, bookID := range reviewedBookIDs {
+			if err := book.RefreshRatingSummary(tx, bookID); err != nil {
+				return err
+			}
+		}
+		// Unscoped agar pesan yang sudah soft delete ikut dianonimkan.
+		if err := tx.Unscoped().Model(&realtime.Message{}).
+			Where("sender_id = ?", userID).
+			Updates(map[string]any{"content": AnonymizedContent, "sender_id": 0}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&user.Identity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&user.PasswordHistory{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&phone.OTP{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&mfa.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&session.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&session.Session{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&apikey.APIKey{}).Error; err != nil {
+			return err
+		}
+		// Antrean email menyimpan alamat dan isi email (termasuk link token).
+		recipients := []string{u.Email}
+		for _, address := range []*string{u.PendingEmail, u.EmailRevertAddress} {
+			if address != nil && *address != "" {
+				recipients = append(recipients, *address)
+			}
+		}
+		if err := tx.Where("recipient IN ?", recipients).Delete(&outbox.Email{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&user.User{}, userID).Error
+	})
+}
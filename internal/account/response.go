@@ -0,0 +1,72 @@
+package account
+
+import "time"
+
+// Export adalah salinan data milik user. Secret seperti hash password, secret
+// TOTP dan token tidak ikut diekspor.
+type Export struct {
+	GeneratedAt   time.Time      `json:"generated_at"`
+	Profile       Profile        `json:"profile"`
+	Identities    []Identity     `json:"identities"`
+	MatchProfiles []MatchProfile `json:"match_profiles"`
+	Messages      []Message      `json:"messages"`
+	Links         []Link         `json:"links"`
+	BookReviews   []BookReview   `json:"book_reviews"`
+}
+
+type Profile struct {
+	ID                  int        `json:"id"`
+	Name                string     `json:"name"`
+	Email               string     `json:"email"`
+	Phone               string     `json:"phone"`
+	Role                string     `json:"role"`
+	Language            string     `json:"language"`
+	EmailVerified       bool       `json:"email_verified"`
+	PhoneVerifiedAt     *time.Time `json:"phone_verified_at,omitempty"`
+	TwoFactorEnabled    bool       `json:"two_factor_enabled"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+type Identity struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MatchProfile struct {
+	ID         int       `json:"id"`
+	Age        int       `json:"age"`
+	Gender     string    `json:"gender"`
+	Interested string    `json:"interested"`
+	City       string    `json:"city"`
+	Name       string    `json:"name"`
+	Bio        string    `json:"bio"`
+	ImageURL   string    `json:"image_url"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type Message struct {
+	ID        uint      `json:"id"`
+	RoomID    string    `json:"room_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Link struct {
+	ID        int       `json:"id"`
+	Original  string    `json:"original"`
+	Shortened string    `json:"shortened"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BookReview struct {
+	ID        int       `json:"id"`
+	BookID    int       `json:"book_id"`
+	Rating    int       `json:"rating"`
+	Comment   string    `json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,271 @@
+package account
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"example/hello/internal/audit"
+	"example/hello/internal/user"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// imageURLPrefix adalah prefix URL foto profil match yang disimpan di imageDir.
+const imageURLPrefix = "/assets/images/"
+
+type Service interface {
+	Export(userID int) (Export, error)
+	// WriteArchive menulis hasil Export sebagai ZIP: satu file JSON per jenis data
+	// ditambah foto profil match di folder images/.
+	WriteArchive(export Export, w io.Writer) error
+	// PurgeDue menghapus permanen akun yang masa tunggunya sudah habis.
+	PurgeDue(now time.Time) (int, error)
+}
+
+type service struct {
+	repository Repository
+	imageDir   string
+	audit      audit.Recorder
+}
+
+// NewService membuat service akun. imageDir adalah folder fisik untuk URL
+// /assets/images/, biasanya "assets/images".
+func NewService(repository Repository, imageDir string, auditRecorder audit.Recorder) *service {
+	return &service{
+		repository: repository,
+		imageDir:   imageDir,
+		audit:      auditRecorder,
+	}
+}
+
+func (s *service) Export(userID int) (Export, error) {
+	u, err := s.repository.FindUser(userID)
+	if err != nil {
+		return Export{}, fmt.Errorf("error finding user: %w", err)
+	}
+	identities, err := s.repository.FindIdentities(userID)
+	if err != nil {
+		return Export{}, fmt.Errorf("error finding identities: %w", err)
+	}
+	matches, err := s.repository.FindMatches(userID)
+	if err != nil {
+		return Export{}, fmt.Errorf("error finding match profiles: %w", err)
+	}
+	messages, err := s.repository.FindMessages(userID)
+	if err != nil {
+		return Export{}, fmt.Errorf("error finding messages: %w", err)
+	}
+	shorts, err := s.repository.FindShorts(userID)
+	if err != nil {
+		return Export{}, fmt.Errorf("error finding links: %w", err)
+	}
+	reviews, err := s.repository.FindReviews(userID)
+	if err != nil {
+		return Export{}, fmt.Errorf("error finding book reviews: %w", err)
+	}
+
+	export := Export{
+		GeneratedAt:   time.Now(),
+		Profile:       toProfile(u),
+		Identities:    []Identity{},
+		MatchProfiles: []MatchProfile{},
+		Messages:      []Message{},
+		Links:         []Link{},
+		BookReviews:   []BookReview{},
+	}
+	for _, identity := range identities {
+		export.Identities = append(export.Identities, Identity{
+			Provider:  identity.Provider,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+	for _, m := range matches {
+		export.MatchProfiles = append(export.MatchProfiles, MatchProfile{
+			ID:         m.ID,
+			Age:        m.Age,
+			Gender:     string(m.Gender),
+			Interested: string(m.Interested),
+			City:       m.City,
+			Name:       m.Name,
+			Bio:        m.Bio,
+			ImageURL:   m.ImageURL,
+			CreatedAt:  m.CreatedAt,
+			UpdatedAt:  m.UpdatedAt,
+		})
+	}
+	for _, msg := range messages {
+		export.Messages = append(export.Messages, Message{
+			ID:        msg.ID,
+			RoomID:    msg.RoomID,
+			Content:   msg.Content,
+			CreatedAt: msg.CreatedAt,
+		})
+	}
+	for _, sh := range shorts {
+		export.Links = append(export.Links, Link{
+			ID:        sh.ID,
+			Original:  sh.Original,
+			Shortened: sh.Shortened,
+			CreatedAt: sh.CreatedAt,
+		})
+	}
+	for _, r := range reviews {
+		export.BookReviews = append(export.BookReviews, BookReview{
+			ID:        r.ID,
+			BookID:    r.BookID,
+			Rating:    r.Rating,
+			Comment:   r.Comment,
+			CreatedAt: r.CreatedAt,
+			UpdatedAt: r.UpdatedAt,
+		})
+	}
+	return export, nil
+}
+
+func (s *service) WriteArchive(export Export, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", export.Profile},
+		{"identities.json", export.Identities},
+		{"match_profiles.json", export.MatchProfiles},
+		{"messages.json", export.Messages},
+		{"links.json", export.Links},
+		{"book_reviews.json", export.BookReviews},
+	}
+	for _, f := range files {
+		if err := writeJSON(zw, f.name, f.data); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range export.MatchProfiles {
+		imagePath, ok := s.imagePath(m.ImageURL)
+		if !ok {
+			continue
+		}
+		if err := copyFile(zw, "images/"+filepath.Base(imagePath), imagePath); err != nil {
+			// Foto yang hilang dari disk tidak membatalkan ekspor data lainnya.
+			log.Printf("Export: gagal menambahkan foto %s: %v", m.ImageURL, err)
+		}
+	}
+	return zw.Close()
+}
+
+func (s *service) PurgeDue(now time.Time) (int, error) {
+	users, err := s.repository.FindDueForPurge(now, purgeBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("error finding accounts due for purge: %w", err)
+	}
+
+	purged := 0
+	for _, u := range users {
+		err := s.purge(u.ID, now)
+		if errors.Is(err, ErrNotDueForPurge) {
+			log.Printf("Purge: user %d dilewati, penghapusan sudah dibatalkan", u.ID)
+			continue
+		}
+		if err != nil {
+			log.Printf("Purge: gagal menghapus user %d: %v", u.ID, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purge menghapus data user di database, lalu foto profil match-nya di disk.
+// File baru dihapus setelah transaksi berhasil agar tidak ada data yang
+// masih menunjuk ke foto yang sudah hilang.
+func (s *service) purge(userID int, now time.Time) error {
+	matches, err := s.repository.FindMatches(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.repository.Purge(userID, now); err != nil {
+		return err
+	}
+
+	for _, m := range matches {
+		imagePath, ok := s.imagePath(m.ImageURL)
+		if !ok {
+			continue
+		}
+		if err := os.Remove(imagePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Purge: gagal menghapus foto %s: %v", imagePath, err)
+		}
+	}
+	s.audit.Record(audit.Entry{
+		Action:     audit.ActionUserPurged,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(userID),
+		Metadata:   map[string]any{"match_profiles": len(matches)},
+	})
+	log.Printf("Purge: akun user %d dihapus permanen", userID)
+	return nil
+}
+
+// imagePath mengubah URL foto menjadi path di imageDir. URL di luar
+// /assets/images/ atau yang mencoba keluar dari folder itu diabaikan.
+func (s *service) imagePath(imageURL string) (string, bool) {
+	if !strings.HasPrefix(imageURL, imageURLPrefix) {
+		return "", false
+	}
+	name := path.Base(imageURL)
+	if name != strings.TrimPrefix(imageURL, imageURLPrefix) || name == "." || name == ".." {
+		return "", false
+	}
+	return filepath.Join(s.imageDir, name), true
+}
+
+func toProfile(u user.User) Profile {
+	return Profile{
+		ID:                  u.ID,
+		Name:                u.Name,
+		Email:               u.Email,
+		Phone:               u.Phone,
+		Role:                string(u.Role),
+		Language:            u.Language,
+		EmailVerified:       u.Verivied,
+		PhoneVerifiedAt:     u.PhoneVerifiedAt,
+		TwoFactorEnabled:    u.TOTPEnabled,
+		DeletionScheduledAt: u.DeletionScheduledAt,
+		CreatedAt:           u.CreatedAt,
+		UpdatedAt:           u.UpdatedAt,
+	}
+}
+
+func writeJSON(zw *zip.Writer, name string, data any) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+func copyFile(zw *zip.Writer, name, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	return err
+}
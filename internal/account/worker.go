@@ -0,0 +1,38 @@
+package account
+
+import (
+	"log"
+	"time"
+)
+
+// purgeBatchSize adalah jumlah akun maksimal yang dihapus per putaran worker.
+const purgeBatchSize = 50
+
+// PurgeWorker menghapus permanen akun yang masa tunggunya sudah habis, mirip outbox.Worker.
+type PurgeWorker struct {
+	service  Service
+	interval time.Duration
+}
+
+func NewPurgeWorker(service Service, interval time.Duration) *PurgeWorker {
+	return &PurgeWorker{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Run menjalankan worker dalam sebuah goroutine.
+func (w *PurgeWorker) Run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := w.service.PurgeDue(time.Now())
+		if err != nil {
+			log.Printf("Purge: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purge: %d akun dihapus permanen", purged)
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,53 @@
+package apikey
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKey adalah personal access token milik user. Seperti refresh token, hanya
+// hash SHA-256 yang disimpan; token mentah ditampilkan sekali saat dibuat.
+type APIKey struct {
+	ID         int
+	UserID     int    `gorm:"index"`
+	Name       string `gorm:"type:varchar(100)"`
+	Prefix     string `gorm:"type:varchar(16)"` // Awal token untuk dikenali user di daftar, misal "pat_Ab12Cd34"
+	TokenHash  string `gorm:"type:varchar(64);uniqueIndex"`
+	Scopes     string `gorm:"type:varchar(255)"` // Dipisah spasi, misal "books:write links:read"
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+	LastUsedIP string     `gorm:"type:varchar(45)"`
+	RevokedAt  *time.Time // Diisi saat token dicabut user
+	CreatedAt  time.Time
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+func (k APIKey) ScopeList() []Scope {
+	scopes := []Scope{}
+	for _, s := range strings.Fields(k.Scopes) {
+		scopes = append(scopes, Scope(s))
+	}
+	return scopes
+}
+
+// HasScope memeriksa apakah token boleh dipakai untuk scope tertentu.
+func (k APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Principal adalah hasil autentikasi API key: token beserta data pemiliknya
+// yang dibaca ulang dari database, sehingga perubahan role langsung berlaku.
+type Principal struct {
+	Key      APIKey
+	UserID   int
+	Role     string
+	Verified bool
+}
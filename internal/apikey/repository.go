@@ -0,0 +1,88 @@
+package apikey
+
+import (
+	"example/hello/internal/user"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(key APIKey) (APIKey, error)
+	FindByID(ID int) (APIKey, error)
+	FindByHash(hash string) (APIKey, error)
+	FindActiveByUser(userID int, now time.Time) ([]APIKey, error)
+	CountActiveByUser(userID int, now time.Time) (int64, error)
+	Touch(ID int, ip string, at time.Time) error
+	Revoke(ID int, revokedAt time.Time) error
+	// FindOwner membaca pemilik token agar role dan status verifikasi selalu terbaru.
+	FindOwner(userID int) (user.User, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) Create(key APIKey) (APIKey, error) {
+	if err := r.db.Create(&key).Error; err != nil {
+		return APIKey{}, err
+	}
+	return key, nil
+}
+
+func (r *repository) FindByID(ID int) (APIKey, error) {
+	var key APIKey
+	if err := r.db.First(&key, ID).Error; err != nil {
+		return APIKey{}, err
+	}
+	return key, nil
+}
+
+func (r *repository) FindByHash(hash string) (APIKey, error) {
+	var key APIKey
+	if err := r.db.Where("token_hash = ?", hash).First(&key).Error; err != nil {
+		return APIKey{}, err
+	}
+	return key, nil
+}
+
+func (r *repository) FindActiveByUser(userID int, now time.Time) ([]APIKey, error) {
+	var keys []APIKey
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("created_at desc").
+		Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *repository) CountActiveByUser(userID int, now time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *repository) Touch(ID int, ip string, at time.Time) error {
+	return r.db.Model(&APIKey{}).Where("id = ?", ID).
+		Updates(map[string]any{"last_used_at": at, "last_used_ip": ip}).Error
+}
+
+func (r *repository) Revoke(ID int, revokedAt time.Time) error {
+	return r.db.Model(&APIKey{}).Where("id = ? AND revoked_at IS NULL", ID).
+		Update("revoked_at", revokedAt).Error
+}
+
+func (r *repository) FindOwner(userID int) (user.User, error) {
+	var owner user.User
+	if err := r.db.First(&owner, userID).Error; err != nil {
+		return user.User{}, err
+	}
+	return owner, nil
+}
@@ -0,0 +1,8 @@
+package apikey
+
+type CreateRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+	// ExpiresInDays default DefaultExpiryDays, maksimal MaxExpiryDays. Token tanpa masa berlaku tidak didukung.
+	ExpiresInDays int `json:"expires_in_days"`
+}
@@ -0,0 +1,30 @@
+package apikey
+
+// Scope membatasi endpoint yang boleh diakses sebuah API key, dengan format <area>:<akses>.
+type Scope string
+
+const (
+	ScopeBooksWrite   Scope = "books:write"
+	ScopeLinksRead    Scope = "links:read"
+	ScopeLinksWrite   Scope = "links:write"
+	ScopeMatchesRead  Scope = "matches:read"
+	ScopeMatchesWrite Scope = "matches:write"
+)
+
+// AllScopes adalah daftar scope yang boleh diminta saat membuat token.
+var AllScopes = []Scope{
+	ScopeBooksWrite,
+	ScopeLinksRead,
+	ScopeLinksWrite,
+	ScopeMatchesRead,
+	ScopeMatchesWrite,
+}
+
+func IsValidScope(scope Scope) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
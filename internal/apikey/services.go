@@ -0,0 +1,262 @@
+package apikey
+
+import (
+	"errors"
+	"example/hello/internal/audit"
+	"example/hello/internal/policy"
+	"example/hello/internal/securetoken"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"gorm.io/gorm"
+)
+
+// TokenPrefix membedakan API key dari JWT di header Authorization.
+const TokenPrefix = "pat_"
+
+const (
+	DefaultExpiryDays = 90
+	MaxExpiryDays     = 365
+	// MaxActiveKeysPerUser membatasi jumlah token aktif agar token lama dibersihkan.
+	MaxActiveKeysPerUser = 20
+)
+
+// touchInterval membatasi seberapa sering LastUsedAt ditulis ke database.
+const touchInterval = time.Minute
+
+var (
+	ErrInvalidAPIKey   = errors.New("API key tidak valid")
+	ErrAPIKeyExpired   = errors.New("API key sudah kadaluarsa")
+	ErrAPIKeyNotFound  = errors.New("API key tidak ditemukan")
+	ErrInvalidScope    = errors.New("scope tidak dikenal")
+	ErrInvalidExpiry   = fmt.Errorf("expires_in_days harus antara 1 dan %d", MaxExpiryDays)
+	ErrTooManyAPIKeys  = fmt.Errorf("maksimal %d API key aktif per user", MaxActiveKeysPerUser)
+	ErrOwnerNotAllowed = errors.New("pemilik API key tidak aktif")
+	ErrDuplicateScope  = errors.New("scope tidak boleh duplikat")
+)
+
+// CreatedKey dikembalikan sekali saat token dibuat; Token tidak bisa diambil lagi.
+type CreatedKey struct {
+	Key   APIKey
+	Token string
+}
+
+type Service interface {
+	Create(actor policy.Actor, req CreateRequest) (CreatedKey, error)
+	List(userID int) ([]APIKey, error)
+	Revoke(actor policy.Actor, ID int) error
+	// RevokeAllForUser mencabut semua API key aktif milik userID, misalnya
+	// setelah reset password atau "log out everywhere".
+	RevokeAllForUser(actor policy.Actor, userID int) (int, error)
+	// Authenticate dipanggil AuthMiddleware untuk token berawalan TokenPrefix
+	// dan sekaligus mencatat waktu terakhir token dipakai.
+	Authenticate(rawToken string, ip string) (Principal, error)
+}
+
+type service struct {
+	repository Repository
+	audit      audit.Recorder
+	cache      *cache.Cache
+}
+
+const keyCacheKeyPrefix = "api_key_"
+
+func NewService(repository Repository, auditRecorder audit.Recorder) *service {
+	// Seperti status sesi, hasil autentikasi hanya di-cache sebentar supaya
+	// pencabutan dan perubahan role dari instance lain cepat berlaku.
+	c := cache.New(30*time.Second, 10*time.Minute)
+	return &service{
+		repository: repository,
+		audit:      auditRecorder,
+		cache:      c,
+	}
+}
+
+func (s *service) Create(actor policy.Actor, req CreateRequest) (CreatedKey, error) {
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return CreatedKey{}, err
+	}
+
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = DefaultExpiryDays
+	}
+	if days < 1 || days > MaxExpiryDays {
+		return CreatedKey{}, ErrInvalidExpiry
+	}
+
+	now := time.Now()
+	active, err := s.repository.CountActiveByUser(actor.UserID, now)
+	if err != nil {
+		return CreatedKey{}, fmt.Errorf("gagal menghitung API key: %w", err)
+	}
+	if active >= MaxActiveKeysPerUser {
+		return CreatedKey{}, ErrTooManyAPIKeys
+	}
+
+	rawToken, err := generateToken()
+	if err != nil {
+		return CreatedKey{}, fmt.Errorf("gagal membuat API key: %w", err)
+	}
+
+	key, err := s.repository.Create(APIKey{
+		UserID:    actor.UserID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    rawToken[:len(TokenPrefix)+8],
+		TokenHash: securetoken.Hash(rawToken),
+		Scopes:    strings.Join(scopes, " "),
+		ExpiresAt: now.AddDate(0, 0, days),
+	})
+	if err != nil {
+		return CreatedKey{}, fmt.Errorf("gagal menyimpan API key: %w", err)
+	}
+
+	s.recordEvent(audit.ActionAPIKeyCreated, actor, key)
+	return CreatedKey{Key: key, Token: rawToken}, nil
+}
+
+func (s *service) List(userID int) ([]APIKey, error) {
+	keys, err := s.repository.FindActiveByUser(userID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil API key: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke mencabut token milik actor. Token milik user lain dianggap tidak ada.
+func (s *service) Revoke(actor policy.Actor, ID int) error {
+	key, err := s.repository.FindByID(ID)
+	if err != nil || key.UserID != actor.UserID || key.RevokedAt != nil {
+		return ErrAPIKeyNotFound
+	}
+	if err := s.repository.Revoke(ID, time.Now()); err != nil {
+		return fmt.Errorf("gagal mencabut API key: %w", err)
+	}
+	s.cache.Delete(keyCacheKeyPrefix + key.TokenHash)
+
+	s.recordEvent(audit.ActionAPIKeyRevoked, actor, key)
+	return nil
+}
+
+func (s *service) RevokeAllForUser(actor policy.Actor, userID int) (int, error) {
+	now := time.Now()
+	keys, err := s.repository.FindActiveByUser(userID, now)
+	if err != nil {
+		return 0, fmt.Errorf("gagal mengambil API key: %w", err)
+	}
+	for _, key := range keys {
+		if err := s.repository.Revoke(key.ID, now); err != nil {
+			return 0, fmt.Errorf("gagal mencabut API key: %w", err)
+		}
+		s.cache.Delete(keyCacheKeyPrefix + key.TokenHash)
+		s.recordEvent(audit.ActionAPIKeyRevoked, actor, key)
+	}
+	return len(keys), nil
+}
+
+func (s *service) Authenticate(rawToken string, ip string) (Principal, error) {
+	if !strings.HasPrefix(rawToken, TokenPrefix) {
+		return Principal{}, ErrInvalidAPIKey
+	}
+
+	hash := securetoken.Hash(rawToken)
+	cacheKey := keyCacheKeyPrefix + hash
+	if x, found := s.cache.Get(cacheKey); found {
+		principal := x.(Principal)
+		if principal.Key.ExpiresAt.Before(time.Now()) {
+			return Principal{}, ErrAPIKeyExpired
+		}
+		return principal, nil
+	}
+
+	key, err := s.repository.FindByHash(hash)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Principal{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return Principal{}, err
+	}
+	if key.RevokedAt != nil {
+		return Principal{}, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.ExpiresAt.Before(now) {
+		return Principal{}, ErrAPIKeyExpired
+	}
+
+	// Akun yang dijadwalkan dihapus tidak boleh diakses lewat token apa pun.
+	owner, err := s.repository.FindOwner(key.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Principal{}, ErrOwnerNotAllowed
+	}
+	if err != nil {
+		return Principal{}, err
+	}
+	if owner.DeletionScheduledAt != nil {
+		return Principal{}, ErrOwnerNotAllowed
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= touchInterval || key.LastUsedIP != ip {
+		if err := s.repository.Touch(key.ID, ip, now); err != nil {
+			log.Printf("Gagal memperbarui waktu terakhir API key %d: %v", key.ID, err)
+		}
+		key.LastUsedAt = &now
+		key.LastUsedIP = ip
+	}
+
+	principal := Principal{
+		Key:      key,
+		UserID:   owner.ID,
+		Role:     string(owner.Role),
+		Verified: owner.Verivied,
+	}
+	s.cache.Set(cacheKey, principal, cache.DefaultExpiration)
+	return principal, nil
+}
+
+func (s *service) recordEvent(action audit.Action, actor policy.Actor, key APIKey) {
+	s.audit.Record(audit.Entry{
+		ActorID:    actor.UserID,
+		Action:     action,
+		TargetType: "api_key",
+		TargetID:   strconv.Itoa(key.ID),
+		Origin:     audit.ActorOrigin(actor),
+		Metadata:   map[string]any{"name": key.Name, "prefix": key.Prefix, "scopes": key.Scopes},
+	})
+}
+
+// normalizeScopes memvalidasi scope yang diminta dan menolak duplikat.
+func normalizeScopes(requested []string) ([]string, error) {
+	seen := map[string]bool{}
+	scopes := []string{}
+	for _, raw := range requested {
+		scope := strings.TrimSpace(raw)
+		if !IsValidScope(Scope(scope)) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidScope, raw)
+		}
+		if seen[scope] {
+			return nil, ErrDuplicateScope
+		}
+		seen[scope] = true
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+	return scopes, nil
+}
+
+// generateToken membuat token acak 32 byte yang aman untuk URL, diawali TokenPrefix.
+func generateToken() (string, error) {
+	raw, err := securetoken.Generate()
+	if err != nil {
+		return "", err
+	}
+	return TokenPrefix + raw, nil
+}
@@ -0,0 +1,46 @@
+package audit
+
+// Action adalah nama aksi dengan format <area>.<kejadian>.
+type Action string
+
+const (
+	ActionLoginSucceeded     Action = "login.succeeded"
+	ActionLoginFailed        Action = "login.failed"
+	ActionMFAFailed          Action = "login.mfa_failed"
+	ActionAccountLocked      Action = "account.locked"
+	ActionAccountUnlocked    Action = "account.unlocked"
+	ActionRefreshTokenReuse  Action = "session.refresh_token_reused"
+	ActionSessionRevoked     Action = "session.revoked"
+	ActionAllSessionsRevoked Action = "session.revoked_all"
+
+	ActionAPIKeyCreated Action = "api_key.created"
+	ActionAPIKeyRevoked Action = "api_key.revoked"
+
+	ActionPasswordResetRequested Action = "password.reset_requested"
+	ActionPasswordReset          Action = "password.reset"
+	ActionPasswordChanged        Action = "password.changed"
+
+	ActionEmailChangeRequested Action = "email.change_requested"
+	ActionEmailChanged         Action = "email.changed"
+	ActionEmailChangeReverted  Action = "email.change_reverted"
+
+	ActionPhoneVerified Action = "phone.verified"
+
+	ActionMFAEnabled             Action = "mfa.enabled"
+	ActionMFADisabled            Action = "mfa.disabled"
+	ActionRecoveryCodesGenerated Action = "mfa.recovery_codes_generated"
+
+	ActionIdentityLinked   Action = "identity.linked"
+	ActionIdentityUnlinked Action = "identity.unlinked"
+
+	ActionUserRegistered        Action = "user.registered"
+	ActionUserUpdated           Action = "user.updated"
+	ActionUserRoleChanged       Action = "user.role_changed"
+	ActionUserDeletionScheduled Action = "user.deletion_scheduled"
+	ActionUserDeletionCancelled Action = "user.deletion_cancelled"
+	ActionUserPurged            Action = "user.purged"
+
+	ActionMatchCreated Action = "match.created"
+	ActionMatchUpdated Action = "match.updated"
+	ActionMatchDeleted Action = "match.deleted"
+)
@@ -0,0 +1,20 @@
+package audit
+
+import "time"
+
+// Event adalah satu baris audit log. Baris tidak pernah diubah atau dihapus oleh aplikasi.
+type Event struct {
+	ID         int64
+	ActorID    *int      `gorm:"index"` // NULL jika pelaku tidak diketahui (misal login gagal) atau sistem
+	Action     Action    `gorm:"type:varchar(64);not null;index"`
+	TargetType string    `gorm:"type:varchar(32)"`
+	TargetID   string    `gorm:"type:varchar(64)"`
+	IP         string    `gorm:"type:varchar(45)"`
+	UserAgent  string    `gorm:"type:varchar(255)"`
+	Metadata   string    `gorm:"type:json"` // Objek JSON, minimal "{}"
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+func (Event) TableName() string {
+	return "audit_events"
+}
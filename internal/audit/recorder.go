@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"encoding/json"
+	"example/hello/internal/policy"
+	"log"
+)
+
+// Origin adalah asal request: alamat IP dan user agent client.
+type Origin struct {
+	IP        string
+	UserAgent string
+}
+
+// ActorOrigin mengambil asal request yang dibawa actor dari handler.
+func ActorOrigin(actor policy.Actor) Origin {
+	return Origin{IP: actor.IP, UserAgent: actor.UserAgent}
+}
+
+// Entry adalah data yang dicatat service. ActorID 0 berarti pelaku tidak diketahui.
+type Entry struct {
+	ActorID    int
+	Action     Action
+	TargetType string
+	TargetID   string
+	Origin     Origin
+	Metadata   map[string]any
+}
+
+// Recorder dipakai service untuk mencatat kejadian. Kegagalan mencatat hanya
+// di-log agar aksi utama (misalnya login) tidak ikut gagal.
+type Recorder interface {
+	Record(entry Entry)
+}
+
+// NopRecorder mengabaikan semua entry, berguna untuk service yang dipakai tanpa audit log.
+type NopRecorder struct{}
+
+func (NopRecorder) Record(Entry) {}
+
+func (s *service) Record(entry Entry) {
+	event, err := toEvent(entry)
+	if err != nil {
+		log.Printf("Audit: gagal menyusun event %s: %v", entry.Action, err)
+		return
+	}
+	if _, err := s.repository.Create(event); err != nil {
+		log.Printf("Audit: gagal menyimpan event %s: %v", entry.Action, err)
+	}
+}
+
+func toEvent(entry Entry) (Event, error) {
+	metadata := "{}"
+	if len(entry.Metadata) > 0 {
+		b, err := json.Marshal(entry.Metadata)
+		if err != nil {
+			return Event{}, err
+		}
+		metadata = string(b)
+	}
+
+	event := Event{
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		IP:         entry.Origin.IP,
+		UserAgent:  truncate(entry.Origin.UserAgent, 255),
+		Metadata:   metadata,
+	}
+	if entry.ActorID != 0 {
+		actorID := entry.ActorID
+		event.ActorID = &actorID
+	}
+	return event, nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Filter membatasi event yang diambil. Field kosong tidak dipakai.
+type Filter struct {
+	ActorID  int
+	Action   Action
+	From     time.Time
+	To       time.Time
+	BeforeID int64 // Cursor: hanya event dengan ID lebih kecil
+	Limit    int
+}
+
+type Repository interface {
+	Create(event Event) (Event, error)
+	Find(filter Filter) ([]Event, error)
+	// Each memanggil fn untuk setiap event yang cocok, urut dari yang terlama,
+	// tanpa memuat semuanya ke memori.
+	Each(filter Filter, fn func(Event) error) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) Create(event Event) (Event, error) {
+	if err := r.db.Create(&event).Error; err != nil {
+		return Event{}, err
+	}
+	return event, nil
+}
+
+func (r *repository) Find(filter Filter) ([]Event, error) {
+	var events []Event
+	query := r.db.Scopes(filter.scope).Order("id desc")
+	if filter.BeforeID > 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if err := query.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *repository) Each(filter Filter, fn func(Event) error) error {
+	rows, err := r.db.Model(&Event{}).Scopes(filter.scope).Order("id asc").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event Event
+		if err := r.db.ScanRows(rows, &event); err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (f Filter) scope(db *gorm.DB) *gorm.DB {
+	if f.ActorID != 0 {
+		db = db.Where("actor_id = ?", f.ActorID)
+	}
+	if f.Action != "" {
+		db = db.Where("action = ?", f.Action)
+	}
+	if !f.From.IsZero() {
+		db = db.Where("created_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		db = db.Where("created_at < ?", f.To)
+	}
+	return db
+}
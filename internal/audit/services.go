@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Service interface {
+	Recorder
+	List(filter Filter) ([]Event, error)
+	// WriteCSV menulis semua event yang cocok dengan filter sebagai CSV.
+	WriteCSV(filter Filter, w io.Writer) error
+}
+
+type service struct {
+	repository Repository
+}
+
+func NewService(repository Repository) *service {
+	return &service{repository}
+}
+
+func (s *service) List(filter Filter) ([]Event, error) {
+	events, err := s.repository.Find(filter)
+	if err != nil {
+		return nil, fmt.Errorf("error finding audit events: %w", err)
+	}
+	return events, nil
+}
+
+func (s *service) WriteCSV(filter Filter, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"id", "created_at", "actor_id", "action", "target_type", "target_id", "ip", "user_agent", "metadata"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	err := s.repository.Each(filter, func(e Event) error {
+		actorID := ""
+		if e.ActorID != nil {
+			actorID = strconv.Itoa(*e.ActorID)
+		}
+		return cw.Write([]string{
+			strconv.FormatInt(e.ID, 10),
+			e.CreatedAt.UTC().Format(time.RFC3339),
+			actorID,
+			string(e.Action),
+			e.TargetType,
+			e.TargetID,
+			e.IP,
+			csvSafe(e.UserAgent),
+			e.Metadata,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("error exporting audit events: %w", err)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvSafe mencegah nilai dari client (user agent) dibaca
+// sebagai formula saat CSV dibuka di aplikasi spreadsheet.
+func csvSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
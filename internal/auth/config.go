@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyConfig berisi konfigurasi kunci JWT, biasanya dibaca dari environment
+// lewat KeyConfigFromEnv.
+type KeyConfig struct {
+	// Algorithm adalah algoritma kunci penandatangan: HS256 (default), RS256 atau EdDSA.
+	Algorithm string
+	// KeyID adalah kid kunci penandatangan. Jika kosong, HS256 memakai "default"
+	// dan kunci asimetris memakai thumbprint RFC 7638.
+	KeyID string
+	// Secret dipakai untuk HS256.
+	Secret string
+	// PrivateKeyFile adalah file PEM (PKCS#1 atau PKCS#8) untuk RS256/EdDSA.
+	PrivateKeyFile string
+	// VerificationKeys berisi kunci lama yang masih diterima, dengan format
+	// "kid=path/ke/kunci.pem" dipisah koma. File bisa berisi public atau private key.
+	VerificationKeys string
+	// PreviousSecrets berisi secret HS256 lama dengan format "kid=secret" dipisah koma.
+	PreviousSecrets string
+}
+
+// KeyConfigFromEnv membaca JWT_ALG, JWT_KEY_ID, JWT_SECRET, JWT_PRIVATE_KEY_FILE,
+// JWT_VERIFICATION_KEYS dan JWT_PREVIOUS_SECRETS.
+func KeyConfigFromEnv() KeyConfig {
+	return KeyConfig{
+		Algorithm:        os.Getenv("JWT_ALG"),
+		KeyID:            os.Getenv("JWT_KEY_ID"),
+		Secret:           os.Getenv("JWT_SECRET"),
+		PrivateKeyFile:   os.Getenv("JWT_PRIVATE_KEY_FILE"),
+		VerificationKeys: os.Getenv("JWT_VERIFICATION_KEYS"),
+		PreviousSecrets:  os.Getenv("JWT_PREVIOUS_SECRETS"),
+	}
+}
+
+// NewKeyProvider membangun KeyProvider dari konfigurasi. Error dikembalikan
+// (bukan log.Fatal) agar pemanggil yang memutuskan apakah aplikasi berhenti.
+func NewKeyProvider(cfg KeyConfig) (KeyProvider, error) {
+	var signing Key
+	switch strings.ToUpper(cfg.Algorithm) {
+	case "", "HS256":
+		if cfg.Secret == "" {
+			return nil, errors.New("JWT_SECRET wajib di-set untuk HS256")
+		}
+		kid := cfg.KeyID
+		if kid == "" {
+			kid = "default"
+		}
+		signing = NewHMACKey(kid, []byte(cfg.Secret))
+	case "RS256", "EDDSA":
+		if cfg.PrivateKeyFile == "" {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE wajib di-set untuk %s", cfg.Algorithm)
+		}
+		key, err := loadKeyFile(cfg.KeyID, cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if !key.CanSign() {
+			return nil, fmt.Errorf("%s tidak berisi private key", cfg.PrivateKeyFile)
+		}
+		if !strings.EqualFold(key.Method.Alg(), cfg.Algorithm) {
+			return nil, fmt.Errorf("%s berisi kunci %s, bukan %s", cfg.PrivateKeyFile, key.Method.Alg(), cfg.Algorithm)
+		}
+		signing = key
+	default:
+		return nil, fmt.Errorf("algoritma JWT %q tidak didukung", cfg.Algorithm)
+	}
+
+	var verification []Key
+	for _, entry := range splitList(cfg.VerificationKeys) {
+		kid, path := splitKeyValue(entry)
+		key, err := loadKeyFile(kid, path)
+		if err != nil {
+			return nil, err
+		}
+		verification = append(verification, key)
+	}
+	for _, entry := range splitList(cfg.PreviousSecrets) {
+		kid, secret := splitKeyValue(entry)
+		if kid == "" || secret == "" {
+			return nil, errors.New(`JWT_PREVIOUS_SECRETS harus berformat "kid=secret"`)
+		}
+		verification = append(verification, NewHMACKey(kid, []byte(secret)))
+	}
+
+	return NewStaticKeyProvider(signing, verification...)
+}
+
+// loadKeyFile membaca kunci RSA atau Ed25519 dari file PEM. Jika kid kosong,
+// thumbprint RFC 7638 dari public key dipakai sebagai kid.
+func loadKeyFile(kid, path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("gagal membaca kunci JWT %s: %w", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("%s bukan file PEM", path)
+	}
+
+	key, err := parsePEMKey(block)
+	if err != nil {
+		return Key{}, fmt.Errorf("gagal mem-parse kunci JWT %s: %w", path, err)
+	}
+
+	if kid == "" {
+		kid, err = Thumbprint(key)
+		if err != nil {
+			return Key{}, err
+		}
+	}
+	key.ID = kid
+	return key, nil
+}
+
+func parsePEMKey(block *pem.Block) (Key, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+		return NewRSAKey("", private), nil
+	case "RSA PUBLIC KEY":
+		public, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+		return NewRSAPublicKey("", public), nil
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+		switch private := parsed.(type) {
+		case *rsa.PrivateKey:
+			return NewRSAKey("", private), nil
+		case ed25519.PrivateKey:
+			return NewEd25519Key("", private), nil
+		}
+		return Key{}, fmt.Errorf("tipe private key %T tidak didukung", parsed)
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+		switch public := parsed.(type) {
+		case *rsa.PublicKey:
+			return NewRSAPublicKey("", public), nil
+		case ed25519.PublicKey:
+			return NewEd25519PublicKey("", public), nil
+		}
+		return Key{}, fmt.Errorf("tipe public key %T tidak didukung", parsed)
+	}
+	return Key{}, fmt.Errorf("blok PEM %q tidak didukung", block.Type)
+}
+
+// Thumbprint menghitung JWK thumbprint (RFC 7638, SHA-256) dari public key.
+func Thumbprint(key Key) (string, error) {
+	jwk, ok := publicJWK(key)
+	if !ok {
+		return "", errors.New("thumbprint hanya tersedia untuk kunci asimetris")
+	}
+
+	// Anggota wajib saja, urut abjad dan tanpa spasi, sesuai RFC 7638.
+	var members any
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// splitKeyValue memecah "kid=value". Tanpa tanda "=", seluruh entri dianggap value.
+func splitKeyValue(entry string) (string, string) {
+	kid, value, found := strings.Cut(entry, "=")
+	if !found {
+		return "", strings.TrimSpace(entry)
+	}
+	return strings.TrimSpace(kid), strings.TrimSpace(value)
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MFAChallengeTTL adalah waktu yang diberikan ke user untuk memasukkan kode 2FA setelah password benar.
+const MFAChallengeTTL = 5 * time.Minute
+
+// MFAClaims adalah klaim untuk token tantangan 2FA. Token ini hanya bisa ditukar
+// di endpoint verifikasi 2FA dan tidak diterima sebagai access token.
+type MFAClaims struct {
+	MFAUserID string `json:"mfa_user_id"`
+	jwt.RegisteredClaims
+}
+
+// GenerateMFAToken membuat token tantangan 2FA berumur pendek.
+func GenerateMFAToken(userID string) (string, error) {
+	claims := &MFAClaims{
+		MFAUserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(MFAChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   userID,
+			Issuer:    "MyApplication",
+		},
+	}
+
+	return signToken(claims)
+}
+
+// ValidateMFAToken memverifikasi token tantangan 2FA.
+func ValidateMFAToken(tokenString string) (*MFAClaims, error) {
+	token, err := parseToken(tokenString, &MFAClaims{})
+	if err != nil {
+		return nil, fmt.Errorf("validasi token gagal: %w", err)
+	}
+
+	claims, ok := token.Claims.(*MFAClaims)
+	if ok && token.Valid && claims.MFAUserID != "" {
+		return claims, nil
+	}
+	return nil, errors.New("token tidak valid")
+}
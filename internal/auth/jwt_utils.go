@@ -3,75 +3,58 @@ package auth
 import (
 	"errors"
 	"fmt"
-	"log"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/joho/godotenv"
+	"github.com/google/uuid"
 )
 
-// jwtSecret adalah kunci rahasia untuk menandatangani token.
-// Variabel ini diinisialisasi di dalam fungsi init().
-var jwtSecret []byte
-
-// init dieksekusi secara otomatis saat paket 'auth' diimpor.
-// Fungsi ini bertanggung jawab untuk memuat konfigurasi yang diperlukan.
-func init() {
-	// Memuat variabel dari file .env, berguna untuk pengembangan lokal.
-	if err := godotenv.Load(); err != nil {
-		log.Println("Peringatan: file .env tidak ditemukan, akan membaca environment variables dari sistem.")
-	}
-
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		log.Fatal("FATAL: Environment variable JWT_SECRET tidak di-set.")
-	}
-	jwtSecret = []byte(secret)
-}
+// AccessTokenTTL adalah masa berlaku access token. Dibuat singkat karena
+// sesi diperpanjang melalui refresh token (lihat paket session).
+const AccessTokenTTL = 15 * time.Minute
 
 // MyClaims mendefinisikan struktur klaim kustom untuk JWT kita.
 type MyClaims struct {
 	UserID   string `json:"user_id"`
 	Verified bool   `json:"verified"`
+	Role     string `json:"role"`
+	// SessionID (sid) menunjuk sesi login di paket session. Kosong untuk token lama.
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // GenerateToken membuat JWT baru.
 // Ini adalah "sign" token seperti di jsonwebtoken.sign()
-func GenerateToken(userID string, verified bool) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // Token berlaku 24 jam
+// Setiap token memiliki ID unik (jti) agar bisa dicabut sebelum kadaluarsa,
+// dan ditandatangani dengan kunci aktif dari KeyProvider (kid ada di header).
+func GenerateToken(userID string, verified bool, role string, sessionID string) (string, *MyClaims, error) {
+	expirationTime := time.Now().Add(AccessTokenTTL)
 
 	claims := &MyClaims{
-		UserID:   userID,
-		Verified: verified,
+		UserID:    userID,
+		Verified:  verified,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   userID,
 			Issuer:    "MyApplication",
+			ID:        uuid.New().String(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims) // Menggunakan HS256
-
-	tokenString, err := token.SignedString(jwtSecret) // Menandatangani token
+	tokenString, err := signToken(claims) // Menandatangani token
 	if err != nil {
-		return "", fmt.Errorf("failed to sign token: %w", err)
+		return "", nil, err
 	}
 
-	return tokenString, nil
+	return tokenString, claims, nil
 }
 
 // ValidateToken memverifikasi tanda tangan token dan mengurai klaim.
 func ValidateToken(tokenString string) (*MyClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &MyClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Pastikan algoritma penandatanganan adalah yang kita harapkan (HMAC).
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return jwtSecret, nil
-	})
+	token, err := parseToken(tokenString, &MyClaims{})
 
 	// Kita bisa langsung mengembalikannya untuk memberikan konteks yang lebih baik.
 	if err != nil {
@@ -80,6 +63,14 @@ func ValidateToken(tokenString string) (*MyClaims, error) {
 
 	claims, ok := token.Claims.(*MyClaims)
 	if ok && token.Valid {
+		// Token tanpa jti tidak bisa dicabut, jadi tidak kita terima.
+		if claims.ID == "" {
+			return nil, errors.New("token tidak memiliki ID (jti)")
+		}
+		// Token tantangan 2FA tidak memiliki user_id dan bukan access token.
+		if claims.UserID == "" {
+			return nil, errors.New("token tidak valid")
+		}
 		return claims, nil
 	}
 
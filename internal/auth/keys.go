@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrUnknownKey dikembalikan jika token ditandatangani dengan kid yang tidak dikenal.
+var ErrUnknownKey = errors.New("kunci penandatangan tidak dikenal")
+
+// Key adalah satu kunci JWT beserta algoritmanya. Kunci yang hanya berisi
+// public key (misalnya kunci lama saat rotasi) hanya bisa dipakai untuk verifikasi.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// signKey kosong untuk kunci yang hanya dipakai verifikasi.
+	signKey   any
+	verifyKey any
+}
+
+// NewHMACKey membuat kunci HS256 dari secret bersama.
+func NewHMACKey(kid string, secret []byte) Key {
+	return Key{ID: kid, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+// NewRSAKey membuat kunci RS256 untuk menandatangani dan memverifikasi.
+func NewRSAKey(kid string, private *rsa.PrivateKey) Key {
+	return Key{ID: kid, Method: jwt.SigningMethodRS256, signKey: private, verifyKey: &private.PublicKey}
+}
+
+// NewRSAPublicKey membuat kunci RS256 yang hanya bisa memverifikasi.
+func NewRSAPublicKey(kid string, public *rsa.PublicKey) Key {
+	return Key{ID: kid, Method: jwt.SigningMethodRS256, verifyKey: public}
+}
+
+// NewEd25519Key membuat kunci EdDSA (Ed25519) untuk menandatangani dan memverifikasi.
+func NewEd25519Key(kid string, private ed25519.PrivateKey) Key {
+	return Key{ID: kid, Method: jwt.SigningMethodEdDSA, signKey: private, verifyKey: private.Public()}
+}
+
+// NewEd25519PublicKey membuat kunci EdDSA yang hanya bisa memverifikasi.
+func NewEd25519PublicKey(kid string, public ed25519.PublicKey) Key {
+	return Key{ID: kid, Method: jwt.SigningMethodEdDSA, verifyKey: public}
+}
+
+// CanSign bernilai true jika kunci memiliki bagian privat/secret.
+func (k Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+// KeyProvider menyediakan kunci untuk menandatangani token baru dan semua kunci
+// yang masih diterima untuk verifikasi. Dengan beberapa kunci verifikasi aktif,
+// kunci penandatangan bisa dirotasi tanpa membuat token lama langsung tidak valid.
+type KeyProvider interface {
+	SigningKey() Key
+	VerificationKey(kid string) (Key, error)
+	VerificationKeys() []Key
+}
+
+type staticKeyProvider struct {
+	signing Key
+	keys    map[string]Key
+	order   []string
+}
+
+// NewStaticKeyProvider membuat KeyProvider dari satu kunci penandatangan dan
+// kunci verifikasi tambahan (kunci lama yang masih berlaku).
+func NewStaticKeyProvider(signing Key, verification ...Key) (KeyProvider, error) {
+	if !signing.CanSign() {
+		return nil, fmt.Errorf("kunci %q tidak bisa dipakai untuk menandatangani", signing.ID)
+	}
+
+	p := &staticKeyProvider{signing: signing, keys: make(map[string]Key)}
+	for _, key := range append([]Key{signing}, verification...) {
+		if key.ID == "" {
+			return nil, errors.New("setiap kunci JWT wajib memiliki kid")
+		}
+		if _, exists := p.keys[key.ID]; exists {
+			return nil, fmt.Errorf("kid %q dipakai lebih dari sekali", key.ID)
+		}
+		p.keys[key.ID] = key
+		p.order = append(p.order, key.ID)
+	}
+	return p, nil
+}
+
+func (p *staticKeyProvider) SigningKey() Key {
+	return p.signing
+}
+
+func (p *staticKeyProvider) VerificationKey(kid string) (Key, error) {
+	key, ok := p.keys[kid]
+	if !ok {
+		return Key{}, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+func (p *staticKeyProvider) VerificationKeys() []Key {
+	keys := make([]Key, 0, len(p.order))
+	for _, kid := range p.order {
+		keys = append(keys, p.keys[kid])
+	}
+	return keys
+}
+
+var (
+	providerMu sync.RWMutex
+	provider   KeyProvider
+)
+
+// SetKeyProvider mengatur KeyProvider yang dipakai paket ini. Dipanggil sekali
+// saat startup (lihat cmd/server) atau oleh test.
+func SetKeyProvider(p KeyProvider) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	provider = p
+}
+
+// CurrentKeyProvider mengembalikan KeyProvider yang sedang dipakai.
+func CurrentKeyProvider() (KeyProvider, error) {
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+	if provider == nil {
+		return nil, errors.New("key provider JWT belum dikonfigurasi")
+	}
+	return provider, nil
+}
+
+// signToken menandatangani klaim dengan kunci aktif dan menyertakan kid di header.
+func signToken(claims jwt.Claims) (string, error) {
+	p, err := CurrentKeyProvider()
+	if err != nil {
+		return "", err
+	}
+	key := p.SigningKey()
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	tokenString, err := token.SignedString(key.signKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return tokenString, nil
+}
+
+// parseToken memverifikasi token memakai kunci sesuai kid di header. Algoritma
+// token harus sama dengan algoritma kunci agar tidak terjadi algorithm confusion
+// (misalnya token HS256 yang "ditandatangani" dengan public key RSA).
+func parseToken(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	p, err := CurrentKeyProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		var key Key
+		if kid == "" {
+			// Token yang dibuat sebelum kid diperkenalkan diverifikasi dengan kunci aktif.
+			key = p.SigningKey()
+		} else {
+			key, err = p.VerificationKey(kid)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.verifyKey, nil
+	})
+}
+
+// JWK adalah representasi public key sesuai RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet adalah isi endpoint /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS mengembalikan semua kunci verifikasi asimetris sebagai JWK Set.
+// Kunci HMAC tidak pernah dipublikasikan karena secret-nya sama dengan kunci penandatangan.
+func PublicJWKS(p KeyProvider) JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range p.VerificationKeys() {
+		if jwk, ok := publicJWK(key); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+func publicJWK(key Key) (JWK, bool) {
+	b64 := base64.RawURLEncoding
+	switch public := key.verifyKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Method.Alg(),
+			N:   b64.EncodeToString(public.N.Bytes()),
+			E:   b64.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Method.Alg(),
+			Crv: "Ed25519",
+			X:   b64.EncodeToString(public),
+		}, true
+	}
+	return JWK{}, false
+}
@@ -0,0 +1,210 @@
+package book
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// CoverURLPrefix adalah prefix URL file sampul yang disimpan di folder CoverStore.
+const CoverURLPrefix = "/assets/covers/"
+
+const (
+	MaxCoverBytes     = 5 << 20
+	MinCoverDimension = 100
+	MaxCoverDimension = 4000
+	coverJPEGQuality  = 85
+)
+
+// CoverSize adalah satu ukuran thumbnail sampul, dibatasi lebarnya.
+type CoverSize struct {
+	Name  string
+	Width int
+}
+
+// CoverSizes adalah thumbnail yang dibuat untuk setiap sampul. Gambar yang
+// lebih kecil dari ukuran thumbnail tidak diperbesar.
+var CoverSizes = []CoverSize{
+	{Name: "small", Width: 160},
+	{Name: "medium", Width: 320},
+	{Name: "large", Width: 640},
+}
+
+var (
+	ErrCoverTooLarge      = fmt.Errorf("ukuran file sampul maksimal %d MB", MaxCoverBytes>>20)
+	ErrUnsupportedCover   = errors.New("sampul harus berupa gambar JPEG, PNG atau GIF")
+	ErrInvalidCoverSize   = fmt.Errorf("dimensi sampul harus antara %d dan %d piksel", MinCoverDimension, MaxCoverDimension)
+	ErrCoverConflict      = errors.New("sampul buku sedang diubah request lain, coba lagi")
+	errInvalidCoverKey    = errors.New("cover key tidak valid")
+	coverKeyPattern       = regexp.MustCompile(`^[0-9]+-[0-9a-f]{32}$`)
+	supportedCoverFormats = map[string]string{"image/jpeg": "jpeg", "image/png": "png", "image/gif": "gif"}
+)
+
+// CoverStore memproses dan menyimpan file sampul buku di satu folder.
+type CoverStore struct {
+	dir string
+}
+
+// NewCoverStore membuat CoverStore. dir adalah folder fisik untuk URL
+// CoverURLPrefix, biasanya "assets/covers".
+func NewCoverStore(dir string) *CoverStore {
+	return &CoverStore{dir: dir}
+}
+
+// Save memvalidasi gambar, meng-encode ulang sebagai JPEG (metadata seperti
+// EXIF ikut terbuang) beserta thumbnail-nya, lalu mengembalikan key file.
+// Tipe file ditentukan dari isinya, bukan dari nama atau Content-Type.
+func (s *CoverStore) Save(bookID int, r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxCoverBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > MaxCoverBytes {
+		return "", ErrCoverTooLarge
+	}
+
+	format, ok := supportedCoverFormats[http.DetectContentType(data)]
+	if !ok {
+		return "", ErrUnsupportedCover
+	}
+	// Dimensi diperiksa dari header sebelum decode agar gambar raksasa yang
+	// dikompresi kecil tidak menghabiskan memori.
+	config, configFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || configFormat != format {
+		return "", ErrUnsupportedCover
+	}
+	if config.Width < MinCoverDimension || config.Height < MinCoverDimension ||
+		config.Width > MaxCoverDimension || config.Height > MaxCoverDimension {
+		return "", ErrInvalidCoverSize
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", ErrUnsupportedCover
+	}
+
+	// Latar putih untuk gambar transparan karena JPEG tidak punya alpha.
+	bounds := decoded.Bounds()
+	original := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(original, original.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(original, original.Bounds(), decoded, bounds.Min, draw.Over)
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%d-%s", bookID, strings.ReplaceAll(uuid.NewString(), "-", ""))
+	if err := s.writeJPEG(coverFileName(key, ""), original); err != nil {
+		s.Remove(key)
+		return "", err
+	}
+	for _, size := range CoverSizes {
+		if err := s.writeJPEG(coverFileName(key, size.Name), resizeToWidth(original, size.Width)); err != nil {
+			s.Remove(key)
+			return "", err
+		}
+	}
+	return key, nil
+}
+
+// Remove menghapus sampul dan semua thumbnail-nya. File yang sudah tidak ada
+// tidak dianggap error.
+func (s *CoverStore) Remove(key string) error {
+	if !coverKeyPattern.MatchString(key) {
+		return errInvalidCoverKey
+	}
+	var errs []error
+	names := []string{coverFileName(key, "")}
+	for _, size := range CoverSizes {
+		names = append(names, coverFileName(key, size.Name))
+	}
+	for _, name := range names {
+		if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeJPEG menulis ke file sementara lalu rename agar URL tidak pernah
+// menunjuk ke file yang setengah jadi.
+func (s *CoverStore) writeJPEG(name string, img image.Image) error {
+	tmp, err := os.CreateTemp(s.dir, ".cover-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := jpeg.Encode(tmp, img, &jpeg.Options{Quality: coverJPEGQuality}); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, name))
+}
+
+func coverFileName(key, size string) string {
+	if size == "" {
+		return key + ".jpg"
+	}
+	return key + "-" + size + ".jpg"
+}
+
+// CoverURLs mengembalikan URL sampul asli dan thumbnail per nama ukuran.
+func CoverURLs(key string) (string, map[string]string) {
+	thumbnails := make(map[string]string, len(CoverSizes))
+	for _, size := range CoverSizes {
+		thumbnails[size.Name] = CoverURLPrefix + coverFileName(key, size.Name)
+	}
+	return CoverURLPrefix + coverFileName(key, ""), thumbnails
+}
+
+// resizeToWidth memperkecil gambar ke lebar width dengan rasio tetap. Setiap
+// piksel tujuan adalah rata-rata area piksel sumber yang diwakilinya.
+func resizeToWidth(src *image.RGBA, width int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if width >= sw {
+		return src
+	}
+	height := max(1, sh*width/sw)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, max((y+1)*sh/height, y*sh/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, max((x+1)*sw/width, x*sw/width+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				offset := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(src.Pix[offset])
+					g += uint64(src.Pix[offset+1])
+					b += uint64(src.Pix[offset+2])
+					a += uint64(src.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -8,7 +8,60 @@ type Book struct {
 	Price       int
 	Synopsis    string
 	Description string
-	Rating      int
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// Deprecated: Rating adalah penilaian editor lama yang tidak lagi bisa diubah
+	// lewat API; hanya dibaca untuk kompatibilitas. Gunakan RatingAverage.
+	Rating int
+	// RatingAverage dan ReviewCount diringkas dari book_reviews, hanya diubah
+	// lewat RefreshRatingSummary.
+	RatingAverage float64 `gorm:"type:decimal(3,2);not null;default:0"`
+	ReviewCount   int     `gorm:"not null;default:0"`
+	// ISBN disimpan sebagai ISBN-13 tanpa pemisah; nil untuk buku tanpa ISBN.
+	ISBN       *string     `gorm:"size:13;uniqueIndex"`
+	Authors    []Author    `gorm:"many2many:book_authors"`
+	Genres     []Genre     `gorm:"many2many:book_genres"`
+	Publishers []Publisher `gorm:"many2many:book_publishers"`
+	// CoverKey adalah nama dasar file sampul di CoverStore; kosong jika belum ada.
+	CoverKey  string `gorm:"size:64"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Author struct {
+	ID        int
+	Name      string `gorm:"size:150;not null;index"`
+	Bio       string `gorm:"type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Genre struct {
+	ID        int
+	Name      string `gorm:"size:80;not null;uniqueIndex"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Publisher struct {
+	ID        int
+	Name      string `gorm:"size:150;not null;uniqueIndex"`
+	Website   string `gorm:"size:255"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Review adalah ulasan satu user untuk satu buku.
+type Review struct {
+	ID      int
+	BookID  int    `gorm:"not null;uniqueIndex:idx_book_reviews_book_user"`
+	UserID  int    `gorm:"not null;uniqueIndex:idx_book_reviews_book_user;index"`
+	Rating  int    `gorm:"not null"`
+	Comment string `gorm:"type:text"`
+	// ReviewerName diisi dari tabel users saat membaca daftar ulasan.
+	ReviewerName string `gorm:"->;-:migration"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (Review) TableName() string {
+	return "book_reviews"
 }
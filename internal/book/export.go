@@ -0,0 +1,126 @@
+package book
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// exportBatchSize adalah jumlah buku yang dimuat per query saat ekspor.
+const exportBatchSize = 500
+
+// exportRow adalah satu baris NDJSON. Field-nya sama dengan BookRequest
+// ditambah data yang hanya dibaca, sehingga bisa diimpor kembali.
+type exportRow struct {
+	ID            int     `json:"id"`
+	ISBN          string  `json:"isbn,omitempty"`
+	Title         string  `json:"title"`
+	Price         int     `json:"price"`
+	Rating        int     `json:"rating"`
+	Synopsis      string  `json:"synopsis"`
+	Description   string  `json:"description"`
+	AuthorIDs     []int   `json:"author_ids"`
+	GenreIDs      []int   `json:"genre_ids"`
+	PublisherIDs  []int   `json:"publisher_ids"`
+	RatingAverage float64 `json:"rating_average"`
+	ReviewCount   int     `json:"review_count"`
+}
+
+func toExportRow(b Book) exportRow {
+	row := exportRow{
+		ID:            b.ID,
+		Title:         b.Title,
+		Price:         b.Price,
+		Rating:        b.Rating,
+		Synopsis:      b.Synopsis,
+		Description:   b.Description,
+		AuthorIDs:     make([]int, 0, len(b.Authors)),
+		GenreIDs:      make([]int, 0, len(b.Genres)),
+		PublisherIDs:  make([]int, 0, len(b.Publishers)),
+		RatingAverage: b.RatingAverage,
+		ReviewCount:   b.ReviewCount,
+	}
+	if b.ISBN != nil {
+		row.ISBN = *b.ISBN
+	}
+	for _, a := range b.Authors {
+		row.AuthorIDs = append(row.AuthorIDs, a.ID)
+	}
+	for _, g := range b.Genres {
+		row.GenreIDs = append(row.GenreIDs, g.ID)
+	}
+	for _, p := range b.Publishers {
+		row.PublisherIDs = append(row.PublisherIDs, p.ID)
+	}
+	return row
+}
+
+// Export menulis semua buku yang cocok dengan filter ke w tanpa memuat seluruh
+// tabel ke memori. Jika w punya method Flush (misal http.ResponseWriter), data
+// dikirim ke client setiap satu batch.
+func (s *service) Export(w io.Writer, format Format, filter Filter) error {
+	flusher, _ := w.(interface{ Flush() })
+	flushed := 0
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	var err error
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(exportColumns); err != nil {
+			return err
+		}
+		err = s.repository.Each(filter, exportBatchSize, func(b Book) error {
+			row := toExportRow(b)
+			if err := cw.Write([]string{
+				strconv.Itoa(row.ID),
+				row.ISBN,
+				csvSafe(row.Title),
+				strconv.Itoa(row.Price),
+				strconv.Itoa(row.Rating),
+				csvSafe(row.Synopsis),
+				csvSafe(row.Description),
+				joinIDs(row.AuthorIDs),
+				joinIDs(row.GenreIDs),
+				joinIDs(row.PublisherIDs),
+				strconv.FormatFloat(row.RatingAverage, 'f', 2, 64),
+				strconv.Itoa(row.ReviewCount),
+			}); err != nil {
+				return err
+			}
+			if flushed++; flushed%exportBatchSize == 0 {
+				cw.Flush()
+				flush()
+			}
+			return nil
+		})
+		cw.Flush()
+		if err == nil {
+			err = cw.Error()
+		}
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		err = s.repository.Each(filter, exportBatchSize, func(b Book) error {
+			if err := encoder.Encode(toExportRow(b)); err != nil {
+				return err
+			}
+			if flushed++; flushed%exportBatchSize == 0 {
+				flush()
+			}
+			return nil
+		})
+	default:
+		return ErrInvalidFormat
+	}
+	if err != nil {
+		return fmt.Errorf("error exporting books: %w", err)
+	}
+	flush()
+	return nil
+}
@@ -0,0 +1,543 @@
+package book
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// ImportMode menentukan apa yang terjadi jika sebagian baris tidak valid.
+type ImportMode string
+
+const (
+	// ImportAllOrNothing hanya menyimpan buku jika semua baris valid.
+	ImportAllOrNothing ImportMode = "all_or_nothing"
+	// ImportBestEffort menyimpan baris yang valid dan melaporkan sisanya.
+	ImportBestEffort ImportMode = "best_effort"
+)
+
+const (
+	MaxImportRows = 50000
+	// MaxReportedRowErrors membatasi ukuran laporan; baris gagal tetap dihitung.
+	MaxReportedRowErrors = 1000
+	importBatchSize      = 200
+	maxNDJSONLineBytes   = 1 << 20
+)
+
+var (
+	ErrInvalidImportMode = errors.New("mode harus all_or_nothing atau best_effort")
+	ErrInvalidHeader     = errors.New("header CSV tidak valid")
+	ErrTooManyRows       = fmt.Errorf("file berisi lebih dari %d baris", MaxImportRows)
+)
+
+func ParseImportMode(s string) (ImportMode, error) {
+	switch ImportMode(s) {
+	case "":
+		return ImportAllOrNothing, nil
+	case ImportAllOrNothing, ImportBestEffort:
+		return ImportMode(s), nil
+	}
+	return "", ErrInvalidImportMode
+}
+
+type ImportOptions struct {
+	Format Format
+	Mode   ImportMode
+	// DryRun memvalidasi semua baris (termasuk ISBN dan relasi) tanpa menyimpan.
+	DryRun bool
+}
+
+// RowError adalah kesalahan satu baris. Row adalah nomor baris di file,
+// termasuk header CSV.
+type RowError struct {
+	Row    int      `json:"row"`
+	Errors []string `json:"errors"`
+}
+
+type ImportReport struct {
+	Format          Format     `json:"format"`
+	Mode            ImportMode `json:"mode"`
+	DryRun          bool       `json:"dry_run"`
+	Rows            int        `json:"rows"`
+	Valid           int        `json:"valid"`
+	Imported        int        `json:"imported"`
+	Failed          int        `json:"failed"`
+	Errors          []RowError `json:"errors"`
+	ErrorsTruncated bool       `json:"errors_truncated"`
+	// RowLimitReached berarti best_effort berhenti di MaxImportRows; baris
+	// setelahnya tidak dibaca.
+	RowLimitReached bool `json:"row_limit_reached"`
+}
+
+func (r *ImportReport) fail(row int, messages ...string) {
+	r.Failed++
+	if len(r.Errors) >= MaxReportedRowErrors {
+		r.ErrorsTruncated = true
+		return
+	}
+	r.Errors = append(r.Errors, RowError{Row: row, Errors: messages})
+}
+
+// requestValidator memakai tag binding yang sama dengan handler, dengan nama
+// kolom dari tag json agar pesan cocok dengan header file.
+var requestValidator = func() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.Split(field.Tag.Get("json"), ",")[0]
+	})
+	return v
+}()
+
+func validateRequest(request BookRequest) []string {
+	err := requestValidator.Struct(request)
+	if err == nil {
+		return nil
+	}
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return []string{err.Error()}
+	}
+	messages := make([]string, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		messages = append(messages, fmt.Sprintf("Error pada kolom '%s', kondisi: '%s'", fieldErr.Field(), fieldErr.ActualTag()))
+	}
+	return messages
+}
+
+// rowReader membaca file impor satu baris data setiap kali.
+type rowReader interface {
+	// next mengembalikan io.EOF di akhir file. *rowError berarti hanya baris
+	// tersebut yang rusak; error lain menghentikan impor.
+	next() (int, BookRequest, error)
+}
+
+type rowError struct {
+	messages []string
+}
+
+func (e *rowError) Error() string {
+	return strings.Join(e.messages, "; ")
+}
+
+type csvRowReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVRowReader(r io.Reader) (*csvRowReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: file kosong", ErrInvalidHeader)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+
+	allowed := map[string]bool{}
+	for _, column := range exportColumns {
+		allowed[column] = true
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !allowed[name] {
+			return nil, fmt.Errorf("%w: kolom %q tidak dikenal", ErrInvalidHeader, name)
+		}
+		if _, dup := columns[name]; dup {
+			return nil, fmt.Errorf("%w: kolom %q muncul dua kali", ErrInvalidHeader, name)
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"title", "price"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: kolom %q wajib ada", ErrInvalidHeader, required)
+		}
+	}
+	return &csvRowReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvRowReader) next() (int, BookRequest, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return parseErr.StartLine, BookRequest{}, &rowError{[]string{parseErr.Err.Error()}}
+		}
+		return 0, BookRequest{}, err
+	}
+	line, _ := r.reader.FieldPos(0)
+
+	cell := func(name string) string {
+		i, ok := r.columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	var messages []string
+	number := func(name string) int {
+		v := cell(name)
+		if v == "" {
+			return 0
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("kolom '%s' harus bilangan bulat", name))
+		}
+		return n
+	}
+	ids := func(name string) []int {
+		v, err := splitIDs(cell(name))
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("kolom '%s' harus daftar ID dipisah '%s'", name, idSeparator))
+		}
+		return v
+	}
+
+	if len(record) != len(r.columns) {
+		messages = append(messages, fmt.Sprintf("jumlah kolom %d, seharusnya %d", len(record), len(r.columns)))
+	}
+	request := BookRequest{
+		Title:        csvUnsafe(cell("title")),
+		Price:        number("price"),
+		Synopsis:     csvUnsafe(cell("synopsis")),
+		Description:  csvUnsafe(cell("description")),
+		ISBN:         cell("isbn"),
+		AuthorIDs:    ids("author_ids"),
+		GenreIDs:     ids("genre_ids"),
+		PublisherIDs: ids("publisher_ids"),
+	}
+	if len(messages) > 0 {
+		return line, BookRequest{}, &rowError{messages}
+	}
+	return line, request, nil
+}
+
+type ndjsonRowReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newNDJSONRowReader(r io.Reader) *ndjsonRowReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLineBytes)
+	return &ndjsonRowReader{scanner: scanner}
+}
+
+// ndjsonRow menerima baris hasil ekspor: field yang hanya dibaca diabaikan,
+// field lain yang tidak dikenal dianggap salah ketik.
+type ndjsonRow struct {
+	BookRequest
+	ID            json.RawMessage `json:"id"`
+	Rating        json.RawMessage `json:"rating"`
+	RatingAverage json.RawMessage `json:"rating_average"`
+	ReviewCount   json.RawMessage `json:"review_count"`
+}
+
+func (r *ndjsonRowReader) next() (int, BookRequest, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		var row ndjsonRow
+		if err := decoder.Decode(&row); err != nil {
+			return r.line, BookRequest{}, &rowError{[]string{fmt.Sprintf("JSON tidak valid: %v", err)}}
+		}
+		if decoder.More() {
+			return r.line, BookRequest{}, &rowError{[]string{"satu baris hanya boleh berisi satu objek JSON"}}
+		}
+		return r.line, row.BookRequest, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return 0, BookRequest{}, err
+	}
+	return 0, BookRequest{}, io.EOF
+}
+
+// importer menyimpan cache ISBN dan relasi selama satu impor agar setiap
+// baris tidak perlu query ulang untuk ID yang sama.
+type importer struct {
+	opts       ImportOptions
+	report     *ImportReport
+	seenISBN   map[string]int
+	authors    map[int]*Author
+	genres     map[int]*Genre
+	publishers map[int]*Publisher
+	pending    []pendingBook
+}
+
+type pendingBook struct {
+	row  int
+	book Book
+}
+
+// Import membaca file katalog baris demi baris, memvalidasinya dengan aturan
+// yang sama seperti BookRequest dan menyimpannya per batch sesuai mode.
+// Error yang dikembalikan berarti file tidak bisa diproses sama sekali;
+// kesalahan per baris ada di ImportReport.
+func (s *service) Import(r io.Reader, opts ImportOptions) (ImportReport, error) {
+	report := ImportReport{Format: opts.Format, Mode: opts.Mode, DryRun: opts.DryRun, Errors: []RowError{}}
+
+	var rows rowReader
+	switch opts.Format {
+	case FormatCSV:
+		csvRows, err := newCSVRowReader(r)
+		if err != nil {
+			return report, err
+		}
+		rows = csvRows
+	case FormatNDJSON:
+		rows = newNDJSONRowReader(r)
+	default:
+		return report, ErrInvalidFormat
+	}
+
+	imp := &importer{
+		opts:       opts,
+		report:     &report,
+		seenISBN:   map[string]int{},
+		authors:    map[int]*Author{},
+		genres:     map[int]*Genre{},
+		publishers: map[int]*Publisher{},
+	}
+	if err := imp.run(s.repository, rows); err != nil {
+		return report, err
+	}
+	if opts.Mode == ImportBestEffort || opts.DryRun || report.Failed > 0 {
+		return report, nil
+	}
+
+	// all_or_nothing: seluruh file sudah divalidasi, jadi transaksi hanya
+	// dibuka untuk menyimpan dan tidak menunggu upload yang lambat.
+	err := s.repository.Transaction(func(txRepo Repository) error {
+		return imp.save(txRepo)
+	})
+	if err != nil {
+		report.Imported = 0
+	}
+	return report, err
+}
+
+func (imp *importer) run(repo Repository, rows rowReader) error {
+	err := imp.readAll(repo, rows)
+	// Kegagalan simpan best_effort baru diketahui saat batch disimpan, jadi
+	// laporan diurutkan ulang berdasarkan nomor baris.
+	sort.SliceStable(imp.report.Errors, func(i, j int) bool {
+		return imp.report.Errors[i].Row < imp.report.Errors[j].Row
+	})
+	return err
+}
+
+func (imp *importer) readAll(repo Repository, rows rowReader) error {
+	for {
+		line, request, err := rows.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var badRow *rowError
+		if err != nil && !errors.As(err, &badRow) {
+			return err
+		}
+
+		if imp.report.Rows >= MaxImportRows {
+			if imp.opts.Mode == ImportAllOrNothing {
+				return ErrTooManyRows
+			}
+			// best_effort menyimpan yang sudah dibaca dan melaporkan batasnya.
+			imp.report.RowLimitReached = true
+			imp.report.Errors = append(imp.report.Errors, RowError{Row: line, Errors: []string{ErrTooManyRows.Error() + ", baris ini dan setelahnya tidak diproses"}})
+			break
+		}
+		imp.report.Rows++
+		if badRow != nil {
+			imp.report.fail(line, badRow.messages...)
+			continue
+		}
+
+		book, messages, err := imp.prepare(repo, request)
+		if err != nil {
+			return err
+		}
+		if len(messages) > 0 {
+			imp.report.fail(line, messages...)
+			continue
+		}
+		imp.report.Valid++
+		if book.ISBN != nil {
+			imp.seenISBN[*book.ISBN] = line
+		}
+
+		if imp.opts.DryRun {
+			continue
+		}
+		// all_or_nothing baru menyimpan setelah seluruh file valid; setelah
+		// kegagalan pertama baris berikutnya hanya divalidasi.
+		if imp.opts.Mode == ImportAllOrNothing {
+			if imp.report.Failed == 0 {
+				imp.pending = append(imp.pending, pendingBook{row: line, book: book})
+			}
+			continue
+		}
+		imp.pending = append(imp.pending, pendingBook{row: line, book: book})
+		if len(imp.pending) >= importBatchSize {
+			imp.flush(repo)
+		}
+	}
+	if imp.opts.DryRun || imp.opts.Mode == ImportAllOrNothing {
+		return nil
+	}
+	imp.flush(repo)
+	return nil
+}
+
+// save menyimpan semua buku all_or_nothing yang sudah divalidasi per batch.
+// Batch yang gagal menggagalkan seluruh impor.
+func (imp *importer) save(repo Repository) error {
+	for start := 0; start < len(imp.pending); start += importBatchSize {
+		end := min(start+importBatchSize, len(imp.pending))
+		books := make([]Book, 0, end-start)
+		for _, p := range imp.pending[start:end] {
+			books = append(books, p.book)
+		}
+		if err := repo.CreateBatch(books); err != nil {
+			return err
+		}
+		imp.report.Imported += len(books)
+	}
+	imp.pending = nil
+	return nil
+}
+
+// flush menyimpan batch best_effort yang tertunda. Batch yang gagal diulang
+// per baris agar kegagalannya bisa ditunjuk ke baris tertentu.
+func (imp *importer) flush(repo Repository) {
+	if len(imp.pending) == 0 {
+		return
+	}
+	books := make([]Book, len(imp.pending))
+	for i, p := range imp.pending {
+		books[i] = p.book
+	}
+
+	if err := repo.CreateBatch(books); err == nil {
+		imp.report.Imported += len(books)
+	} else {
+		for _, p := range imp.pending {
+			if _, err := repo.Create(p.book); err != nil {
+				imp.report.Valid--
+				imp.report.fail(p.row, fmt.Sprintf("gagal disimpan: %v", err))
+				continue
+			}
+			imp.report.Imported++
+		}
+	}
+	imp.pending = imp.pending[:0]
+}
+
+// prepare memvalidasi satu baris seperti Create. messages berisi kesalahan
+// baris; error berarti database tidak bisa dihubungi.
+func (imp *importer) prepare(repo Repository, request BookRequest) (Book, []string, error) {
+	messages := validateRequest(request)
+	book := Book{
+		Title:       request.Title,
+		Price:       request.Price,
+		Synopsis:    request.Synopsis,
+		Description: request.Description,
+	}
+
+	if request.ISBN != "" {
+		isbn, err := NormalizeISBN(request.ISBN)
+		switch {
+		case err != nil:
+			messages = append(messages, err.Error())
+		case imp.seenISBN[isbn] > 0:
+			messages = append(messages, fmt.Sprintf("ISBN sama dengan baris %d", imp.seenISBN[isbn]))
+		default:
+			_, err := repo.FindByISBN(isbn)
+			if err == nil {
+				messages = append(messages, ErrDuplicateISBN.Error())
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return Book{}, nil, err
+			}
+			book.ISBN = &isbn
+		}
+	}
+
+	var err error
+	var missing []int
+	if book.Authors, missing, err = resolveIDs(imp.authors, request.AuthorIDs, repo.FindAuthorsByIDs, func(a Author) int { return a.ID }); err != nil {
+		return Book{}, nil, err
+	}
+	if len(missing) > 0 {
+		messages = append(messages, fmt.Sprintf("%v: %s", ErrAuthorNotFound, joinIDs(missing)))
+	}
+	if book.Genres, missing, err = resolveIDs(imp.genres, request.GenreIDs, repo.FindGenresByIDs, func(g Genre) int { return g.ID }); err != nil {
+		return Book{}, nil, err
+	}
+	if len(missing) > 0 {
+		messages = append(messages, fmt.Sprintf("%v: %s", ErrGenreNotFound, joinIDs(missing)))
+	}
+	if book.Publishers, missing, err = resolveIDs(imp.publishers, request.PublisherIDs, repo.FindPublishersByIDs, func(p Publisher) int { return p.ID }); err != nil {
+		return Book{}, nil, err
+	}
+	if len(missing) > 0 {
+		messages = append(messages, fmt.Sprintf("%v: %s", ErrPublisherNotFound, joinIDs(missing)))
+	}
+	return book, messages, nil
+}
+
+// resolveIDs mengambil relasi dari cache, lalu dari database untuk ID yang
+// belum pernah dilihat. ID yang tidak ada disimpan di cache sebagai nil.
+func resolveIDs[T any](cache map[int]*T, ids []int, find func([]int) ([]T, error), idOf func(T) int) ([]T, []int, error) {
+	ids = uniqueIDs(ids)
+	var unknown []int
+	for _, id := range ids {
+		if _, ok := cache[id]; !ok {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) > 0 {
+		found, err := find(unknown)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, id := range unknown {
+			cache[id] = nil
+		}
+		for i := range found {
+			cache[idOf(found[i])] = &found[i]
+		}
+	}
+
+	var resolved []T
+	var missing []int
+	for _, id := range ids {
+		if item := cache[id]; item != nil {
+			resolved = append(resolved, *item)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return resolved, missing, nil
+}
@@ -0,0 +1,76 @@
+package book
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidISBN = errors.New("ISBN harus ISBN-10 atau ISBN-13 dengan checksum yang benar")
+
+// NormalizeISBN memvalidasi ISBN-10 atau ISBN-13 (tanda hubung dan spasi
+// diabaikan) dan mengembalikannya sebagai ISBN-13 tanpa pemisah. ISBN-10
+// dikonversi ke ISBN-13 agar buku yang sama tidak bisa terdaftar dua kali
+// dengan format berbeda.
+func NormalizeISBN(raw string) (string, error) {
+	isbn := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(raw))
+
+	switch len(isbn) {
+	case 10:
+		if !validISBN10(isbn) {
+			return "", ErrInvalidISBN
+		}
+		body := "978" + isbn[:9]
+		return body + string(isbn13CheckDigit(body)), nil
+	case 13:
+		if !isDigits(isbn) || !(strings.HasPrefix(isbn, "978") || strings.HasPrefix(isbn, "979")) {
+			return "", ErrInvalidISBN
+		}
+		if isbn13CheckDigit(isbn[:12]) != isbn[12] {
+			return "", ErrInvalidISBN
+		}
+		return isbn, nil
+	}
+	return "", ErrInvalidISBN
+}
+
+// validISBN10 memeriksa checksum modulo 11; digit terakhir boleh 'X' (nilai 10).
+func validISBN10(isbn string) bool {
+	if !isDigits(isbn[:9]) {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += (10 - i) * int(isbn[i]-'0')
+	}
+	switch last := isbn[9]; {
+	case last == 'X':
+		sum += 10
+	case last >= '0' && last <= '9':
+		sum += int(last - '0')
+	default:
+		return false
+	}
+	return sum%11 == 0
+}
+
+// isbn13CheckDigit menghitung digit cek dari 12 digit pertama ISBN-13.
+func isbn13CheckDigit(body string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(body[i]-'0')
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package book
+
+import (
+	"errors"
+	"example/hello/internal/pagination"
+	"fmt"
+	"strings"
+)
+
+// Sort adalah urutan katalog: nama kolom, diawali "-" untuk urutan menurun.
+type Sort string
+
+const (
+	SortCreatedAtDesc Sort = "-created_at"
+	SortCreatedAtAsc  Sort = "created_at"
+	SortTitleAsc      Sort = "title"
+	SortTitleDesc     Sort = "-title"
+	SortPriceAsc      Sort = "price"
+	SortPriceDesc     Sort = "-price"
+	// SortRatingAsc dan SortRatingDesc mengurutkan menurut rata-rata ulasan pembaca.
+	SortRatingAsc  Sort = "rating"
+	SortRatingDesc Sort = "-rating"
+)
+
+// DefaultSort menampilkan buku terbaru lebih dulu.
+const DefaultSort = SortCreatedAtDesc
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	ErrInvalidSort   = errors.New("sort harus salah satu dari title, price, rating, created_at (awali '-' untuk menurun)")
+	ErrInvalidCursor = pagination.ErrInvalidCursor
+	ErrInvalidQuery  = errors.New("parameter pencarian tidak valid")
+)
+
+func (s Sort) column() (string, bool, error) {
+	switch s {
+	case SortRatingAsc, SortRatingDesc:
+		return "rating_average", s == SortRatingDesc, nil
+	case SortCreatedAtAsc, SortCreatedAtDesc, SortTitleAsc, SortTitleDesc,
+		SortPriceAsc, SortPriceDesc:
+		return strings.TrimPrefix(string(s), "-"), strings.HasPrefix(string(s), "-"), nil
+	}
+	return "", false, ErrInvalidSort
+}
+
+// Filter membatasi buku yang ditampilkan. Field nil atau kosong tidak dipakai.
+type Filter struct {
+	MinPrice *int
+	MaxPrice *int
+	// MinRating dan MaxRating membatasi rata-rata ulasan pembaca (0-5).
+	MinRating *float64
+	MaxRating *float64
+	AuthorID  *int
+	GenreID   *int
+	// Keyword dicari di judul, sinopsis dan deskripsi. Setiap kata harus
+	// muncul di salah satu kolom tersebut.
+	Keyword string
+}
+
+// ListQuery adalah permintaan satu halaman katalog. Halaman dipilih lewat Page
+// (offset) atau Cursor (keyset, dari NextCursor halaman sebelumnya), tidak keduanya.
+type ListQuery struct {
+	Filter Filter
+	Sort   Sort
+	Page   int
+	Cursor string
+	Limit  int
+}
+
+// Spec adalah query yang sudah divalidasi untuk Repository.FindBySpec.
+// AfterID > 0 berarti keyset: hanya baris setelah (AfterValue, AfterID)
+// menurut Column dan arah urutan. Selain itu Offset yang dipakai.
+type Spec struct {
+	Filter     Filter
+	Column     string
+	Descending bool
+	Offset     int
+	AfterValue any
+	AfterID    int
+	Limit      int
+}
+
+// Page adalah satu halaman katalog beserta metadata paging.
+type Page struct {
+	Books      []Book
+	Total      int64
+	Page       int // 0 jika halaman dipilih dengan cursor
+	Limit      int
+	TotalPages int
+	HasNext    bool
+	NextCursor string
+}
+
+// cursorValue mengembalikan nilai kolom sort buku untuk cursor.
+func cursorValue(column string, b Book) any {
+	switch column {
+	case "created_at":
+		return b.CreatedAt
+	case "price":
+		return b.Price
+	case "rating_average":
+		return b.RatingAverage
+	}
+	return b.Title
+}
+
+func encodeCursor(sort Sort, column string, last Book) string {
+	return pagination.Encode(string(sort), cursorValue(column, last), last.ID)
+}
+
+// decodeCursor mengembalikan nilai kolom dalam tipe yang sesuai untuk query.
+func decodeCursor(raw string, sort Sort, column string) (any, int, error) {
+	c, err := pagination.Decode(raw, string(sort))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var value any
+	switch column {
+	case "created_at":
+		value, err = c.Time()
+	case "price":
+		value, err = c.Int()
+	case "rating_average":
+		value, err = c.Float()
+	default:
+		value = c.Value
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return value, c.ID, nil
+}
+
+// toSpec memvalidasi ListQuery dan menyusun Spec untuk repository.
+func (q ListQuery) toSpec() (Spec, error) {
+	if q.Sort == "" {
+		q.Sort = DefaultSort
+	}
+	column, descending, err := q.Sort.column()
+	if err != nil {
+		return Spec{}, err
+	}
+
+	f := q.Filter
+	if f.MinPrice != nil && f.MaxPrice != nil && *f.MinPrice > *f.MaxPrice {
+		return Spec{}, fmt.Errorf("%w: min_price lebih besar dari max_price", ErrInvalidQuery)
+	}
+	if f.MinRating != nil && f.MaxRating != nil && *f.MinRating > *f.MaxRating {
+		return Spec{}, fmt.Errorf("%w: min_rating lebih besar dari max_rating", ErrInvalidQuery)
+	}
+	if q.Cursor != "" && q.Page > 1 {
+		return Spec{}, fmt.Errorf("%w: gunakan page atau cursor, tidak keduanya", ErrInvalidQuery)
+	}
+
+	spec := Spec{
+		Filter:     f,
+		Column:     column,
+		Descending: descending,
+		Limit:      q.Limit,
+	}
+	if q.Cursor != "" {
+		spec.AfterValue, spec.AfterID, err = decodeCursor(q.Cursor, q.Sort, column)
+		if err != nil {
+			return Spec{}, err
+		}
+	} else if spec.Offset, err = pagination.Offset(q.Page, q.Limit); err != nil {
+		return Spec{}, fmt.Errorf("%w: %w", ErrInvalidQuery, err)
+	}
+	return spec, nil
+}
@@ -0,0 +1,151 @@
+package book
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAuthorNotFound     = errors.New("penulis tidak ditemukan")
+	ErrGenreNotFound      = errors.New("genre tidak ditemukan")
+	ErrPublisherNotFound  = errors.New("penerbit tidak ditemukan")
+	ErrDuplicateGenre     = errors.New("genre dengan nama ini sudah ada")
+	ErrDuplicatePublisher = errors.New("penerbit dengan nama ini sudah ada")
+)
+
+// notFound menerjemahkan gorm.ErrRecordNotFound ke error domain relasi.
+func notFound(err, domainErr error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domainErr
+	}
+	return err
+}
+
+func (s *service) FindAuthors() ([]Author, error) {
+	return s.repository.FindAuthors()
+}
+
+func (s *service) FindAuthorByID(ID int) (Author, error) {
+	author, err := s.repository.FindAuthorByID(ID)
+	if err != nil {
+		return Author{}, notFound(err, ErrAuthorNotFound)
+	}
+	return author, nil
+}
+
+func (s *service) CreateAuthor(request AuthorRequest) (Author, error) {
+	return s.repository.SaveAuthor(Author{
+		Name: strings.TrimSpace(request.Name),
+		Bio:  strings.TrimSpace(request.Bio),
+	})
+}
+
+func (s *service) UpdateAuthor(ID int, request AuthorRequest) (Author, error) {
+	author, err := s.FindAuthorByID(ID)
+	if err != nil {
+		return Author{}, err
+	}
+	author.Name = strings.TrimSpace(request.Name)
+	author.Bio = strings.TrimSpace(request.Bio)
+	return s.repository.SaveAuthor(author)
+}
+
+func (s *service) DeleteAuthor(ID int) error {
+	if _, err := s.FindAuthorByID(ID); err != nil {
+		return err
+	}
+	return s.repository.DeleteAuthor(ID)
+}
+
+func (s *service) FindGenres() ([]Genre, error) {
+	return s.repository.FindGenres()
+}
+
+func (s *service) FindGenreByID(ID int) (Genre, error) {
+	genre, err := s.repository.FindGenreByID(ID)
+	if err != nil {
+		return Genre{}, notFound(err, ErrGenreNotFound)
+	}
+	return genre, nil
+}
+
+func (s *service) CreateGenre(request GenreRequest) (Genre, error) {
+	return s.saveGenre(Genre{}, request)
+}
+
+func (s *service) UpdateGenre(ID int, request GenreRequest) (Genre, error) {
+	genre, err := s.FindGenreByID(ID)
+	if err != nil {
+		return Genre{}, err
+	}
+	return s.saveGenre(genre, request)
+}
+
+// saveGenre memastikan nama genre unik sebelum disimpan.
+func (s *service) saveGenre(genre Genre, request GenreRequest) (Genre, error) {
+	name := strings.TrimSpace(request.Name)
+	existing, err := s.repository.FindGenreByName(name)
+	if err == nil && existing.ID != genre.ID {
+		return Genre{}, ErrDuplicateGenre
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return Genre{}, err
+	}
+	genre.Name = name
+	return s.repository.SaveGenre(genre)
+}
+
+func (s *service) DeleteGenre(ID int) error {
+	if _, err := s.FindGenreByID(ID); err != nil {
+		return err
+	}
+	return s.repository.DeleteGenre(ID)
+}
+
+func (s *service) FindPublishers() ([]Publisher, error) {
+	return s.repository.FindPublishers()
+}
+
+func (s *service) FindPublisherByID(ID int) (Publisher, error) {
+	publisher, err := s.repository.FindPublisherByID(ID)
+	if err != nil {
+		return Publisher{}, notFound(err, ErrPublisherNotFound)
+	}
+	return publisher, nil
+}
+
+func (s *service) CreatePublisher(request PublisherRequest) (Publisher, error) {
+	return s.savePublisher(Publisher{}, request)
+}
+
+func (s *service) UpdatePublisher(ID int, request PublisherRequest) (Publisher, error) {
+	publisher, err := s.FindPublisherByID(ID)
+	if err != nil {
+		return Publisher{}, err
+	}
+	return s.savePublisher(publisher, request)
+}
+
+// savePublisher memastikan nama penerbit unik sebelum disimpan.
+func (s *service) savePublisher(publisher Publisher, request PublisherRequest) (Publisher, error) {
+	name := strings.TrimSpace(request.Name)
+	existing, err := s.repository.FindPublisherByName(name)
+	if err == nil && existing.ID != publisher.ID {
+		return Publisher{}, ErrDuplicatePublisher
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return Publisher{}, err
+	}
+	publisher.Name = name
+	publisher.Website = strings.TrimSpace(request.Website)
+	return s.repository.SavePublisher(publisher)
+}
+
+func (s *service) DeletePublisher(ID int) error {
+	if _, err := s.FindPublisherByID(ID); err != nil {
+		return err
+	}
+	return s.repository.DeletePublisher(ID)
+}
@@ -1,15 +1,61 @@
 package book
 
 import (
+	"example/hello/internal/pagination"
+	"strings"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
-	FindAll() ([]Book, error)
+	// FindBySpec mengambil satu halaman buku sesuai Spec beserta jumlah semua
+	// buku yang cocok dengan filter-nya.
+	FindBySpec(spec Spec) ([]Book, int64, error)
 	FIndByID(ID int) (Book, error)
+	FindByISBN(isbn string) (Book, error)
 	Create(book Book) (Book, error)
+	// UpdateCover mengganti cover_key hanya jika nilainya masih oldKey. false
+	// berarti buku sudah dihapus atau sampulnya diganti request lain.
+	UpdateCover(ID int, oldKey, newKey string) (bool, error)
+	// CreateBatch menyimpan banyak buku sekaligus dalam satu transaksi.
+	CreateBatch(books []Book) error
 	Update(book Book) (Book, error)
 	Delete(ID int) error
+	// Each memanggil fn untuk setiap buku yang cocok dengan filter, urut ID,
+	// dengan memuat batchSize buku (beserta relasinya) per query.
+	Each(filter Filter, batchSize int, fn func(Book) error) error
+	// Transaction menjalankan fn dalam satu transaksi database.
+	Transaction(fn func(txRepo Repository) error) error
+
+	FindAuthors() ([]Author, error)
+	FindAuthorByID(ID int) (Author, error)
+	FindAuthorsByIDs(IDs []int) ([]Author, error)
+	SaveAuthor(author Author) (Author, error)
+	DeleteAuthor(ID int) error
+
+	FindGenres() ([]Genre, error)
+	FindGenreByID(ID int) (Genre, error)
+	FindGenreByName(name string) (Genre, error)
+	FindGenresByIDs(IDs []int) ([]Genre, error)
+	SaveGenre(genre Genre) (Genre, error)
+	DeleteGenre(ID int) error
+
+	FindPublishers() ([]Publisher, error)
+	FindPublisherByID(ID int) (Publisher, error)
+	FindPublisherByName(name string) (Publisher, error)
+	FindPublishersByIDs(IDs []int) ([]Publisher, error)
+	SavePublisher(publisher Publisher) (Publisher, error)
+	DeletePublisher(ID int) error
+
+	FindReviews(bookID, offset, limit int) ([]Review, int64, error)
+	FindReview(bookID, userID int) (Review, error)
+	FindReviewByID(ID int) (Review, error)
+	// CreateReview, UpdateReview dan DeleteReview memperbarui ringkasan
+	// rating buku dalam transaksi yang sama.
+	CreateReview(review Review) (Review, error)
+	UpdateReview(review Review) (Review, error)
+	DeleteReview(review Review) error
 }
 
 type repository struct {
@@ -20,39 +66,386 @@ func NewRepository(db *gorm.DB) *repository {
 	return &repository{db}
 }
 
-func (r *repository) FindAll() ([]Book, error) {
+func (r *repository) FindBySpec(spec Spec) ([]Book, int64, error) {
+	var total int64
+	if err := r.db.Model(&Book{}).Scopes(spec.Filter.scope).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Column sudah divalidasi service, jadi aman disusun ke SQL.
+	keyset := pagination.Keyset{Column: spec.Column, Descending: spec.Descending}
+	query := r.db.Scopes(spec.Filter.scope)
+	if spec.AfterID > 0 {
+		query = query.Scopes(keyset.After(spec.AfterValue, spec.AfterID))
+	} else if spec.Offset > 0 {
+		query = query.Offset(spec.Offset)
+	}
+
 	var books []Book
-	if err := r.db.Find(&books).Error; err != nil {
-		return nil, err
+	err := query.Scopes(preloadRelations).
+		Order(keyset.Order()).
+		Limit(spec.Limit).
+		Find(&books).Error
+	if err != nil {
+		return nil, 0, err
 	}
-	return books, nil
+	return books, total, nil
+}
+
+// scope menerapkan filter katalog ke query.
+func (f Filter) scope(db *gorm.DB) *gorm.DB {
+	if f.MinPrice != nil {
+		db = db.Where("price >= ?", *f.MinPrice)
+	}
+	if f.MaxPrice != nil {
+		db = db.Where("price <= ?", *f.MaxPrice)
+	}
+	if f.MinRating != nil {
+		db = db.Where("rating_average >= ?", *f.MinRating)
+	}
+	if f.MaxRating != nil {
+		db = db.Where("rating_average <= ?", *f.MaxRating)
+	}
+	if f.AuthorID != nil {
+		db = db.Where("id IN (SELECT book_id FROM book_authors WHERE author_id = ?)", *f.AuthorID)
+	}
+	if f.GenreID != nil {
+		db = db.Where("id IN (SELECT book_id FROM book_genres WHERE genre_id = ?)", *f.GenreID)
+	}
+	for _, word := range strings.Fields(f.Keyword) {
+		pattern := "%" + pagination.EscapeLike(word) + "%"
+		db = db.Where("(title LIKE ? OR synopsis LIKE ? OR description LIKE ?)", pattern, pattern, pattern)
+	}
+	return db
+}
+
+// preloadRelations memuat penulis, genre dan penerbit buku.
+func preloadRelations(db *gorm.DB) *gorm.DB {
+	return db.Preload("Authors").Preload("Genres").Preload("Publishers")
 }
 
 func (r *repository) FIndByID(ID int) (Book, error) {
 	var book Book
-	if err := r.db.First(&book, ID).Error; err != nil {
+	if err := r.db.Scopes(preloadRelations).First(&book, ID).Error; err != nil {
+		return Book{}, err
+	}
+	return book, nil
+}
+
+func (r *repository) FindByISBN(isbn string) (Book, error) {
+	var book Book
+	if err := r.db.Where("isbn = ?", isbn).First(&book).Error; err != nil {
 		return Book{}, err
 	}
 	return book, nil
 }
 
+// Create menyimpan buku beserta baris join relasinya. Penulis, genre dan
+// penerbit sudah ada di database, jadi tidak ikut disimpan ulang.
 func (r *repository) Create(book Book) (Book, error) {
-	if err := r.db.Create(&book).Error; err != nil {
+	if err := r.db.Omit("Authors.*", "Genres.*", "Publishers.*").Create(&book).Error; err != nil {
 		return Book{}, err
 	}
 	return book, nil
 }
 
+func (r *repository) UpdateCover(ID int, oldKey, newKey string) (bool, error) {
+	result := r.db.Model(&Book{}).Where("id = ? AND cover_key = ?", ID, oldKey).Update("cover_key", newKey)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *repository) CreateBatch(books []Book) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Omit("Authors.*", "Genres.*", "Publishers.*").Create(&books).Error
+	})
+}
+
+func (r *repository) Each(filter Filter, batchSize int, fn func(Book) error) error {
+	var batch []Book
+	return r.db.Scopes(filter.scope, preloadRelations).
+		FindInBatches(&batch, batchSize, func(_ *gorm.DB, _ int) error {
+			for _, b := range batch {
+				if err := fn(b); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+func (r *repository) Transaction(fn func(txRepo Repository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewRepository(tx))
+	})
+}
+
+// Update menyimpan buku dan mengganti seluruh relasinya dengan isi book.
 func (r *repository) Update(book Book) (Book, error) {
-	if err := r.db.Save(&book).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Ringkasan rating dan sampul tidak ikut disimpan agar ulasan atau
+		// upload sampul yang masuk di antara FIndByID dan Save tidak tertimpa.
+		if err := tx.Omit(clause.Associations, "Rating", "RatingAverage", "ReviewCount", "CoverKey").Save(&book).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&book).Omit("Authors.*").Association("Authors").Replace(book.Authors); err != nil {
+			return err
+		}
+		if err := tx.Model(&book).Omit("Genres.*").Association("Genres").Replace(book.Genres); err != nil {
+			return err
+		}
+		return tx.Model(&book).Omit("Publishers.*").Association("Publishers").Replace(book.Publishers)
+	})
+	if err != nil {
 		return Book{}, err
 	}
 	return book, nil
 }
 
+// Delete menghapus buku beserta baris join relasi dan ulasannya.
 func (r *repository) Delete(ID int) error {
-	if err := r.db.Delete(&Book{}, ID).Error; err != nil {
-		return err
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("book_id = ?", ID).Delete(&Review{}).Error; err != nil {
+			return err
+		}
+		return tx.Select(clause.Associations).Delete(&Book{ID: ID}).Error
+	})
+}
+
+func (r *repository) FindAuthors() ([]Author, error) {
+	var authors []Author
+	if err := r.db.Order("name asc, id asc").Find(&authors).Error; err != nil {
+		return nil, err
+	}
+	return authors, nil
+}
+
+func (r *repository) FindAuthorByID(ID int) (Author, error) {
+	var author Author
+	if err := r.db.First(&author, ID).Error; err != nil {
+		return Author{}, err
+	}
+	return author, nil
+}
+
+func (r *repository) FindAuthorsByIDs(IDs []int) ([]Author, error) {
+	var authors []Author
+	if err := r.db.Where("id IN ?", IDs).Find(&authors).Error; err != nil {
+		return nil, err
+	}
+	return authors, nil
+}
+
+func (r *repository) SaveAuthor(author Author) (Author, error) {
+	if err := r.db.Save(&author).Error; err != nil {
+		return Author{}, err
+	}
+	return author, nil
+}
+
+// DeleteAuthor menghapus penulis dan melepasnya dari semua buku.
+func (r *repository) DeleteAuthor(ID int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM book_authors WHERE author_id = ?", ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Author{}, ID).Error
+	})
+}
+
+func (r *repository) FindGenres() ([]Genre, error) {
+	var genres []Genre
+	if err := r.db.Order("name asc").Find(&genres).Error; err != nil {
+		return nil, err
 	}
-	return nil
+	return genres, nil
+}
+
+func (r *repository) FindGenreByID(ID int) (Genre, error) {
+	var genre Genre
+	if err := r.db.First(&genre, ID).Error; err != nil {
+		return Genre{}, err
+	}
+	return genre, nil
+}
+
+func (r *repository) FindGenreByName(name string) (Genre, error) {
+	var genre Genre
+	if err := r.db.Where("name = ?", name).First(&genre).Error; err != nil {
+		return Genre{}, err
+	}
+	return genre, nil
+}
+
+func (r *repository) FindGenresByIDs(IDs []int) ([]Genre, error) {
+	var genres []Genre
+	if err := r.db.Where("id IN ?", IDs).Find(&genres).Error; err != nil {
+		return nil, err
+	}
+	return genres, nil
+}
+
+func (r *repository) SaveGenre(genre Genre) (Genre, error) {
+	if err := r.db.Save(&genre).Error; err != nil {
+		return Genre{}, err
+	}
+	return genre, nil
+}
+
+// DeleteGenre menghapus genre dan melepasnya dari semua buku.
+func (r *repository) DeleteGenre(ID int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM book_genres WHERE genre_id = ?", ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Genre{}, ID).Error
+	})
+}
+
+func (r *repository) FindPublishers() ([]Publisher, error) {
+	var publishers []Publisher
+	if err := r.db.Order("name asc").Find(&publishers).Error; err != nil {
+		return nil, err
+	}
+	return publishers, nil
+}
+
+func (r *repository) FindPublisherByID(ID int) (Publisher, error) {
+	var publisher Publisher
+	if err := r.db.First(&publisher, ID).Error; err != nil {
+		return Publisher{}, err
+	}
+	return publisher, nil
+}
+
+func (r *repository) FindPublisherByName(name string) (Publisher, error) {
+	var publisher Publisher
+	if err := r.db.Where("name = ?", name).First(&publisher).Error; err != nil {
+		return Publisher{}, err
+	}
+	return publisher, nil
+}
+
+func (r *repository) FindPublishersByIDs(IDs []int) ([]Publisher, error) {
+	var publishers []Publisher
+	if err := r.db.Where("id IN ?", IDs).Find(&publishers).Error; err != nil {
+		return nil, err
+	}
+	return publishers, nil
+}
+
+func (r *repository) SavePublisher(publisher Publisher) (Publisher, error) {
+	if err := r.db.Save(&publisher).Error; err != nil {
+		return Publisher{}, err
+	}
+	return publisher, nil
+}
+
+// DeletePublisher menghapus penerbit dan melepasnya dari semua buku.
+func (r *repository) DeletePublisher(ID int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM book_publishers WHERE publisher_id = ?", ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Publisher{}, ID).Error
+	})
+}
+
+func (r *repository) FindReviews(bookID, offset, limit int) ([]Review, int64, error) {
+	var total int64
+	if err := r.db.Model(&Review{}).Where("book_id = ?", bookID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []Review
+	err := r.db.Select("book_reviews.*, users.name AS reviewer_name").
+		Joins("LEFT JOIN users ON users.id = book_reviews.user_id").
+		Where("book_reviews.book_id = ?", bookID).
+		Order("book_reviews.created_at desc, book_reviews.id desc").
+		Offset(offset).
+		Limit(limit).
+		Find(&reviews).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+func (r *repository) FindReview(bookID, userID int) (Review, error) {
+	var review Review
+	if err := r.db.Where("book_id = ? AND user_id = ?", bookID, userID).First(&review).Error; err != nil {
+		return Review{}, err
+	}
+	return review, nil
+}
+
+func (r *repository) FindReviewByID(ID int) (Review, error) {
+	var review Review
+	if err := r.db.First(&review, ID).Error; err != nil {
+		return Review{}, err
+	}
+	return review, nil
+}
+
+func (r *repository) CreateReview(review Review) (Review, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBook(tx, review.BookID); err != nil {
+			return err
+		}
+		if err := tx.Create(&review).Error; err != nil {
+			return err
+		}
+		return RefreshRatingSummary(tx, review.BookID)
+	})
+	if err != nil {
+		return Review{}, err
+	}
+	return review, nil
+}
+
+func (r *repository) UpdateReview(review Review) (Review, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBook(tx, review.BookID); err != nil {
+			return err
+		}
+		if err := tx.Save(&review).Error; err != nil {
+			return err
+		}
+		return RefreshRatingSummary(tx, review.BookID)
+	})
+	if err != nil {
+		return Review{}, err
+	}
+	return review, nil
+}
+
+func (r *repository) DeleteReview(review Review) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBook(tx, review.BookID); err != nil {
+			return err
+		}
+		if err := tx.Delete(&Review{}, review.ID).Error; err != nil {
+			return err
+		}
+		return RefreshRatingSummary(tx, review.BookID)
+	})
+}
+
+// RefreshRatingSummary menghitung ulang rata-rata rating dan jumlah ulasan
+// buku dari tabel book_reviews. Panggil di dalam transaksi yang sama dengan
+// perubahan ulasannya.
+func RefreshRatingSummary(tx *gorm.DB, bookID int) error {
+	return tx.Exec(`UPDATE books SET
+		rating_average = (SELECT COALESCE(ROUND(AVG(rating), 2), 0) FROM book_reviews WHERE book_id = ?),
+		review_count = (SELECT COUNT(*) FROM book_reviews WHERE book_id = ?)
+		WHERE id = ?`, bookID, bookID, bookID).Error
+}
+
+// lockBook mengunci baris buku agar perubahan ulasan yang bersamaan
+// menghitung ringkasan rating secara berurutan.
+func lockBook(tx *gorm.DB, bookID int) error {
+	var book Book
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&book, bookID).Error
 }
@@ -1,9 +1,35 @@
 package book
 
+// BookRequest dipakai untuk membuat dan mengganti buku. AuthorIDs, GenreIDs dan
+// PublisherIDs yang tidak dikirim membiarkan relasi apa adanya saat update;
+// array kosong menghapus semua relasi tersebut. Rating tidak lagi diterima;
+// penilaian buku dihitung dari ulasan pembaca.
 type BookRequest struct {
-	Title       string `json:"title" binding:"required"`
-	Price       int    `json:"price" binding:"required,number"`
-	Synopsis    string `json:"synopsis"`
-	Description string `json:"description"`
-	Rating      int    `json:"rating" binding:"required,number"`
+	Title        string `json:"title" binding:"required"`
+	Price        int    `json:"price" binding:"required,number"`
+	Synopsis     string `json:"synopsis"`
+	Description  string `json:"description"`
+	ISBN         string `json:"isbn"`
+	AuthorIDs    []int  `json:"author_ids" binding:"omitempty,dive,min=1"`
+	GenreIDs     []int  `json:"genre_ids" binding:"omitempty,dive,min=1"`
+	PublisherIDs []int  `json:"publisher_ids" binding:"omitempty,dive,min=1"`
+}
+
+type AuthorRequest struct {
+	Name string `json:"name" binding:"required,max=150"`
+	Bio  string `json:"bio"`
+}
+
+type GenreRequest struct {
+	Name string `json:"name" binding:"required,max=80"`
+}
+
+type PublisherRequest struct {
+	Name    string `json:"name" binding:"required,max=150"`
+	Website string `json:"website" binding:"omitempty,url,max=255"`
+}
+
+type ReviewRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment string `json:"comment" binding:"max=2000"`
 }
@@ -1,10 +1,111 @@
 package book
 
+import "time"
+
 type BookResponse struct {
-	ID          int    `json:"id"`
-	Title       string `json:"title"`
-	Price       int    `json:"price"`
-	Synopsis    string `json:"synopsis"`
-	Description string `json:"description"`
-	Rating      int    `json:"rating"`
+	ID            int            `json:"id"`
+	Title         string         `json:"title"`
+	Price         int            `json:"price"`
+	Synopsis      string         `json:"synopsis"`
+	Description   string         `json:"description"`
+	Rating        int            `json:"rating"` // Deprecated: penilaian editor lama, gunakan rating_average
+	RatingAverage float64        `json:"rating_average"`
+	ReviewCount   int            `json:"review_count"`
+	ISBN          string         `json:"isbn,omitempty"`
+	Authors       []Ref          `json:"authors"`
+	Genres        []Ref          `json:"genres"`
+	Publishers    []Ref          `json:"publishers"`
+	Cover         *CoverResponse `json:"cover"`
+}
+
+// CoverResponse berisi URL sampul asli dan thumbnail per ukuran
+// (small, medium, large).
+type CoverResponse struct {
+	URL        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails"`
+}
+
+// ToCoverResponse mengembalikan nil untuk buku tanpa sampul.
+func ToCoverResponse(b Book) *CoverResponse {
+	if b.CoverKey == "" {
+		return nil
+	}
+	url, thumbnails := CoverURLs(b.CoverKey)
+	return &CoverResponse{URL: url, Thumbnails: thumbnails}
+}
+
+// Ref adalah ringkasan relasi buku (penulis, genre atau penerbit).
+type Ref struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type AuthorResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Bio  string `json:"bio"`
+}
+
+type GenreResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type PublisherResponse struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Website string `json:"website"`
+}
+
+func ToAuthorResponse(a Author) AuthorResponse {
+	return AuthorResponse{ID: a.ID, Name: a.Name, Bio: a.Bio}
+}
+
+func ToGenreResponse(g Genre) GenreResponse {
+	return GenreResponse{ID: g.ID, Name: g.Name}
+}
+
+func ToPublisherResponse(p Publisher) PublisherResponse {
+	return PublisherResponse{ID: p.ID, Name: p.Name, Website: p.Website}
+}
+
+// Refs meringkas relasi buku untuk BookResponse.
+func Refs(b Book) (authors, genres, publishers []Ref) {
+	authors = make([]Ref, 0, len(b.Authors))
+	for _, a := range b.Authors {
+		authors = append(authors, Ref{ID: a.ID, Name: a.Name})
+	}
+	genres = make([]Ref, 0, len(b.Genres))
+	for _, g := range b.Genres {
+		genres = append(genres, Ref{ID: g.ID, Name: g.Name})
+	}
+	publishers = make([]Ref, 0, len(b.Publishers))
+	for _, p := range b.Publishers {
+		publishers = append(publishers, Ref{ID: p.ID, Name: p.Name})
+	}
+	return authors, genres, publishers
+}
+
+type ReviewResponse struct {
+	ID           int       `json:"id"`
+	BookID       int       `json:"book_id"`
+	UserID       int       `json:"user_id"`
+	ReviewerName string    `json:"reviewer_name,omitempty"`
+	Rating       int       `json:"rating"`
+	Comment      string    `json:"comment"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func ToReviewResponse(r Review) ReviewResponse {
+	return ReviewResponse{
+		ID:           r.ID,
+		BookID:       r.BookID,
+		UserID:       r.UserID,
+		ReviewerName: r.ReviewerName,
+		Rating:       r.Rating,
+		Comment:      r.Comment,
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+	}
 }
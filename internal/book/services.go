@@ -1,19 +1,72 @@
 package book
 
+import (
+	"errors"
+	"io"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDuplicateISBN  = errors.New("ISBN sudah dipakai buku lain")
+	ErrBookNotFound   = errors.New("buku tidak ditemukan")
+	ErrReviewNotFound = errors.New("ulasan tidak ditemukan")
+	ErrReviewExists   = errors.New("kamu sudah mengulas buku ini, ubah ulasan yang ada")
+)
+
+const (
+	DefaultReviewLimit = 10
+	MaxReviewLimit     = 50
+)
+
 type Service interface {
 	Create(book BookRequest) (Book, error)
-	FindAll() ([]Book, error)
+	List(query ListQuery) (Page, error)
 	FIndByID(ID int) (Book, error)
 	Update(ID int, book BookRequest) (Book, error)
 	Delete(ID int) error
+
+	FindAuthors() ([]Author, error)
+	FindAuthorByID(ID int) (Author, error)
+	CreateAuthor(request AuthorRequest) (Author, error)
+	UpdateAuthor(ID int, request AuthorRequest) (Author, error)
+	DeleteAuthor(ID int) error
+
+	FindGenres() ([]Genre, error)
+	FindGenreByID(ID int) (Genre, error)
+	CreateGenre(request GenreRequest) (Genre, error)
+	UpdateGenre(ID int, request GenreRequest) (Genre, error)
+	DeleteGenre(ID int) error
+
+	FindPublishers() ([]Publisher, error)
+	FindPublisherByID(ID int) (Publisher, error)
+	CreatePublisher(request PublisherRequest) (Publisher, error)
+	UpdatePublisher(ID int, request PublisherRequest) (Publisher, error)
+	DeletePublisher(ID int) error
+
+	ListReviews(bookID, page, limit int) (ReviewPage, error)
+	CreateReview(userID, bookID int, request ReviewRequest) (Review, error)
+	UpdateReview(userID, bookID int, request ReviewRequest) (Review, error)
+	DeleteReview(userID, bookID int) error
+	ModerateReview(reviewID int) error
+
+	Import(r io.Reader, opts ImportOptions) (ImportReport, error)
+	Export(w io.Writer, format Format, filter Filter) error
+
+	// SetCover mengganti sampul buku; file sampul lama ikut dihapus.
+	SetCover(ID int, image io.Reader) (Book, error)
+	RemoveCover(ID int) (Book, error)
 }
 
 type service struct {
 	repository Repository
+	covers     *CoverStore
 }
 
-func NewService(repository Repository) *service {
-	return &service{repository}
+func NewService(repository Repository, covers *CoverStore) *service {
+	return &service{repository: repository, covers: covers}
 }
 
 func (s *service) Create(bookRequest BookRequest) (Book, error) {
@@ -22,7 +75,9 @@ func (s *service) Create(bookRequest BookRequest) (Book, error) {
 		Price:       bookRequest.Price,
 		Synopsis:    bookRequest.Synopsis,
 		Description: bookRequest.Description,
-		Rating:      bookRequest.Rating,
+	}
+	if err := s.applyDetails(&book, bookRequest); err != nil {
+		return Book{}, err
 	}
 
 	createdBook, err := s.repository.Create(book)
@@ -32,12 +87,45 @@ func (s *service) Create(bookRequest BookRequest) (Book, error) {
 	return createdBook, nil
 }
 
-func (s *service) FindAll() ([]Book, error) {
-	books, err := s.repository.FindAll()
+// List mengembalikan satu halaman katalog. Selain nomor halaman, NextCursor
+// selalu diisi jika masih ada halaman berikutnya.
+func (s *service) List(query ListQuery) (Page, error) {
+	if query.Limit < 1 || query.Limit > MaxLimit {
+		query.Limit = DefaultLimit
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	spec, err := query.toSpec()
+	if err != nil {
+		return Page{}, err
+	}
+
+	// Ambil satu baris lebih untuk mengetahui apakah masih ada halaman berikutnya.
+	spec.Limit = query.Limit + 1
+	books, total, err := s.repository.FindBySpec(spec)
 	if err != nil {
-		return nil, err
+		return Page{}, err
+	}
+
+	page := Page{
+		Total:      total,
+		Limit:      query.Limit,
+		TotalPages: int((total + int64(query.Limit) - 1) / int64(query.Limit)),
 	}
-	return books, nil
+	if query.Cursor == "" {
+		page.Page = query.Page
+	}
+	if len(books) > query.Limit {
+		books = books[:query.Limit]
+		page.HasNext = true
+		if query.Sort == "" {
+			query.Sort = DefaultSort
+		}
+		page.NextCursor = encodeCursor(query.Sort, spec.Column, books[len(books)-1])
+	}
+	page.Books = books
+	return page, nil
 }
 
 func (s *service) FIndByID(ID int) (Book, error) {
@@ -58,7 +146,9 @@ func (s *service) Update(ID int, bookRequest BookRequest) (Book, error) {
 	book.Price = bookRequest.Price
 	book.Synopsis = bookRequest.Synopsis
 	book.Description = bookRequest.Description
-	book.Rating = bookRequest.Rating
+	if err := s.applyDetails(&book, bookRequest); err != nil {
+		return Book{}, err
+	}
 
 	updatedBook, err := s.repository.Update(book)
 	if err != nil {
@@ -68,9 +158,237 @@ func (s *service) Update(ID int, bookRequest BookRequest) (Book, error) {
 }
 
 func (s *service) Delete(ID int) error {
-
+	book, err := s.repository.FIndByID(ID)
+	if err != nil {
+		return notFound(err, ErrBookNotFound)
+	}
 	if err := s.repository.Delete(ID); err != nil {
 		return err
 	}
+	s.removeCoverFiles(book.CoverKey)
 	return nil
 }
+
+func (s *service) SetCover(ID int, image io.Reader) (Book, error) {
+	book, err := s.repository.FIndByID(ID)
+	if err != nil {
+		return Book{}, notFound(err, ErrBookNotFound)
+	}
+
+	key, err := s.covers.Save(ID, image)
+	if err != nil {
+		return Book{}, err
+	}
+	book, err = s.swapCover(book, key)
+	if err != nil {
+		s.removeCoverFiles(key)
+		return Book{}, err
+	}
+	return book, nil
+}
+
+func (s *service) RemoveCover(ID int) (Book, error) {
+	book, err := s.repository.FIndByID(ID)
+	if err != nil {
+		return Book{}, notFound(err, ErrBookNotFound)
+	}
+	if book.CoverKey == "" {
+		return book, nil
+	}
+
+	return s.swapCover(book, "")
+}
+
+// maxCoverSwapAttempts membatasi pengulangan swapCover saat sampul terus
+// diganti request lain.
+const maxCoverSwapAttempts = 3
+
+// swapCover mengganti CoverKey dari nilai yang terakhir dibaca ke key, lalu
+// menghapus file sampul lama. Jika sampul diganti request lain di antaranya,
+// buku dibaca ulang agar file yang benar-benar lama yang dihapus.
+func (s *service) swapCover(book Book, key string) (Book, error) {
+	for attempt := 0; attempt < maxCoverSwapAttempts; attempt++ {
+		swapped, err := s.repository.UpdateCover(book.ID, book.CoverKey, key)
+		if err != nil {
+			return Book{}, err
+		}
+		if swapped {
+			s.removeCoverFiles(book.CoverKey)
+			book.CoverKey = key
+			return book, nil
+		}
+
+		book, err = s.repository.FIndByID(book.ID)
+		if err != nil {
+			return Book{}, notFound(err, ErrBookNotFound)
+		}
+		if book.CoverKey == key {
+			return book, nil
+		}
+	}
+	return Book{}, ErrCoverConflict
+}
+
+// removeCoverFiles menghapus file sampul yang sudah tidak dipakai. Kegagalan
+// hanya dicatat karena data buku sudah tersimpan.
+func (s *service) removeCoverFiles(key string) {
+	if key == "" {
+		return
+	}
+	if err := s.covers.Remove(key); err != nil {
+		log.Printf("Book: gagal menghapus file sampul %s: %v", key, err)
+	}
+}
+
+// applyDetails memvalidasi ISBN dan memuat relasi yang diminta ke book.
+// Daftar ID yang nil membiarkan relasi book apa adanya.
+func (s *service) applyDetails(book *Book, request BookRequest) error {
+	book.ISBN = nil
+	if request.ISBN != "" {
+		isbn, err := NormalizeISBN(request.ISBN)
+		if err != nil {
+			return err
+		}
+		existing, err := s.repository.FindByISBN(isbn)
+		if err == nil && existing.ID != book.ID {
+			return ErrDuplicateISBN
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		book.ISBN = &isbn
+	}
+
+	if request.AuthorIDs != nil {
+		ids := uniqueIDs(request.AuthorIDs)
+		authors, err := s.repository.FindAuthorsByIDs(ids)
+		if err != nil {
+			return err
+		}
+		if len(authors) != len(ids) {
+			return ErrAuthorNotFound
+		}
+		book.Authors = authors
+	}
+	if request.GenreIDs != nil {
+		ids := uniqueIDs(request.GenreIDs)
+		genres, err := s.repository.FindGenresByIDs(ids)
+		if err != nil {
+			return err
+		}
+		if len(genres) != len(ids) {
+			return ErrGenreNotFound
+		}
+		book.Genres = genres
+	}
+	if request.PublisherIDs != nil {
+		ids := uniqueIDs(request.PublisherIDs)
+		publishers, err := s.repository.FindPublishersByIDs(ids)
+		if err != nil {
+			return err
+		}
+		if len(publishers) != len(ids) {
+			return ErrPublisherNotFound
+		}
+		book.Publishers = publishers
+	}
+	return nil
+}
+
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// ReviewPage adalah satu halaman ulasan, terbaru lebih dulu.
+type ReviewPage struct {
+	Reviews    []Review
+	Total      int64
+	Page       int
+	Limit      int
+	TotalPages int
+	HasNext    bool
+}
+
+func (s *service) ListReviews(bookID, page, limit int) (ReviewPage, error) {
+	if limit < 1 || limit > MaxReviewLimit {
+		limit = DefaultReviewLimit
+	}
+	if page < 1 {
+		page = 1
+	}
+	if _, err := s.repository.FIndByID(bookID); err != nil {
+		return ReviewPage{}, notFound(err, ErrBookNotFound)
+	}
+
+	reviews, total, err := s.repository.FindReviews(bookID, (page-1)*limit, limit)
+	if err != nil {
+		return ReviewPage{}, err
+	}
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	return ReviewPage{
+		Reviews:    reviews,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+		HasNext:    page < totalPages,
+	}, nil
+}
+
+// CreateReview mengandalkan unique index (book_id, user_id): dua request
+// bersamaan dari user yang sama tidak bisa sama-sama lolos pengecekan.
+func (s *service) CreateReview(userID, bookID int, request ReviewRequest) (Review, error) {
+	review, err := s.repository.CreateReview(Review{
+		BookID:  bookID,
+		UserID:  userID,
+		Rating:  request.Rating,
+		Comment: strings.TrimSpace(request.Comment),
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return Review{}, ErrReviewExists
+	}
+	if err != nil {
+		return Review{}, notFound(err, ErrBookNotFound)
+	}
+	return review, nil
+}
+
+func (s *service) UpdateReview(userID, bookID int, request ReviewRequest) (Review, error) {
+	review, err := s.repository.FindReview(bookID, userID)
+	if err != nil {
+		return Review{}, notFound(err, ErrReviewNotFound)
+	}
+
+	review.Rating = request.Rating
+	review.Comment = strings.TrimSpace(request.Comment)
+	updated, err := s.repository.UpdateReview(review)
+	if err != nil {
+		return Review{}, notFound(err, ErrBookNotFound)
+	}
+	return updated, nil
+}
+
+func (s *service) DeleteReview(userID, bookID int) error {
+	review, err := s.repository.FindReview(bookID, userID)
+	if err != nil {
+		return notFound(err, ErrReviewNotFound)
+	}
+	return s.repository.DeleteReview(review)
+}
+
+// ModerateReview menghapus ulasan siapa pun; dipakai moderator dan admin.
+func (s *service) ModerateReview(reviewID int) error {
+	review, err := s.repository.FindReviewByID(reviewID)
+	if err != nil {
+		return notFound(err, ErrReviewNotFound)
+	}
+	return s.repository.DeleteReview(review)
+}
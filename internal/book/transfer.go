@@ -0,0 +1,79 @@
+package book
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Format adalah format file untuk impor dan ekspor katalog.
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+var ErrInvalidFormat = errors.New("format harus csv atau ndjson")
+
+// ParseFormat menerima nama format atau ekstensi file/Content-Type yang umum.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "csv", "text/csv":
+		return FormatCSV, nil
+	case "ndjson", "jsonl", "application/x-ndjson", "application/jsonl", "application/json-lines":
+		return FormatNDJSON, nil
+	}
+	return "", ErrInvalidFormat
+}
+
+// Kolom CSV. id, rating, rating_average dan review_count hanya ada di hasil
+// ekspor dan diabaikan saat impor, jadi file ekspor bisa langsung diimpor kembali.
+var exportColumns = []string{
+	"id", "isbn", "title", "price", "rating", "synopsis", "description",
+	"author_ids", "genre_ids", "publisher_ids", "rating_average", "review_count",
+}
+
+// idSeparator memisahkan beberapa ID relasi dalam satu sel CSV, misal "3;7".
+const idSeparator = ";"
+
+func joinIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, idSeparator)
+}
+
+func splitIDs(cell string) ([]int, error) {
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		return nil, nil
+	}
+	parts := strings.Split(cell, idSeparator)
+	ids := make([]int, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// csvSafe mencegah teks buku dibaca sebagai formula saat CSV dibuka di
+// aplikasi spreadsheet. csvUnsafe membalikkannya saat file diimpor kembali.
+func csvSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+func csvUnsafe(v string) string {
+	if len(v) > 1 && v[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(v[1])) {
+		return v[1:]
+	}
+	return v
+}
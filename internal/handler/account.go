@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"example/hello/internal/account"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountHandler struct {
+	accountService account.Service
+}
+
+func NewAccountHandler(accountService account.Service) *AccountHandler {
+	return &AccountHandler{accountService: accountService}
+}
+
+// ExportMyData mengunduh salinan data user yang sedang login.
+// Default berupa ZIP; ?format=json mengembalikan satu dokumen JSON.
+func (h *AccountHandler) ExportMyData(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	switch c.DefaultQuery("format", "zip") {
+	case "json":
+		export, err := h.accountService.Export(actor.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to export data", "err": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": true, "message": "Data exported successfully", "data": export})
+	case "zip":
+		// Data dimuat sebelum header dikirim agar error database masih bisa
+		// dibalas 500, bukan arsip kosong dengan status 200.
+		export, err := h.accountService.Export(actor.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to export data", "err": err.Error()})
+			return
+		}
+		filename := fmt.Sprintf("export-%d-%s.zip", actor.UserID, time.Now().Format("20060102"))
+		c.Header("Content-Type", "application/zip")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Header("Cache-Control", "no-store")
+		c.Status(http.StatusOK)
+		// Arsip ditulis langsung ke response; jika gagal di tengah jalan header
+		// sudah terkirim, jadi error hanya bisa dicatat.
+		if err := h.accountService.WriteArchive(export, c.Writer); err != nil {
+			log.Printf("Export: gagal menulis arsip user %d: %v", actor.UserID, err)
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "format must be zip or json"})
+	}
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"example/hello/internal/audit"
+	"example/hello/internal/policy"
+	"example/hello/internal/user"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// actorFromContext membangun policy.Actor dari data yang disimpan AuthMiddleware di konteks Gin.
+func actorFromContext(c *gin.Context) (policy.Actor, error) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		return policy.Actor{}, fmt.Errorf("user not authenticated")
+	}
+
+	userIDStr, ok := userIDVal.(string)
+	if !ok {
+		return policy.Actor{}, fmt.Errorf("invalid user ID format in context")
+	}
+
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		return policy.Actor{}, fmt.Errorf("invalid user ID in token")
+	}
+
+	role, _ := c.Get("role")
+	roleStr, _ := role.(string)
+
+	return policy.Actor{
+		UserID:    userID,
+		IsAdmin:   user.Role(roleStr) == user.RoleAdmin,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}, nil
+}
+
+// originFromContext mengambil IP dan user agent untuk endpoint tanpa login.
+func originFromContext(c *gin.Context) audit.Origin {
+	return audit.Origin{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"example/hello/internal/apikey"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	apiKeyService apikey.Service
+}
+
+func NewAPIKeyHandler(apiKeyService apikey.Service) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// ListAPIKeys menampilkan API key aktif milik user tanpa token mentahnya.
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	keys, err := h.apiKeyService.List(actor.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to retrieve API keys", "err": err.Error()})
+		return
+	}
+
+	responses := []apikey.APIKeyResponse{}
+	for _, k := range keys {
+		responses = append(responses, apikey.ToResponse(k))
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "data": responses})
+}
+
+// CreateAPIKey membuat API key baru. Token mentah hanya ada di respons ini.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	var req apikey.CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Invalid request", "err": err.Error()})
+		return
+	}
+
+	created, err := h.apiKeyService.Create(actor, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, apikey.ErrInvalidScope), errors.Is(err, apikey.ErrDuplicateScope), errors.Is(err, apikey.ErrInvalidExpiry):
+			c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": err.Error(), "allowed_scopes": apikey.AllScopes})
+		case errors.Is(err, apikey.ErrTooManyAPIKeys):
+			c.JSON(http.StatusConflict, gin.H{"status": false, "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to create API key", "err": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  true,
+		"message": "API key created. Simpan token ini, token tidak akan ditampilkan lagi",
+		"token":   created.Token,
+		"data":    apikey.ToResponse(created.Key),
+	})
+}
+
+// RevokeAPIKey mencabut satu API key; request berikutnya dengan token itu ditolak.
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	ID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Invalid API key ID"})
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(actor, ID); err != nil {
+		if errors.Is(err, apikey.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"status": false, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to revoke API key", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "API key revoked successfully"})
+}
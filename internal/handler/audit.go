@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"example/hello/internal/audit"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService audit.Service
+}
+
+func NewAuditHandler(auditService audit.Service) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// ListEvents menampilkan audit log terbaru lebih dulu. Filter: ?actor_id=, ?action=,
+// ?from= dan ?to= (RFC 3339), ?limit= dan ?before_id= untuk halaman berikutnya.
+func (h *AuditHandler) ListEvents(c *gin.Context) {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "limit must be between 1 and 200"})
+		return
+	}
+	filter.Limit = limit
+	if v := c.Query("before_id"); v != "" {
+		beforeID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || beforeID < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "before_id must be a positive integer"})
+			return
+		}
+		filter.BeforeID = beforeID
+	}
+
+	events, err := h.auditService.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to retrieve audit events", "err": err.Error()})
+		return
+	}
+
+	responses := []audit.EventResponse{}
+	for _, e := range events {
+		responses = append(responses, convertToEventResponse(e))
+	}
+
+	var nextBeforeID *int64
+	if len(events) == limit {
+		nextBeforeID = &events[len(events)-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "data": responses, "next_before_id": nextBeforeID})
+}
+
+// ExportEvents mengunduh semua event yang cocok dengan filter sebagai CSV.
+func (h *AuditHandler) ExportEvents(c *gin.Context) {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("audit-events-%s.csv", time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+	if err := h.auditService.WriteCSV(filter, c.Writer); err != nil {
+		log.Printf("Audit: gagal menulis CSV: %v", err)
+	}
+}
+
+func parseAuditFilter(c *gin.Context) (audit.Filter, error) {
+	var filter audit.Filter
+	if v := c.Query("actor_id"); v != "" {
+		actorID, err := strconv.Atoi(v)
+		if err != nil || actorID < 1 {
+			return audit.Filter{}, fmt.Errorf("actor_id must be a positive integer")
+		}
+		filter.ActorID = actorID
+	}
+	filter.Action = audit.Action(c.Query("action"))
+
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		v := c.Query(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return audit.Filter{}, fmt.Errorf("%s must be an RFC 3339 timestamp", p.name)
+		}
+		*p.dst = t
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return audit.Filter{}, fmt.Errorf("from must be before to")
+	}
+	return filter, nil
+}
+
+func convertToEventResponse(e audit.Event) audit.EventResponse {
+	return audit.EventResponse{
+		ID:         e.ID,
+		ActorID:    e.ActorID,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		IP:         e.IP,
+		UserAgent:  e.UserAgent,
+		Metadata:   json.RawMessage(e.Metadata),
+		CreatedAt:  e.CreatedAt,
+	}
+}
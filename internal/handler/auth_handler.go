@@ -1,103 +1,316 @@
 package handler
 
 import (
-	"context"
-	"crypto/rand"
-	"encoding/base64"
+	"crypto/subtle"
+	"errors"
 	"example/hello/internal/auth"
+	"example/hello/internal/oauth"
+	"example/hello/internal/session"
 	"example/hello/internal/user"
-	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/oauth2"
-	oauth2api "google.golang.org/api/oauth2/v2"
-	"google.golang.org/api/option"
 )
 
+// OAuthOptions mengatur cookie state dan halaman SPA yang boleh menerima hasil login OAuth.
+type OAuthOptions struct {
+	CookieDomain      string // Kosong berarti cookie hanya untuk host API
+	CookieSecure      bool
+	RedirectAllowlist oauth.RedirectAllowlist
+}
+
 type AuthHandler struct {
-	googleOauthConfig *oauth2.Config
-	userService       user.Service
+	providers      *oauth.Registry
+	flows          *oauth.StateStore
+	oauthOptions   OAuthOptions
+	userService    user.Service
+	sessionService session.Service
+	keyProvider    auth.KeyProvider
 }
 
-func NewAuthHandler(googleOauthConfig *oauth2.Config, userService user.Service) *AuthHandler {
+func NewAuthHandler(providers *oauth.Registry, flows *oauth.StateStore, oauthOptions OAuthOptions, userService user.Service, sessionService session.Service, keyProvider auth.KeyProvider) *AuthHandler {
 	return &AuthHandler{
-		googleOauthConfig: googleOauthConfig,
-		userService:       userService,
+		providers:      providers,
+		flows:          flows,
+		oauthOptions:   oauthOptions,
+		userService:    userService,
+		sessionService: sessionService,
+		keyProvider:    keyProvider,
 	}
 }
 
-func (h *AuthHandler) GoogleLogin(c *gin.Context) {
-	// Generate a random state string to prevent CSRF attacks.
-	b := make([]byte, 16)
-	_, err := rand.Read(b)
+// JWKS mempublikasikan public key penandatangan JWT agar service lain bisa
+// memverifikasi access token tanpa berbagi secret.
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, auth.PublicJWKS(h.keyProvider))
+}
+
+// ListProviders mengembalikan nama provider OAuth/OIDC yang aktif.
+func (h *AuthHandler) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.providers.Names()})
+}
+
+// ProviderLogin mengarahkan user ke halaman persetujuan provider dengan PKCE (S256) dan nonce.
+// redirect_uri (harus ada di allowlist) adalah halaman SPA yang menerima code sekali pakai;
+// tanpa redirect_uri, callback membalas JSON. Dengan ?intent=link, hasilnya hanya
+// token penautan untuk akun yang sedang login.
+func (h *AuthHandler) ProviderLogin(c *gin.Context) {
+	provider, err := h.providers.Get(c.Param("provider"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate state"})
+		h.oauthError(c, oauth.Flow{}, http.StatusNotFound, oauth.ErrorUnknownProvider, "Unknown login provider")
+		return
+	}
+
+	redirectURI := c.Query("redirect_uri")
+	if redirectURI != "" && !h.oauthOptions.RedirectAllowlist.Allows(redirectURI) {
+		h.oauthError(c, oauth.Flow{}, http.StatusBadRequest, oauth.ErrorInvalidRedirectURI, "redirect_uri is not allowed")
 		return
 	}
-	state := base64.URLEncoding.EncodeToString(b)
+	intent := ""
+	if c.Query("intent") == "link" {
+		intent = "link"
+	}
 
-	// Store the state in a short-lived cookie.
-	c.SetCookie("oauthstate", state, 3600, "/", "localhost", false, true)
+	// State, PKCE verifier dan nonce disimpan di database; cookie hanya mengikat state ke browser ini.
+	state, flow, err := h.flows.NewFlow(provider.Name(), redirectURI, intent)
+	if err != nil {
+		h.oauthError(c, oauth.Flow{}, http.StatusInternalServerError, oauth.ErrorServer, "Failed to generate state")
+		return
+	}
+	h.setStateCookie(c, state, int(oauth.FlowTTL.Seconds()))
 
-	// Redirect user to Google's consent page.
-	url := h.googleOauthConfig.AuthCodeURL(state)
+	// Redirect user to the provider's consent page.
+	url := provider.AuthCodeURL(state, flow.Verifier, flow.Nonce)
 	c.Redirect(http.StatusTemporaryRedirect, url)
 }
 
-func (h *AuthHandler) GoogleCallback(c *gin.Context) {
+func (h *AuthHandler) ProviderCallback(c *gin.Context) {
 	// Check if the state from the cookie matches the state from the URL.
-	oauthState, _ := c.Cookie("oauthstate")
-	if c.Query("state") != oauthState {
-		log.Println("Invalid oauth google state")
-		c.Redirect(http.StatusTemporaryRedirect, "/") // Redirect to home or login page
+	state := c.Query("state")
+	cookieState, _ := c.Cookie(oauthStateCookie)
+	h.setStateCookie(c, "", -1)
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookieState)) != 1 {
+		h.oauthError(c, oauth.Flow{}, http.StatusBadRequest, oauth.ErrorInvalidState, "Invalid or expired login state")
+		return
+	}
+	flow, ok, err := h.flows.TakeFlow(state)
+	if err != nil {
+		log.Printf("Gagal membaca state OAuth: %s\n", err.Error())
+		h.oauthError(c, oauth.Flow{}, http.StatusInternalServerError, oauth.ErrorServer, "Failed to read login state")
+		return
+	}
+	if !ok || flow.Provider != c.Param("provider") {
+		h.oauthError(c, oauth.Flow{}, http.StatusBadRequest, oauth.ErrorInvalidState, "Invalid or expired login state")
 		return
 	}
 
-	// Exchange the authorization code for a token.
-	code := c.Query("code")
-	token, err := h.googleOauthConfig.Exchange(context.Background(), code)
+	provider, err := h.providers.Get(flow.Provider)
 	if err != nil {
-		log.Printf("Code exchange failed: %s\n", err.Error())
-		c.Redirect(http.StatusTemporaryRedirect, "/")
+		h.oauthError(c, flow, http.StatusNotFound, oauth.ErrorUnknownProvider, "Unknown login provider")
+		return
+	}
+
+	// Provider mengembalikan ?error= jika user menolak atau permintaan tidak valid.
+	if providerErr := c.Query("error"); providerErr != "" {
+		code := oauth.ErrorExchangeFailed
+		if providerErr == "access_denied" {
+			code = oauth.ErrorAccessDenied
+		}
+		h.oauthError(c, flow, http.StatusUnauthorized, code, "Login was not completed at the provider")
 		return
 	}
 
-	// Use the token to get user info from Google.
-	oauth2Service, err := oauth2api.NewService(context.Background(), option.WithTokenSource(h.googleOauthConfig.TokenSource(context.Background(), token)))
+	// Exchange the authorization code and verify the user's profile.
+	profile, err := provider.Exchange(c.Request.Context(), c.Query("code"), flow.Verifier, flow.Nonce)
 	if err != nil {
-		log.Printf("Failed to create oauth2 service: %s\n", err.Error())
-		c.Redirect(http.StatusTemporaryRedirect, "/")
+		log.Printf("OAuth %s login failed: %s\n", provider.Name(), err.Error())
+		if errors.Is(err, oauth.ErrNonceMismatch) {
+			h.oauthError(c, flow, http.StatusUnauthorized, oauth.ErrorInvalidNonce, "Invalid login response")
+			return
+		}
+		h.oauthError(c, flow, http.StatusUnauthorized, oauth.ErrorExchangeFailed, "Failed to verify login with provider")
 		return
 	}
 
-	userInfo, err := oauth2Service.Userinfo.Get().Do()
+	input := user.ProviderLoginInput{
+		Provider:      profile.Provider,
+		Subject:       profile.Subject,
+		Email:         profile.Email,
+		EmailVerified: profile.EmailVerified,
+		Name:          profile.Name,
+	}
+
+	var result user.LoginResult
+	if flow.Intent == "link" {
+		// User yang sedang login ingin menautkan provider ini ke akunnya.
+		linkToken, err := h.userService.CreateLinkToken(input)
+		if err != nil {
+			h.oauthError(c, flow, http.StatusInternalServerError, oauth.ErrorServer, "Failed to generate link token")
+			return
+		}
+		result = user.LoginResult{LinkRequired: true, LinkToken: linkToken}
+	} else {
+		// Find or create a user in our database, then generate our own JWT and
+		// refresh token, or a 2FA challenge if the user has enabled it.
+		result, err = h.userService.LoginWithProvider(input, originFromContext(c))
+		if errors.Is(err, user.ErrDeletionScheduledByAdmin) {
+			h.oauthError(c, flow, http.StatusForbidden, oauth.ErrorAccessDenied, err.Error())
+			return
+		}
+		if errors.Is(err, user.ErrProviderEmailNotVerified) {
+			h.oauthError(c, flow, http.StatusForbidden, oauth.ErrorEmailNotVerified, err.Error())
+			return
+		}
+		if err != nil {
+			log.Printf("OAuth %s login failed: %s\n", provider.Name(), err.Error())
+			h.oauthError(c, flow, http.StatusInternalServerError, oauth.ErrorServer, "Failed to process user")
+			return
+		}
+	}
+
+	if flow.RedirectURI == "" {
+		respondProviderResult(c, result)
+		return
+	}
+
+	// Token tidak pernah ditaruh di URL: SPA menerima code sekali pakai
+	// lalu menukarnya di POST /v1/auth/exchange. Data user tidak ikut
+	// disimpan karena respons hanya butuh token.
+	result.User = user.User{}
+	code, err := h.flows.SaveHandoff(result)
 	if err != nil {
-		log.Printf("Failed to get user info: %s\n", err.Error())
-		c.Redirect(http.StatusTemporaryRedirect, "/")
+		h.oauthError(c, flow, http.StatusInternalServerError, oauth.ErrorServer, "Failed to complete login")
 		return
 	}
+	c.Redirect(http.StatusFound, oauth.RedirectWith(flow.RedirectURI, url.Values{"code": {code}}))
+}
 
-	// Find or create a user in our database.
-	userInput := user.GoogleLoginInput{
-		Email: userInfo.Email,
-		Name:  userInfo.Name,
+// ExchangeCode menukar code sekali pakai dari callback OAuth dengan hasil login.
+func (h *AuthHandler) ExchangeCode(c *gin.Context) {
+	var input oauth.ExchangeRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Code is required"})
+		return
 	}
 
-	loggedInUser, err := h.userService.FindOrCreateByGoogle(userInput)
+	var result user.LoginResult
+	ok, err := h.flows.TakeHandoff(input.Code, &result)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process user"})
+		log.Printf("Gagal membaca hasil login OAuth: %s\n", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "error_code": oauth.ErrorServer, "message": "Failed to read login result"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error_code": oauth.ErrorInvalidCode, "message": "Invalid or expired code"})
+		return
+	}
+	respondProviderResult(c, result)
+}
+
+// respondProviderResult mengirim hasil login provider, termasuk kasus penautan akun.
+func respondProviderResult(c *gin.Context, result user.LoginResult) {
+	if result.LinkRequired {
+		c.JSON(http.StatusOK, gin.H{
+			"status":        true,
+			"link_required": true,
+			"link_token":    result.LinkToken,
+			"expires_in":    int(auth.LinkTokenTTL.Seconds()),
+			"message":       "Log in to your account and confirm linking with this link_token.",
+		})
+		return
+	}
+	respondLoginResult(c, result)
+}
+
+const oauthStateCookie = "oauthstate"
+
+func (h *AuthHandler) setStateCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, value, maxAge, "/", h.oauthOptions.CookieDomain, h.oauthOptions.CookieSecure, true)
+}
+
+// oauthError mengirim kode error ke SPA lewat redirect_uri jika diketahui,
+// atau sebagai JSON untuk client tanpa redirect_uri.
+func (h *AuthHandler) oauthError(c *gin.Context, flow oauth.Flow, status int, code oauth.ErrorCode, message string) {
+	if flow.RedirectURI != "" {
+		c.Redirect(http.StatusFound, oauth.RedirectWith(flow.RedirectURI, url.Values{"error": {string(code)}}))
+		return
+	}
+	c.JSON(status, gin.H{"status": false, "error_code": code, "message": message})
+}
+
+// RefreshToken menukar refresh token dengan pasangan token baru (rotasi).
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var input session.RefreshRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Refresh token is required"})
 		return
 	}
 
-	// Generate our own JWT for the user.
-	jwtToken, err := auth.GenerateToken(fmt.Sprintf("%d", loggedInUser.ID), loggedInUser.Verivied)
+	pair, err := h.userService.RefreshToken(input.RefreshToken, originFromContext(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		if errors.Is(err, session.ErrInvalidRefreshToken) || errors.Is(err, session.ErrRefreshTokenExpired) || errors.Is(err, session.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to refresh token", "err": err.Error()})
 		return
 	}
 
-	// Return the JWT to the client.
-	c.JSON(http.StatusOK, gin.H{"token": jwtToken})
+	c.JSON(http.StatusOK, gin.H{
+		"status":        true,
+		"token":         pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    int(time.Until(pair.ExpiresAt).Seconds()),
+	})
+}
+
+// Logout mencabut access token yang sedang dipakai dan, jika dikirim,
+// seluruh keluarga refresh token-nya.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	claimsVal, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": "User not authenticated"})
+		return
+	}
+	claims := claimsVal.(*auth.MyClaims)
+
+	var input session.LogoutRequest
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Invalid input data"})
+		return
+	}
+
+	if err := h.sessionService.RevokeAccessToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to logout", "err": err.Error()})
+		return
+	}
+
+	// Sesi dari sid dicabut beserta refresh token-nya, jadi refresh_token di body
+	// hanya dibutuhkan untuk token lama yang belum membawa sid.
+	if claims.SessionID != "" {
+		actor, err := actorFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+			return
+		}
+		if err := h.sessionService.RevokeSession(actor, claims.SessionID); err != nil && !errors.Is(err, session.ErrSessionNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to logout", "err": err.Error()})
+			return
+		}
+	}
+
+	if input.RefreshToken != "" {
+		if err := h.sessionService.RevokeByRefreshToken(input.RefreshToken); err != nil && !errors.Is(err, session.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to logout", "err": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Logged out successfully"})
 }
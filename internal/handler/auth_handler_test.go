@@ -0,0 +1,452 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"example/hello/internal/audit"
+	"example/hello/internal/auth"
+	"example/hello/internal/oauth"
+	"example/hello/internal/outbox"
+	"example/hello/internal/session"
+	"example/hello/internal/user"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const fakeClientID = "test-client"
+
+// fakeOIDCProvider adalah issuer OIDC palsu: discovery, token endpoint dan
+// JWKS. Profil yang dikembalikan di ID token diatur per test.
+type fakeOIDCProvider struct {
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	profile oauth.Profile
+	nonce   string
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	p := &fakeOIDCProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "valid-code" || r.FormValue("code_verifier") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			writeTestJSON(w, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            p.server.URL,
+			"aud":            fakeClientID,
+			"sub":            p.profile.Subject,
+			"email":          p.profile.Email,
+			"email_verified": p.profile.EmailVerified,
+			"name":           p.profile.Name,
+			"nonce":          p.nonce,
+			"exp":            time.Now().Add(time.Minute).Unix(),
+		})
+		idToken.Header["kid"] = "test"
+		signed, err := idToken.SignedString(key)
+		if err != nil {
+			t.Errorf("sign id_token: %v", err)
+		}
+		writeTestJSON(w, map[string]any{
+			"access_token": "provider-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   60,
+			"id_token":     signed,
+		})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func writeTestJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// fakeUserRepository menyimpan user dan identitas di memori. Method yang tidak
+// dipakai alur login provider tidak diimplementasikan.
+type fakeUserRepository struct {
+	user.Repository
+	users      []user.User
+	identities []user.Identity
+}
+
+func (r *fakeUserRepository) FindIdentity(provider, subject string) (user.Identity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return user.Identity{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) FindByID(ID int) (user.User, error) {
+	for _, u := range r.users {
+		if u.ID == ID {
+			return u, nil
+		}
+	}
+	return user.User{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) FindByEmail(email string) (user.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return user.User{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) RegisterUser(u user.User) (user.User, error) {
+	u.ID = len(r.users) + 1
+	r.users = append(r.users, u)
+	return u, nil
+}
+
+func (r *fakeUserRepository) CreateIdentity(identity user.Identity) (user.Identity, error) {
+	identity.ID = len(r.identities) + 1
+	r.identities = append(r.identities, identity)
+	return identity, nil
+}
+
+func (r *fakeUserRepository) Transaction(fn func(txRepo user.Repository, txOutbox outbox.Repository) error) error {
+	return fn(r, nil)
+}
+
+// fakeStateRepository adalah tabel oauth_states di memori. Satu repository
+// bisa dipakai beberapa handler untuk mensimulasikan beberapa instance server.
+type fakeStateRepository struct {
+	mu     sync.Mutex
+	states map[string]oauth.PendingState
+}
+
+func (r *fakeStateRepository) Create(state oauth.PendingState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[state.KeyHash] = state
+	return nil
+}
+
+func (r *fakeStateRepository) Take(keyHash string, now time.Time) (oauth.PendingState, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.states[keyHash]
+	if !ok || !state.ExpiresAt.After(now) {
+		return oauth.PendingState{}, false, nil
+	}
+	delete(r.states, keyHash)
+	return state, true, nil
+}
+
+func (r *fakeStateRepository) DeleteExpired(before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, state := range r.states {
+		if state.ExpiresAt.Before(before) {
+			delete(r.states, hash)
+		}
+	}
+	return nil
+}
+
+type fakeSessionService struct {
+	session.Service
+}
+
+func (fakeSessionService) Issue(userID int, verified bool, role string, familyID string, origin audit.Origin) (session.Pair, error) {
+	return session.Pair{AccessToken: "access-token", RefreshToken: "refresh-token", ExpiresAt: time.Now().Add(15 * time.Minute)}, nil
+}
+
+const testSPARedirect = "http://app.test/callback"
+
+type oauthTestEnv struct {
+	router      *gin.Engine
+	provider    *fakeOIDCProvider
+	users       *fakeUserRepository
+	states      *fakeStateRepository
+	registry    *oauth.Registry
+	keyProvider auth.KeyProvider
+}
+
+func newOAuthTestEnv(t *testing.T) *oauthTestEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	keyProvider, err := auth.NewKeyProvider(auth.KeyConfig{Secret: "test-secret"})
+	if err != nil {
+		t.Fatalf("key provider: %v", err)
+	}
+	auth.SetKeyProvider(keyProvider)
+
+	fake := newFakeOIDCProvider(t)
+	provider, err := oauth.Discover(context.Background(), oauth.OIDCConfig{
+		Name:         "fake",
+		Issuer:       fake.server.URL,
+		ClientID:     fakeClientID,
+		ClientSecret: "test-secret",
+		RedirectURL:  "http://api.test/v1/auth/fake/callback",
+		HTTPClient:   fake.server.Client(),
+	})
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+
+	env := &oauthTestEnv{
+		provider:    fake,
+		users:       &fakeUserRepository{},
+		states:      &fakeStateRepository{states: map[string]oauth.PendingState{}},
+		registry:    oauth.NewRegistry(provider),
+		keyProvider: keyProvider,
+	}
+	env.router = env.newInstance()
+	return env
+}
+
+// newInstance membuat router baru yang berbagi database dengan instance lain,
+// seperti server kedua di belakang load balancer.
+func (env *oauthTestEnv) newInstance() *gin.Engine {
+	userService := user.NewService(env.users, fakeSessionService{}, nil, nil, audit.NopRecorder{}, nil, nil, nil, nil)
+	options := OAuthOptions{RedirectAllowlist: oauth.RedirectAllowlist{testSPARedirect}}
+	h := NewAuthHandler(env.registry, oauth.NewStateStore(env.states), options, userService, fakeSessionService{}, env.keyProvider)
+
+	router := gin.New()
+	router.GET("/v1/auth/:provider/login", h.ProviderLogin)
+	router.GET("/v1/auth/:provider/callback", h.ProviderCallback)
+	router.POST("/v1/auth/exchange", h.ExchangeCode)
+	return router
+}
+
+// startLogin memanggil endpoint login dan mengembalikan state dari URL
+// otorisasi beserta cookie state yang dipasang untuk browser.
+func (env *oauthTestEnv) startLogin(t *testing.T) (string, *http.Cookie) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	env.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/auth/fake/login", nil))
+	if rec.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("login status = %d, want %d", rec.Code, http.StatusTemporaryRedirect)
+	}
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("parse redirect: %v", err)
+	}
+	if location.Query().Get("code_challenge_method") != "S256" {
+		t.Errorf("authorization URL tanpa PKCE S256: %s", location)
+	}
+	env.provider.nonce = location.Query().Get("nonce")
+
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == oauthStateCookie {
+			return location.Query().Get("state"), cookie
+		}
+	}
+	t.Fatal("login tidak memasang cookie state")
+	return "", nil
+}
+
+func (env *oauthTestEnv) callback(state string, cookie *http.Cookie) (int, map[string]any) {
+	query := url.Values{"state": {state}, "code": {"valid-code"}}
+	req := httptest.NewRequest(http.MethodGet, "/v1/auth/fake/callback?"+query.Encode(), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	env.router.ServeHTTP(rec, req)
+
+	var body map[string]any
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	return rec.Code, body
+}
+
+func TestProviderCallbackRegistersVerifiedUser(t *testing.T) {
+	env := newOAuthTestEnv(t)
+	env.provider.profile = oauth.Profile{Subject: "sub-1", Email: "new@example.com", EmailVerified: true, Name: "New User"}
+
+	state, cookie := env.startLogin(t)
+	status, body := env.callback(state, cookie)
+
+	if status != http.StatusOK || body["token"] != "access-token" {
+		t.Fatalf("callback = %d %v, want 200 with token", status, body)
+	}
+	if len(env.users.users) != 1 || !env.users.users[0].Verivied {
+		t.Fatalf("users = %+v, want one verified user", env.users.users)
+	}
+	if len(env.users.identities) != 1 || env.users.identities[0].Subject != "sub-1" {
+		t.Fatalf("identities = %+v, want identity for sub-1", env.users.identities)
+	}
+
+	// State hanya bisa dipakai sekali.
+	if status, body := env.callback(state, cookie); status != http.StatusBadRequest || body["error_code"] != string(oauth.ErrorInvalidState) {
+		t.Errorf("reused state = %d %v, want 400 invalid_state", status, body)
+	}
+}
+
+func TestProviderCallbackRejectsStateMismatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		state  func(state string) string
+		cookie func(cookie *http.Cookie) *http.Cookie
+	}{
+		{
+			name:   "state berbeda dari cookie",
+			state:  func(string) string { return "forged-state" },
+			cookie: func(cookie *http.Cookie) *http.Cookie { return cookie },
+		},
+		{
+			name:   "tanpa cookie state",
+			state:  func(state string) string { return state },
+			cookie: func(*http.Cookie) *http.Cookie { return nil },
+		},
+		{
+			name:   "state kosong",
+			state:  func(string) string { return "" },
+			cookie: func(*http.Cookie) *http.Cookie { return &http.Cookie{Name: oauthStateCookie, Value: ""} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newOAuthTestEnv(t)
+			env.provider.profile = oauth.Profile{Subject: "sub-1", Email: "new@example.com", EmailVerified: true}
+
+			state, cookie := env.startLogin(t)
+			status, body := env.callback(tt.state(state), tt.cookie(cookie))
+
+			if status != http.StatusBadRequest || body["error_code"] != string(oauth.ErrorInvalidState) {
+				t.Fatalf("callback = %d %v, want 400 invalid_state", status, body)
+			}
+			if len(env.users.users) != 0 {
+				t.Errorf("users = %+v, want none", env.users.users)
+			}
+		})
+	}
+}
+
+func TestProviderCallbackExistingEmailRequiresLink(t *testing.T) {
+	env := newOAuthTestEnv(t)
+	env.users.users = []user.User{{ID: 1, Email: "owner@example.com", Password: "hashed", Verivied: true}}
+	env.provider.profile = oauth.Profile{Subject: "sub-2", Email: "owner@example.com", EmailVerified: true}
+
+	state, cookie := env.startLogin(t)
+	status, body := env.callback(state, cookie)
+
+	if status != http.StatusOK || body["link_required"] != true {
+		t.Fatalf("callback = %d %v, want 200 with link_required", status, body)
+	}
+	linkToken, _ := body["link_token"].(string)
+	claims, err := auth.ValidateLinkToken(linkToken)
+	if err != nil {
+		t.Fatalf("link_token tidak valid: %v", err)
+	}
+	if claims.LinkSubject != "sub-2" || claims.Email != "owner@example.com" {
+		t.Errorf("link claims = %+v, want sub-2 owner@example.com", claims)
+	}
+	if len(env.users.identities) != 0 || body["token"] != nil {
+		t.Errorf("identitas ditautkan otomatis tanpa konfirmasi: %+v %v", env.users.identities, body)
+	}
+}
+
+func TestProviderCallbackRejectsUnverifiedEmail(t *testing.T) {
+	env := newOAuthTestEnv(t)
+	env.provider.profile = oauth.Profile{Subject: "sub-3", Email: "victim@example.com", EmailVerified: false}
+
+	state, cookie := env.startLogin(t)
+	status, body := env.callback(state, cookie)
+
+	if status != http.StatusForbidden || body["error_code"] != string(oauth.ErrorEmailNotVerified) {
+		t.Fatalf("callback = %d %v, want 403 email_not_verified", status, body)
+	}
+	if len(env.users.users) != 0 || len(env.users.identities) != 0 {
+		t.Errorf("akun dibuat dari email yang belum diverifikasi: %+v %+v", env.users.users, env.users.identities)
+	}
+}
+
+func TestProviderLoginAcrossInstances(t *testing.T) {
+	env := newOAuthTestEnv(t)
+	env.provider.profile = oauth.Profile{Subject: "sub-4", Email: "spa@example.com", EmailVerified: true}
+
+	// Login, callback dan exchange masing-masing ditangani instance yang berbeda.
+	rec := httptest.NewRecorder()
+	env.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/auth/fake/login?redirect_uri="+url.QueryEscape(testSPARedirect), nil))
+	if rec.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("login status = %d, want %d", rec.Code, http.StatusTemporaryRedirect)
+	}
+	location, _ := url.Parse(rec.Header().Get("Location"))
+	state := location.Query().Get("state")
+	env.provider.nonce = location.Query().Get("nonce")
+
+	query := url.Values{"state": {state}, "code": {"valid-code"}}
+	req := httptest.NewRequest(http.MethodGet, "/v1/auth/fake/callback?"+query.Encode(), nil)
+	req.AddCookie(&http.Cookie{Name: oauthStateCookie, Value: state})
+	rec = httptest.NewRecorder()
+	env.newInstance().ServeHTTP(rec, req)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("callback status = %d %s, want %d", rec.Code, rec.Body, http.StatusFound)
+	}
+	redirect, _ := url.Parse(rec.Header().Get("Location"))
+	code := redirect.Query().Get("code")
+	if code == "" || redirect.Query().Get("error") != "" {
+		t.Fatalf("callback redirect = %s, want code", redirect)
+	}
+
+	// Token hanya ada dalam bentuk terenkripsi di tabel state.
+	for _, pending := range env.states.states {
+		if strings.Contains(string(pending.Payload), "access-token") {
+			t.Errorf("handoff disimpan tanpa enkripsi: %s", pending.Payload)
+		}
+	}
+
+	exchange := func(router *gin.Engine) (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/exchange", strings.NewReader(`{"code":"`+code+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rec, req)
+		var body map[string]any
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+	if status, body := exchange(env.newInstance()); status != http.StatusOK || body["token"] != "access-token" {
+		t.Fatalf("exchange = %d %v, want 200 with token", status, body)
+	}
+	// Code hanya bisa ditukar sekali, juga dari instance lain.
+	if status, body := exchange(env.router); status != http.StatusBadRequest || body["error_code"] != string(oauth.ErrorInvalidCode) {
+		t.Errorf("reused code = %d %v, want 400 invalid_code", status, body)
+	}
+}
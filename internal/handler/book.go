@@ -3,9 +3,11 @@ package handler
 import (
 	"example/hello/internal/book"
 
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -20,8 +22,26 @@ func NewBookHandler(bookService book.Service) *BookHandler {
 }
 
 func (h *BookHandler) GetBooks(c *gin.Context) {
-	books, err := h.bookService.FindAll()
+	query, errs := parseBookListQuery(c)
+	if len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid query parameters",
+			"errors":  errs,
+		})
+		return
+	}
+
+	page, err := h.bookService.List(query)
 	if err != nil {
+		if errors.Is(err, book.ErrInvalidSort) || errors.Is(err, book.ErrInvalidCursor) || errors.Is(err, book.ErrInvalidQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Invalid query parameters",
+				"errors":  []string{err.Error()},
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "Failed to retrieve books",
@@ -30,18 +50,85 @@ func (h *BookHandler) GetBooks(c *gin.Context) {
 		return
 	}
 
-	var bookResponse []book.BookResponse
-	for _, b := range books {
+	bookResponse := make([]book.BookResponse, 0, len(page.Books))
+	for _, b := range page.Books {
 		bookResponse = append(bookResponse, convertToBookResponse(b))
 	}
 
+	meta := gin.H{
+		"total":       page.Total,
+		"limit":       page.Limit,
+		"total_pages": page.TotalPages,
+		"has_next":    page.HasNext,
+		"next_cursor": page.NextCursor,
+	}
+	if page.Page > 0 {
+		meta["page"] = page.Page
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Books retrieved successfully",
 		"data":    bookResponse,
+		"meta":    meta,
 	})
 }
 
+// parseBookListQuery membaca parameter paging, filter, sort dan pencarian
+// katalog. Semua kesalahan dikumpulkan agar klien bisa memperbaikinya sekaligus.
+func parseBookListQuery(c *gin.Context) (book.ListQuery, []string) {
+	var errs []string
+	intParam := func(name string, min int) *int {
+		raw := c.Query(name)
+		if raw == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < min {
+			errs = append(errs, fmt.Sprintf("%s must be an integer >= %d", name, min))
+			return nil
+		}
+		return &n
+	}
+
+	ratingParam := func(name string) *float64 {
+		raw := c.Query(name)
+		if raw == "" {
+			return nil
+		}
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil || n < 0 || n > 5 {
+			errs = append(errs, fmt.Sprintf("%s must be a number between 0 and 5", name))
+			return nil
+		}
+		return &n
+	}
+
+	query := book.ListQuery{
+		Filter: book.Filter{
+			MinPrice:  intParam("min_price", 0),
+			MaxPrice:  intParam("max_price", 0),
+			MinRating: ratingParam("min_rating"),
+			MaxRating: ratingParam("max_rating"),
+			AuthorID:  intParam("author_id", 1),
+			GenreID:   intParam("genre_id", 1),
+			Keyword:   strings.TrimSpace(c.Query("q")),
+		},
+		Sort:   book.Sort(c.Query("sort")),
+		Cursor: c.Query("cursor"),
+	}
+	if page := intParam("page", 1); page != nil {
+		query.Page = *page
+	}
+	if limit := intParam("limit", 1); limit != nil {
+		if *limit > book.MaxLimit {
+			errs = append(errs, fmt.Sprintf("limit must not exceed %d", book.MaxLimit))
+		}
+		query.Limit = *limit
+	}
+	return query, errs
+}
+
 func (h *BookHandler) GetBookById(c *gin.Context) {
 	ID := c.Param("id")
 	if ID == "" {
@@ -85,30 +172,20 @@ func (h *BookHandler) CreateBook(c *gin.Context) {
 	var bookRequest book.BookRequest
 
 	if err := c.ShouldBindJSON(&bookRequest); err != nil {
-		// Inisialisasi slice untuk menampung pesan error
-		errorMessages := []string{}
-
-		// Periksa apakah error adalah ValidationError dari validator
-		if _, ok := err.(validator.ValidationErrors); ok {
-			for _, fieldErr := range err.(validator.ValidationErrors) {
-				errorMessage := fmt.Sprintf("Error pada kolom '%s', kondisi: '%s'", fieldErr.Field(), fieldErr.ActualTag())
-				errorMessages = append(errorMessages, errorMessage)
-			}
-		} else {
-			errorMessages = append(errorMessages, fmt.Sprintf("Format JSON tidak valid: %s", err.Error()))
-		}
-
 		// Kirim semua pesan error dalam satu respons JSON
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  "error",
 			"message": "Input tidak valid",
-			"errors":  errorMessages, // Mengirim slice pesan error
+			"errors":  bindingErrorMessages(err),
 		})
 		return
 	}
 
 	book, err := h.bookService.Create(bookRequest)
 	if err != nil {
+		if respondBookDetailError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "Gagal membuat buku",
@@ -121,13 +198,7 @@ func (h *BookHandler) CreateBook(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Buku berhasil dibuat",
-		"data": gin.H{
-			"title":       book.Title,
-			"synopsis":    book.Synopsis,
-			"price":       book.Price,
-			"description": book.Description,
-			"rating":      book.Rating,
-		},
+		"data":    convertToBookResponse(book),
 	})
 }
 
@@ -162,6 +233,9 @@ func (h *BookHandler) UpdateBook(c *gin.Context) {
 
 	book, err := h.bookService.Update(intID, bookRequest)
 	if err != nil {
+		if respondBookDetailError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "Failed to update book",
@@ -197,6 +271,14 @@ func (h *BookHandler) DeleteBook(c *gin.Context) {
 	}
 
 	if err := h.bookService.Delete(intID); err != nil {
+		if errors.Is(err, book.ErrBookNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"message": "Book not found",
+				"errors":  []string{err.Error()},
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "Failed to delete book",
@@ -211,13 +293,60 @@ func (h *BookHandler) DeleteBook(c *gin.Context) {
 	})
 }
 
+// bindingErrorMessages mengubah error binding menjadi pesan per kolom.
+func bindingErrorMessages(err error) []string {
+	errorMessages := []string{}
+
+	// Periksa apakah error adalah ValidationError dari validator
+	if fieldErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range fieldErrs {
+			errorMessage := fmt.Sprintf("Error pada kolom '%s', kondisi: '%s'", fieldErr.Field(), fieldErr.ActualTag())
+			errorMessages = append(errorMessages, errorMessage)
+		}
+	} else {
+		errorMessages = append(errorMessages, fmt.Sprintf("Format JSON tidak valid: %s", err.Error()))
+	}
+	return errorMessages
+}
+
+// respondBookDetailError menangani ISBN dan relasi yang tidak valid saat
+// membuat atau mengubah buku. Mengembalikan false untuk error lain.
+func respondBookDetailError(c *gin.Context, err error) bool {
+	status := 0
+	switch {
+	case errors.Is(err, book.ErrInvalidISBN),
+		errors.Is(err, book.ErrAuthorNotFound),
+		errors.Is(err, book.ErrGenreNotFound),
+		errors.Is(err, book.ErrPublisherNotFound):
+		status = http.StatusBadRequest
+	case errors.Is(err, book.ErrDuplicateISBN):
+		status = http.StatusConflict
+	default:
+		return false
+	}
+	c.JSON(status, gin.H{
+		"status":  "error",
+		"message": "Input tidak valid",
+		"errors":  []string{err.Error()},
+	})
+	return true
+}
+
 func convertToBookResponse(b book.Book) book.BookResponse {
-	return book.BookResponse{
-		ID:          b.ID,
-		Title:       b.Title,
-		Price:       b.Price,
-		Synopsis:    b.Synopsis,
-		Description: b.Description,
-		Rating:      b.Rating,
+	response := book.BookResponse{
+		ID:            b.ID,
+		Title:         b.Title,
+		Price:         b.Price,
+		Synopsis:      b.Synopsis,
+		Description:   b.Description,
+		Rating:        b.Rating,
+		RatingAverage: b.RatingAverage,
+		ReviewCount:   b.ReviewCount,
+	}
+	if b.ISBN != nil {
+		response.ISBN = *b.ISBN
 	}
+	response.Authors, response.Genres, response.Publishers = book.Refs(b)
+	response.Cover = book.ToCoverResponse(b)
+	return response
 }
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"example/hello/internal/book"
+
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadCover mengganti sampul buku dari field multipart "cover".
+func (h *BookHandler) UploadCover(c *gin.Context) {
+	ID, ok := relationID(c)
+	if !ok {
+		return
+	}
+
+	// Sisakan ruang untuk overhead multipart di atas batas ukuran gambar.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, book.MaxCoverBytes+(1<<20))
+	fileHeader, err := c.FormFile("cover")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondCoverError(c, http.StatusRequestEntityTooLarge, book.ErrCoverTooLarge)
+			return
+		}
+		respondCoverError(c, http.StatusBadRequest, errors.New("field 'cover' wajib berisi file gambar"))
+		return
+	}
+	if fileHeader.Size > book.MaxCoverBytes {
+		respondCoverError(c, http.StatusRequestEntityTooLarge, book.ErrCoverTooLarge)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		respondCoverError(c, http.StatusInternalServerError, err)
+		return
+	}
+	defer file.Close()
+
+	updated, err := h.bookService.SetCover(ID, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, book.ErrBookNotFound):
+			respondCoverError(c, http.StatusNotFound, err)
+		case errors.Is(err, book.ErrCoverTooLarge):
+			respondCoverError(c, http.StatusRequestEntityTooLarge, err)
+		case errors.Is(err, book.ErrUnsupportedCover):
+			respondCoverError(c, http.StatusUnsupportedMediaType, err)
+		case errors.Is(err, book.ErrInvalidCoverSize):
+			respondCoverError(c, http.StatusUnprocessableEntity, err)
+		case errors.Is(err, book.ErrCoverConflict):
+			respondCoverError(c, http.StatusConflict, err)
+		default:
+			respondCoverError(c, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Sampul buku berhasil diperbarui",
+		"data":    convertToBookResponse(updated),
+	})
+}
+
+// DeleteCover menghapus sampul buku beserta thumbnail-nya.
+func (h *BookHandler) DeleteCover(c *gin.Context) {
+	ID, ok := relationID(c)
+	if !ok {
+		return
+	}
+
+	updated, err := h.bookService.RemoveCover(ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, book.ErrBookNotFound):
+			respondCoverError(c, http.StatusNotFound, err)
+		case errors.Is(err, book.ErrCoverConflict):
+			respondCoverError(c, http.StatusConflict, err)
+		default:
+			respondCoverError(c, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Sampul buku berhasil dihapus",
+		"data":    convertToBookResponse(updated),
+	})
+}
+
+func respondCoverError(c *gin.Context, status int, err error) {
+	c.JSON(status, gin.H{
+		"status":  "error",
+		"message": "Gagal memproses sampul buku",
+		"errors":  []string{err.Error()},
+	})
+}
@@ -0,0 +1,320 @@
+package handler
+
+import (
+	"example/hello/internal/book"
+
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// relationID membaca parameter :id untuk penulis, genre dan penerbit.
+func relationID(c *gin.Context) (int, bool) {
+	ID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || ID < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "ID must be a valid integer",
+		})
+		return 0, false
+	}
+	return ID, true
+}
+
+// respondRelationError memetakan error service relasi buku ke status HTTP.
+func respondRelationError(c *gin.Context, err error, message string) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, book.ErrAuthorNotFound),
+		errors.Is(err, book.ErrGenreNotFound),
+		errors.Is(err, book.ErrPublisherNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, book.ErrDuplicateGenre),
+		errors.Is(err, book.ErrDuplicatePublisher):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{
+		"status":  "error",
+		"message": message,
+		"errors":  []string{err.Error()},
+	})
+}
+
+// bindRelationRequest mem-bind body JSON dan mengirim 400 jika tidak valid.
+func bindRelationRequest(c *gin.Context, request any) bool {
+	if err := c.ShouldBindJSON(request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Input tidak valid",
+			"errors":  bindingErrorMessages(err),
+		})
+		return false
+	}
+	return true
+}
+
+func (h *BookHandler) GetAuthors(c *gin.Context) {
+	authors, err := h.bookService.FindAuthors()
+	if err != nil {
+		respondRelationError(c, err, "Failed to retrieve authors")
+		return
+	}
+
+	response := make([]book.AuthorResponse, 0, len(authors))
+	for _, a := range authors {
+		response = append(response, book.ToAuthorResponse(a))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Authors retrieved successfully",
+		"data":    response,
+	})
+}
+
+func (h *BookHandler) GetAuthor(c *gin.Context) {
+	ID, ok := relationID(c)
+	if !ok {
+		return
+	}
+	author, err := h.bookService.FindAuthorByID(ID)
+	if err != nil {
+		respondRelationError(c, err, "Failed to retrieve author")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Author retrieved successfully",
+		"data":    book.ToAuthorResponse(author),
+	})
+}
+
+func (h *BookHandler) CreateAuthor(c *gin.Context) {
+	var request book.AuthorRequest
+	if !bindRelationRequest(c, &request) {
+		return
+	}
+	author, err := h.bookService.CreateAuthor(request)
+	if err != nil {
+		respondRelationError(c, err, "Gagal membuat penulis")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Penulis berhasil dibuat",
+		"data":    book.ToAuthorResponse(author),
+	})
+}
+
+func (h *BookHandler) UpdateAuthor(c *gin.Context) {
+	ID, ok := relationID(c)
+	if !ok {
+		return
+	}
+	var request book.AuthorRequest
+	if !bindRelationRequest(c, &request) {
+		return
+	}
+	author, err := h.bookService.UpdateAuthor(ID, request)
+	if err != nil {
+		respondRelationError(c, err, "Failed to update author")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Author updated successfully",
+		"data":    book.ToAuthorResponse(author),
+	})
+}
+
+func (h *BookHandler) DeleteAuthor(c *gin.Context) {
+	ID, ok := relationID(c)
+	if !ok {
+		return
+	}
+	if err := h.bookService.DeleteAuthor(ID); err != nil {
+		respondRelationError(c, err, "Failed to delete author")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Author deleted successfully",
+	})
+}
+
+func (h *BookHandler) GetGenres(c *gin.Context) {
+	genres, err := h.bookService.FindGenres()
+	if err != nil {
+		respondRelationError(c, err, "Failed to retrieve genres")
+		return
+	}
+
+	response := make([]book.GenreResponse, 0, len(genres))
+	for _, g := range genres {
+		response = append(response, book.ToGenreResponse(g))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Genres retrieved successfully",
+		"data":    response,
+	})
+}
+
+func (h *BookHandler) GetGenre(c *gin.Context) {
+	ID, ok := relationID(c)
+	if !ok {
+		return
+	}
+	genre, err := h.bookService.FindGenreByID(ID)
+	if err != nil {
+		respondRelationError(c, err, "Failed to retrieve genre")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Genre retrieved successfully",
+		"data":    book.ToGenreResponse(genre),
+	})
+}
+
+func (h *BookHandler) CreateGenre(c *gin.Context) {
+	var request book.GenreRequest
+	if !bindRelationRequest(c, &request) {
+		return
+	}
+	genre, err := h.bookService.CreateGenre(request)
+	if err != nil {
+		respondRelationError(c, err, "Gagal membuat genre")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Genre berhasil dibuat",
+		"data":    book.ToGenreResponse(genre),
+	})
+}
+
+func (h *BookHandler) UpdateGenre(c *gin.Context) {
+	ID, ok := relationID(c)
+	if !ok {
+		return
+	}
+	var request book.GenreRequest
+	if !bindRelationRequest(c, &request) {
+		return
+	}
+	genre, err := h.bookService.UpdateGenre(ID, request)
+	if err != nil {
+		respondRelationError(c, err, "Failed to update genre")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Genre updated successfully",
+		"data":    book.ToGenreResponse(genre),
+	})
+}
+
+func (h *BookHandler) DeleteGenre(c *gin.Context) {
+	ID, ok := relationID(c)
+	if !ok {
+		return
+	}
+	if err := h.bookService.DeleteGenre(ID); err != nil {
+		respondRelationError(c, err, "Failed to delete genre")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Genre deleted successfully",
+	})
+}
+
+func (h *BookHandler) GetPublishers(c *gin.Context) {
+	publishers, err := h.bookService.FindPublishers()
+	if err != nil {
+		respondRelationError(c, err, "Failed to retrieve publishers")
+		return
+	}
+
+	response := make([]book.PublisherResponse, 0, len(publishers))
+	for _, p := range publishers {
+		response = append(response, book.ToPublisherResponse(p))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Publishers retrieved successfully",
+		"data":    response,
+	})
+}
+
+func (h *BookHandler) GetPublisher(c *gin.Context) {
+	ID, ok := relationID(c)
+	if !ok {
+		return
+	}
+	publisher, err := h.bookService.FindPublisherByID(ID)
+	if err != nil {
+		respondRelationError(c, err, "Failed to retrieve publisher")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Publisher retrieved successfully",
+		"data":    book.ToPublisherResponse(publisher),
+	})
+}
+
+func (h *BookHandler) CreatePublisher(c *gin.Context) {
+	var request book.PublisherRequest
+	if !bindRelationRequest(c, &request) {
+		return
+	}
+	publisher, err := h.bookService.CreatePublisher(request)
+	if err != nil {
+		respondRelationError(c, err, "Gagal membuat penerbit")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Penerbit berhasil dibuat",
+		"data":    book.ToPublisherResponse(publisher),
+	})
+}
+
+func (h *BookHandler) UpdatePublisher(c *gin.Context) {
+	ID, ok := relationID(c)
+	if !ok {
+		return
+	}
+	var request book.PublisherRequest
+	if !bindRelationRequest(c, &request) {
+		return
+	}
+	publisher, err := h.bookService.UpdatePublisher(ID, request)
+	if err != nil {
+		respondRelationError(c, err, "Failed to update publisher")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Publisher updated successfully",
+		"data":    book.ToPublisherResponse(publisher),
+	})
+}
+
+func (h *BookHandler) DeletePublisher(c *gin.Context) {
+	ID, ok := relationID(c)
+	if !ok {
+		return
+	}
+	if err := h.bookService.DeletePublisher(ID); err != nil {
+		respondRelationError(c, err, "Failed to delete publisher")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Publisher deleted successfully",
+	})
+}
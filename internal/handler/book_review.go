@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"example/hello/internal/book"
+
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondReviewError memetakan error ulasan ke status HTTP.
+func respondReviewError(c *gin.Context, err error, message string) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, book.ErrBookNotFound), errors.Is(err, book.ErrReviewNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, book.ErrReviewExists):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{
+		"status":  "error",
+		"message": message,
+		"errors":  []string{err.Error()},
+	})
+}
+
+// GetReviews menampilkan ulasan buku per halaman (?page= dan ?limit=).
+func (h *BookHandler) GetReviews(c *gin.Context) {
+	bookID, ok := relationID(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.Query("page"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	result, err := h.bookService.ListReviews(bookID, page, limit)
+	if err != nil {
+		respondReviewError(c, err, "Failed to retrieve reviews")
+		return
+	}
+
+	reviews := make([]book.ReviewResponse, 0, len(result.Reviews))
+	for _, r := range result.Reviews {
+		reviews = append(reviews, book.ToReviewResponse(r))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Reviews retrieved successfully",
+		"data":    reviews,
+		"meta": gin.H{
+			"page":        result.Page,
+			"limit":       result.Limit,
+			"total":       result.Total,
+			"total_pages": result.TotalPages,
+			"has_next":    result.HasNext,
+		},
+	})
+}
+
+// CreateReview menyimpan ulasan user yang login; satu ulasan per buku.
+func (h *BookHandler) CreateReview(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	bookID, ok := relationID(c)
+	if !ok {
+		return
+	}
+	var request book.ReviewRequest
+	if !bindRelationRequest(c, &request) {
+		return
+	}
+
+	review, err := h.bookService.CreateReview(actor.UserID, bookID, request)
+	if err != nil {
+		respondReviewError(c, err, "Gagal menyimpan ulasan")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Ulasan berhasil disimpan",
+		"data":    book.ToReviewResponse(review),
+	})
+}
+
+// UpdateMyReview mengubah ulasan milik user yang login.
+func (h *BookHandler) UpdateMyReview(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	bookID, ok := relationID(c)
+	if !ok {
+		return
+	}
+	var request book.ReviewRequest
+	if !bindRelationRequest(c, &request) {
+		return
+	}
+
+	review, err := h.bookService.UpdateReview(actor.UserID, bookID, request)
+	if err != nil {
+		respondReviewError(c, err, "Failed to update review")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Review updated successfully",
+		"data":    book.ToReviewResponse(review),
+	})
+}
+
+// DeleteMyReview menghapus ulasan milik user yang login.
+func (h *BookHandler) DeleteMyReview(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	bookID, ok := relationID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.bookService.DeleteReview(actor.UserID, bookID); err != nil {
+		respondReviewError(c, err, "Failed to delete review")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Review deleted successfully",
+	})
+}
+
+// ModerateReview menghapus ulasan siapa pun berdasarkan ID ulasan.
+func (h *BookHandler) ModerateReview(c *gin.Context) {
+	reviewID, ok := relationID(c)
+	if !ok {
+		return
+	}
+	if err := h.bookService.ModerateReview(reviewID); err != nil {
+		respondReviewError(c, err, "Failed to delete review")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Review deleted successfully",
+	})
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"example/hello/internal/book"
+
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportBytes membatasi ukuran file impor katalog.
+const maxImportBytes = 32 << 20
+
+// ImportBooks mengimpor buku dari file CSV atau NDJSON. File dikirim sebagai
+// body mentah atau field "file" multipart; format dari ?format=, Content-Type
+// atau ekstensi file. ?mode=all_or_nothing (default) atau best_effort, dan
+// ?dry_run=true hanya memvalidasi.
+func (h *BookHandler) ImportBooks(c *gin.Context) {
+	mode, err := book.ParseImportMode(c.Query("mode"))
+	if err != nil {
+		respondImportError(c, http.StatusBadRequest, err)
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	body, format, err := importSource(c)
+	if err != nil {
+		respondImportError(c, http.StatusBadRequest, err)
+		return
+	}
+	defer body.Close()
+
+	report, err := h.bookService.Import(body, book.ImportOptions{Format: format, Mode: mode, DryRun: dryRun})
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			respondImportError(c, http.StatusRequestEntityTooLarge, fmt.Errorf("file lebih besar dari %d MB", maxImportBytes>>20))
+		case errors.Is(err, book.ErrInvalidHeader), errors.Is(err, book.ErrTooManyRows), errors.Is(err, bufio.ErrTooLong):
+			respondImportError(c, http.StatusBadRequest, err)
+		default:
+			respondImportError(c, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	switch {
+	case report.DryRun:
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": fmt.Sprintf("Validasi selesai: %d baris valid, %d baris gagal", report.Valid, report.Failed),
+			"data":    report,
+		})
+	case report.Mode == book.ImportAllOrNothing && report.Failed > 0:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": fmt.Sprintf("Import dibatalkan: %d baris gagal, tidak ada buku yang disimpan", report.Failed),
+			"data":    report,
+		})
+	case report.RowLimitReached:
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": fmt.Sprintf("%d buku berhasil diimpor, %d baris gagal; file dipotong setelah %d baris", report.Imported, report.Failed, book.MaxImportRows),
+			"data":    report,
+		})
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": fmt.Sprintf("%d buku berhasil diimpor, %d baris gagal", report.Imported, report.Failed),
+			"data":    report,
+		})
+	}
+}
+
+// ExportBooks mengunduh katalog sebagai CSV (default) atau NDJSON
+// (?format=ndjson) dengan filter yang sama seperti GET /v1/get-books.
+func (h *BookHandler) ExportBooks(c *gin.Context) {
+	format := book.FormatCSV
+	if v := c.Query("format"); v != "" {
+		var err error
+		if format, err = book.ParseFormat(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Invalid query parameters",
+				"errors":  []string{err.Error()},
+			})
+			return
+		}
+	}
+	query, errs := parseBookListQuery(c)
+	if len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid query parameters",
+			"errors":  errs,
+		})
+		return
+	}
+
+	contentType, extension := "text/csv; charset=utf-8", "csv"
+	if format == book.FormatNDJSON {
+		contentType, extension = "application/x-ndjson", "ndjson"
+	}
+	filename := fmt.Sprintf("books-%s.%s", time.Now().Format("20060102-150405"), extension)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+	if err := h.bookService.Export(c.Writer, format, query.Filter); err != nil {
+		log.Printf("Book: gagal menulis ekspor: %v", err)
+	}
+}
+
+// importSource mengembalikan isi file impor beserta formatnya.
+func importSource(c *gin.Context) (io.ReadCloser, book.Format, error) {
+	formatHint := c.Query("format")
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+
+	if mediaType == "multipart/form-data" {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", fmt.Errorf("field 'file' wajib diisi: %w", err)
+		}
+		if formatHint == "" {
+			formatHint = strings.TrimPrefix(filepath.Ext(fileHeader.Filename), ".")
+		}
+		format, err := book.ParseFormat(formatHint)
+		if err != nil {
+			return nil, "", err
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		return file, format, nil
+	}
+
+	if formatHint == "" {
+		formatHint = mediaType
+	}
+	format, err := book.ParseFormat(formatHint)
+	if err != nil {
+		return nil, "", err
+	}
+	return c.Request.Body, format, nil
+}
+
+func respondImportError(c *gin.Context, status int, err error) {
+	c.JSON(status, gin.H{
+		"status":  "error",
+		"message": "Import gagal",
+		"errors":  []string{err.Error()},
+	})
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"example/hello/internal/user"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *UserHandler) ListIdentities(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	identities, err := h.userService.FindIdentities(actor.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to retrieve linked accounts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "data": convertToIdentityResponses(identities)})
+}
+
+// LinkIdentity mengonfirmasi penautan akun provider memakai link_token dari callback OAuth.
+func (h *UserHandler) LinkIdentity(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	var input user.LinkIdentityInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": h.getValidationErrors(err)})
+		return
+	}
+
+	identity, err := h.userService.LinkIdentity(actor, input.LinkToken)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Failed to link account", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "data": convertToIdentityResponse(identity)})
+}
+
+func (h *UserHandler) UnlinkIdentity(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	if err := h.userService.UnlinkIdentity(actor, c.Param("provider")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Failed to unlink account", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Account unlinked successfully"})
+}
+
+func convertToIdentityResponse(i user.Identity) user.IdentityResponse {
+	return user.IdentityResponse{
+		Provider:  i.Provider,
+		Email:     i.Email,
+		CreatedAt: i.CreatedAt,
+	}
+}
+
+func convertToIdentityResponses(identities []user.Identity) []user.IdentityResponse {
+	responses := []user.IdentityResponse{}
+	for _, i := range identities {
+		responses = append(responses, convertToIdentityResponse(i))
+	}
+	return responses
+}
@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"errors"
 	"example/hello/internal/match"
+	"example/hello/internal/policy"
 
 	"net/http"
 	"path/filepath"
@@ -53,21 +55,14 @@ func (h *MatchHandler) CreateMatch(c *gin.Context) {
 	}
 
 	// Ambil userID dari context yang sudah di-set oleh middleware Auth
-	userIDVal, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "User not authenticated"})
-		return
-	}
-
-	userID, err := strconv.Atoi(userIDVal.(string))
+	actor, err := actorFromContext(c)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Invalid user ID in token"})
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
 
 	// 4. Buat request object untuk service
 	matchRequest := match.MatchRequest{
-		UserID:     userID,
 		Age:        age,
 		Gender:     match.Gender(c.PostForm("gender")),
 		Interested: match.Interest(c.PostForm("interested")),
@@ -78,7 +73,7 @@ func (h *MatchHandler) CreateMatch(c *gin.Context) {
 	}
 
 	// 5. Panggil service untuk membuat match
-	newMatch, err := h.matchService.Create(matchRequest)
+	newMatch, err := h.matchService.Create(actor, matchRequest)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -189,6 +184,12 @@ func (h *MatchHandler) UpdateMatchUrl(c *gin.Context) {
 		return
 	}
 
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
 	var bookRequest match.MatchRequest
 	if err := c.ShouldBindJSON(&bookRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -199,7 +200,15 @@ func (h *MatchHandler) UpdateMatchUrl(c *gin.Context) {
 		return
 	}
 
-	updated, err := h.matchService.Update(intID, bookRequest)
+	updated, err := h.matchService.Update(actor, intID, bookRequest)
+	if errors.Is(err, policy.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "You can only update your own profile",
+			"errors":  []string{err.Error()},
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -236,7 +245,21 @@ func (h *MatchHandler) DeleteMatchUrl(c *gin.Context) {
 		return
 	}
 
-	if err := h.matchService.Delete(intID); err != nil {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	if err := h.matchService.Delete(actor, intID); err != nil {
+		if errors.Is(err, policy.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"message": "You can only delete your own profile",
+				"errors":  []string{err.Error()},
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "Failed to delete profile",
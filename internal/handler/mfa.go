@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"example/hello/internal/user"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyMFALogin adalah langkah kedua login untuk user dengan 2FA aktif.
+func (h *UserHandler) VerifyMFALogin(c *gin.Context) {
+	var input user.MFALoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": h.getValidationErrors(err)})
+		return
+	}
+
+	result, err := h.userService.VerifyMFALogin(input.MFAToken, input.Code, originFromContext(c))
+	if respondLoginLimited(c, err) || respondDeletionScheduled(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": "Login failed", "err": err.Error()})
+		return
+	}
+	respondLoginResult(c, result)
+}
+
+func (h *UserHandler) BeginTOTPEnrollment(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	enrollment, err := h.userService.BeginTOTPEnrollment(actor)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Failed to start 2FA enrollment", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "data": enrollment})
+}
+
+func (h *UserHandler) ConfirmTOTPEnrollment(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	var input user.TOTPCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": h.getValidationErrors(err)})
+		return
+	}
+
+	codes, err := h.userService.ConfirmTOTPEnrollment(actor, input.Code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Failed to enable 2FA", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "2FA enabled. Store these recovery codes somewhere safe; they will not be shown again.", "recovery_codes": codes})
+}
+
+func (h *UserHandler) DisableTOTP(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	var input user.TOTPCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": h.getValidationErrors(err)})
+		return
+	}
+
+	if err := h.userService.DisableTOTP(actor, input.Code); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Failed to disable 2FA", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "2FA disabled"})
+}
+
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	var input user.TOTPCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": h.getValidationErrors(err)})
+		return
+	}
+
+	codes, err := h.userService.RegenerateRecoveryCodes(actor, input.Code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Failed to regenerate recovery codes", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "recovery_codes": codes})
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"example/hello/internal/outbox"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OutboxHandler struct {
+	outboxService outbox.Service
+}
+
+func NewOutboxHandler(outboxService outbox.Service) *OutboxHandler {
+	return &OutboxHandler{outboxService: outboxService}
+}
+
+// ListEmails menampilkan isi outbox, bisa difilter dengan ?status=pending|sent|dead.
+func (h *OutboxHandler) ListEmails(c *gin.Context) {
+	status := outbox.Status(c.Query("status"))
+	switch status {
+	case "", outbox.StatusPending, outbox.StatusSent, outbox.StatusDead:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "status must be one of pending, sent, dead"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "limit must be between 1 and 200"})
+		return
+	}
+
+	emails, err := h.outboxService.List(status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to retrieve emails", "err": err.Error()})
+		return
+	}
+
+	responses := []outbox.EmailResponse{}
+	for _, e := range emails {
+		responses = append(responses, convertToEmailResponse(e))
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "data": responses})
+}
+
+// RetryEmail mengembalikan email yang gagal ke antrean.
+func (h *OutboxHandler) RetryEmail(c *gin.Context) {
+	intID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "ID must be a valid integer"})
+		return
+	}
+
+	email, err := h.outboxService.Retry(intID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Failed to retry email", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "data": convertToEmailResponse(email)})
+}
+
+func convertToEmailResponse(e outbox.Email) outbox.EmailResponse {
+	return outbox.EmailResponse{
+		ID:            e.ID,
+		Recipient:     e.Recipient,
+		Subject:       e.Subject,
+		Status:        e.Status,
+		Attempts:      e.Attempts,
+		LastError:     e.LastError,
+		NextAttemptAt: e.NextAttemptAt,
+		SentAt:        e.SentAt,
+		CreatedAt:     e.CreatedAt,
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"errors"
+	"example/hello/internal/phone"
+	"example/hello/internal/user"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SendPhoneVerification mengirim kode OTP lewat SMS ke nomor telepon user.
+func (h *UserHandler) SendPhoneVerification(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	expiresAt, err := h.userService.SendPhoneVerification(actor)
+	if respondPhoneRateLimited(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Failed to send verification code", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Verification code sent", "expires_at": expiresAt})
+}
+
+// VerifyPhone mengonfirmasi nomor telepon dengan kode OTP dari SMS.
+func (h *UserHandler) VerifyPhone(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	var input user.PhoneCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": h.getValidationErrors(err)})
+		return
+	}
+
+	if err := h.userService.VerifyPhone(actor, input.Code); err != nil {
+		if errors.Is(err, phone.ErrTooManyAttempts) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"status": false, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Failed to verify phone number", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Phone number verified"})
+}
+
+// respondPhoneRateLimited mengirim 429 jika kode OTP diminta terlalu sering.
+func respondPhoneRateLimited(c *gin.Context, err error) bool {
+	var limitErr *phone.RateLimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+	retryAfter := int(math.Ceil(limitErr.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{"status": false, "message": limitErr.Error(), "retry_after": retryAfter})
+	return true
+}
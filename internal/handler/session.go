@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"example/hello/internal/apikey"
+	"example/hello/internal/auth"
+	"example/hello/internal/session"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	sessionService session.Service
+	apiKeyService  apikey.Service
+}
+
+func NewSessionHandler(sessionService session.Service, apiKeyService apikey.Service) *SessionHandler {
+	return &SessionHandler{sessionService: sessionService, apiKeyService: apiKeyService}
+}
+
+// ListSessions menampilkan perangkat tempat user sedang login.
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(actor.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to retrieve sessions", "err": err.Error()})
+		return
+	}
+
+	currentID := currentSessionID(c)
+	responses := []session.SessionResponse{}
+	for _, s := range sessions {
+		responses = append(responses, session.SessionResponse{
+			ID:         s.ID,
+			Device:     s.Device,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			Current:    s.ID == currentID,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			ExpiresAt:  s.ExpiresAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "data": responses})
+}
+
+// RevokeSession mengeluarkan satu perangkat. Access token sesi itu langsung ditolak.
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(actor, c.Param("id")); err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"status": false, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to revoke session", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Session revoked successfully"})
+}
+
+// RevokeAllSessions mengeluarkan semua perangkat ("log out everywhere") dan
+// mencabut semua API key. Dengan ?keep_current=true sesi yang sedang dipakai tetap login.
+func (h *SessionHandler) RevokeAllSessions(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	keep := ""
+	if c.Query("keep_current") == "true" {
+		keep = currentSessionID(c)
+	}
+
+	revoked, err := h.sessionService.RevokeOtherSessions(actor, keep)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to revoke sessions", "err": err.Error()})
+		return
+	}
+	revokedKeys, err := h.apiKeyService.RevokeAllForUser(actor, actor.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to revoke API keys", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Sessions revoked successfully", "revoked": revoked, "revoked_api_keys": revokedKeys})
+}
+
+// currentSessionID mengambil sid dari klaim yang disimpan AuthMiddleware.
+func currentSessionID(c *gin.Context) string {
+	claimsVal, exists := c.Get("claims")
+	if !exists {
+		return ""
+	}
+	claims, ok := claimsVal.(*auth.MyClaims)
+	if !ok {
+		return ""
+	}
+	return claims.SessionID
+}
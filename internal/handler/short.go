@@ -68,6 +68,13 @@ func (h *ShortUrlHandler) CreateShortUrl(c *gin.Context) {
 		return
 	}
 
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	shortRequest.UserID = actor.UserID
+
 	short, err := h.shortService.Create(shortRequest)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -1,10 +1,19 @@
 package handler
 
 import (
+	"errors"
+	"example/hello/internal/auth"
+	"example/hello/internal/loginguard"
+	"example/hello/internal/password"
+	"example/hello/internal/phone"
+	"example/hello/internal/policy"
 	"example/hello/internal/user"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -17,13 +26,67 @@ type UserHandler struct {
 func NewUserHandler(userService user.Service) *UserHandler {
 	return &UserHandler{userService: userService}
 }
+
+// GetUsers menampilkan direktori user per halaman. Filter: ?verified=true|false,
+// ?created_from= dan ?created_to= (RFC 3339), ?name= dan ?email= (awalan).
+// Urutan lewat ?sort= (default -created_at), ukuran halaman lewat ?limit= (1-100)
+// dan halaman berikutnya lewat ?cursor= dari next_cursor.
 func (h *UserHandler) GetUsers(c *gin.Context) {
-	users, err := h.userService.FindAll()
+	query, err := parseDirectoryQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	page, err := h.userService.ListUsers(query)
+	if errors.Is(err, user.ErrInvalidSort) || errors.Is(err, user.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to retrieve users"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"status": true, "data": convertToUserResponses(users)})
+
+	var nextCursor *string
+	if page.NextCursor != "" {
+		nextCursor = &page.NextCursor
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "data": convertToUserResponses(page.Users), "next_cursor": nextCursor, "total": page.Total})
+}
+
+func parseDirectoryQuery(c *gin.Context) (user.DirectoryQuery, error) {
+	query := user.DirectoryQuery{
+		Sort:   user.DirectorySort(c.Query("sort")),
+		Cursor: c.Query("cursor"),
+		Limit:  user.DefaultDirectoryLimit,
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > user.MaxDirectoryLimit {
+			return user.DirectoryQuery{}, fmt.Errorf("limit must be between 1 and %d", user.MaxDirectoryLimit)
+		}
+		query.Limit = limit
+	}
+	if v := c.Query("verified"); v != "" {
+		verified, err := strconv.ParseBool(v)
+		if err != nil {
+			return user.DirectoryQuery{}, fmt.Errorf("verified must be true or false")
+		}
+		query.Filter.Verified = &verified
+	}
+	for param, target := range map[string]*time.Time{"created_from": &query.Filter.CreatedFrom, "created_to": &query.Filter.CreatedTo} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return user.DirectoryQuery{}, fmt.Errorf("%s must be an RFC 3339 timestamp", param)
+			}
+			*target = t
+		}
+	}
+	query.Filter.NamePrefix = strings.TrimSpace(c.Query("name"))
+	query.Filter.EmailPrefix = strings.TrimSpace(c.Query("email"))
+	return query, nil
 }
 func (h *UserHandler) GetUserById(c *gin.Context) {
 	intID, err := h.validateID(c)
@@ -74,7 +137,14 @@ func (h *UserHandler) RegisterUser(c *gin.Context) {
 		return
 	}
 
-	newUser, err := h.userService.RegisterUser(userRequest)
+	newUser, err := h.userService.RegisterUser(userRequest, originFromContext(c))
+	if respondPasswordRejected(c, err) {
+		return
+	}
+	if errors.Is(err, phone.ErrInvalidNumber) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Registration failed", "err": err.Error()})
 		return
@@ -98,6 +168,51 @@ func (h *UserHandler) VerifyEmail(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Email verified successfully"})
 }
 
+func (h *UserHandler) ConfirmEmailChange(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Token is required"})
+		return
+	}
+
+	if err := h.userService.ConfirmEmailChange(token, originFromContext(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Email address changed successfully"})
+}
+
+func (h *UserHandler) RevertEmailChange(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Token is required"})
+		return
+	}
+
+	if err := h.userService.RevertEmailChange(token, originFromContext(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Your previous email address has been restored and all sessions were signed out. Please log in again and change your password."})
+}
+
+func (h *UserHandler) UnlockAccount(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Token is required"})
+		return
+	}
+
+	if err := h.userService.UnlockAccount(token, originFromContext(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Account unlocked successfully. You can log in again."})
+}
+
 func (h *UserHandler) ResendVerificationEmail(c *gin.Context) {
 	var input user.ResendVerificationInput
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -121,7 +236,7 @@ func (h *UserHandler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	err := h.userService.ForgotPassword(input.Email)
+	err := h.userService.ForgotPassword(input.Email, originFromContext(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": err.Error()})
 		return
@@ -130,6 +245,11 @@ func (h *UserHandler) ForgotPassword(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": true, "message": "A password reset has been sent. Please check your inbox."})
 }
 
+// GetPasswordPolicy menampilkan aturan password yang berlaku.
+func (h *UserHandler) GetPasswordPolicy(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": true, "data": h.userService.PasswordPolicy()})
+}
+
 func (h *UserHandler) ResetPassword(c *gin.Context) {
 	var newPassword user.ResetPassword
 	if err := c.ShouldBindJSON(&newPassword); err != nil {
@@ -143,7 +263,10 @@ func (h *UserHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	err := h.userService.ResetPassword(token, newPassword.Password)
+	err := h.userService.ResetPassword(token, newPassword.Password, originFromContext(c))
+	if respondPasswordRejected(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": err.Error()})
 		return
@@ -158,12 +281,66 @@ func (h *UserHandler) LoginUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Invalid input data"})
 		return
 	}
-	token, _, err := h.userService.UserLogin(userRequest)
+	result, err := h.userService.UserLogin(userRequest, originFromContext(c))
+	if respondLoginLimited(c, err) || respondDeletionScheduled(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": "Login failed"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"status": true, "token": token})
+	respondLoginResult(c, result)
+}
+
+// respondLoginLimited mengirim 429 jika err berasal dari loginguard.
+func respondLoginLimited(c *gin.Context, err error) bool {
+	var limitErr *loginguard.LimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+	retryAfter := int(math.Ceil(limitErr.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{"status": false, "message": limitErr.Error(), "locked": limitErr.Locked, "retry_after": retryAfter})
+	return true
+}
+
+// respondDeletionScheduled mengirim 403 jika akun dijadwalkan dihapus oleh admin.
+func respondDeletionScheduled(c *gin.Context, err error) bool {
+	if !errors.Is(err, user.ErrDeletionScheduledByAdmin) {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"status": false, "message": err.Error(), "code": "account_deletion_scheduled"})
+	return true
+}
+
+// respondPasswordRejected mengirim 400 beserta semua alasan jika password baru
+// ditolak kebijakan password.
+func respondPasswordRejected(c *gin.Context, err error) bool {
+	var policyErr *password.PolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Password does not meet the password policy", "reasons": policyErr.Violations})
+	return true
+}
+
+// respondLoginResult mengirim pasangan token, atau token tantangan jika 2FA aktif.
+func respondLoginResult(c *gin.Context, result user.LoginResult) {
+	if result.MFARequired {
+		c.JSON(http.StatusOK, gin.H{
+			"status":       true,
+			"mfa_required": true,
+			"mfa_token":    result.MFAToken,
+			"expires_in":   int(auth.MFAChallengeTTL.Seconds()),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":        true,
+		"token":         result.Pair.AccessToken,
+		"refresh_token": result.Pair.RefreshToken,
+		"expires_in":    int(time.Until(result.Pair.ExpiresAt).Seconds()),
+	})
 }
 
 func (h *UserHandler) UpdateUser(c *gin.Context) {
@@ -173,13 +350,30 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
 	var userRequest user.UserRequest
 	if err := c.ShouldBindJSON(&userRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Invalid input data", "err": err.Error()})
 		return
 	}
 
-	updatedUser, err := h.userService.Update(intID, userRequest)
+	updatedUser, err := h.userService.Update(actor, intID, userRequest)
+	if respondPasswordRejected(c, err) {
+		return
+	}
+	if errors.Is(err, phone.ErrInvalidNumber) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+	if errors.Is(err, policy.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"status": false, "message": "You can only update your own account", "err": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to update user", "err": err.Error()})
 		return
@@ -194,11 +388,67 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.Delete(intID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to update user", "err": err.Error()})
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	h.deleteUser(c, actor, intID)
+}
+
+// DeleteMyAccount menjadwalkan penghapusan akun milik user yang sedang login.
+func (h *UserHandler) DeleteMyAccount(c *gin.Context) {
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+	h.deleteUser(c, actor, actor.UserID)
+}
+
+func (h *UserHandler) deleteUser(c *gin.Context, actor policy.Actor, ID int) {
+	deleted, err := h.userService.Delete(actor, ID)
+	if err != nil {
+		if errors.Is(err, policy.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"status": false, "message": "You can only delete your own account", "err": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "Failed to delete user", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":                true,
+		"message":               "Akun dijadwalkan untuk dihapus permanen. Login kembali sebelum tanggal tersebut untuk membatalkan.",
+		"deletion_scheduled_at": deleted.DeletionScheduledAt,
+	})
+}
+
+func (h *UserHandler) UpdateUserRole(c *gin.Context) {
+	intID, err := h.validateID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": err.Error()})
+		return
+	}
+
+	var input user.UpdateRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": h.getValidationErrors(err)})
+		return
+	}
+
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": false, "message": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"status": true, "message": "User deleted successfully"})
+
+	updatedUser, err := h.userService.UpdateRole(actor, intID, input.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "Failed to update user role", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "data": convertToUserResponse(updatedUser)})
 }
 
 func (h *UserHandler) validateID(c *gin.Context) (int, error) {
@@ -231,6 +481,17 @@ func convertToUserResponse(b user.User) user.UserResponse {
 		Name:  b.Name,
 		Email: b.Email,
 		Phone: b.Phone,
+
+		EmailVerified: b.Verivied,
+		PhoneVerified: b.PhoneVerifiedAt != nil,
+		Role:          b.Role,
+		Language:      b.Language,
+
+		TwoFactorEnabled: b.TOTPEnabled,
+		PendingEmail:     b.PendingEmail,
+
+		DeletionScheduledAt: b.DeletionScheduledAt,
+		CreatedAt:           b.CreatedAt,
 	}
 }
 
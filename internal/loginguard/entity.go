@@ -0,0 +1,32 @@
+package loginguard
+
+import "time"
+
+// Attempt mencatat percobaan login gagal untuk satu kunci, misalnya
+// "account:budi@example.com" atau "ip:10.0.0.1".
+type Attempt struct {
+	ID            int
+	Key           string `gorm:"column:attempt_key;type:varchar(255);uniqueIndex"`
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (Attempt) TableName() string {
+	return "login_attempts"
+}
+
+// LimitError dikembalikan saat login ditolak karena terlalu banyak percobaan gagal.
+type LimitError struct {
+	Locked     bool // true jika akun/IP dikunci sementara, false jika hanya perlu menunggu jeda
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	if e.Locked {
+		return "too many failed login attempts, temporarily locked"
+	}
+	return "too many failed login attempts, please wait before trying again"
+}
@@ -0,0 +1,134 @@
+package loginguard
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository menyimpan counter percobaan login. Tersedia dua implementasi:
+// database (dibagi antar instance) dan in-memory (satu proses saja).
+type Repository interface {
+	Find(key string) (Attempt, error)
+	RegisterFailure(key string, now time.Time, window time.Duration) (Attempt, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) Find(key string) (Attempt, error) {
+	var attempt Attempt
+	err := r.db.Where("attempt_key = ?", key).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Attempt{Key: key}, nil
+	}
+	if err != nil {
+		return Attempt{}, err
+	}
+	return attempt, nil
+}
+
+// RegisterFailure menambah counter secara atomik (SELECT ... FOR UPDATE).
+// Counter dimulai ulang jika kegagalan terakhir sudah di luar window.
+func (r *repository) RegisterFailure(key string, now time.Time, window time.Duration) (Attempt, error) {
+	var attempt Attempt
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("attempt_key = ?", key).First(&attempt).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			attempt = Attempt{Key: key, Failures: 1, LastFailureAt: now}
+			return tx.Create(&attempt).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		applyFailure(&attempt, now, window)
+		return tx.Save(&attempt).Error
+	})
+	if err != nil {
+		return Attempt{}, err
+	}
+	return attempt, nil
+}
+
+// Lock mengunci kunci sampai until dan memulai counter dari nol lagi.
+func (r *repository) Lock(key string, until time.Time) error {
+	return r.db.Model(&Attempt{}).Where("attempt_key = ?", key).
+		Updates(map[string]any{"locked_until": until, "failures": 0}).Error
+}
+
+func (r *repository) Reset(key string) error {
+	return r.db.Where("attempt_key = ?", key).Delete(&Attempt{}).Error
+}
+
+type memoryRepository struct {
+	mu    sync.Mutex
+	cache *cache.Cache
+}
+
+// NewMemoryRepository menyimpan counter di memori proses. Cocok untuk satu instance.
+func NewMemoryRepository() *memoryRepository {
+	return &memoryRepository{cache: cache.New(time.Hour, 10*time.Minute)}
+}
+
+func (r *memoryRepository) Find(key string) (Attempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if x, found := r.cache.Get(key); found {
+		return x.(Attempt), nil
+	}
+	return Attempt{Key: key}, nil
+}
+
+func (r *memoryRepository) RegisterFailure(key string, now time.Time, window time.Duration) (Attempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt := Attempt{Key: key}
+	if x, found := r.cache.Get(key); found {
+		attempt = x.(Attempt)
+	}
+	applyFailure(&attempt, now, window)
+	r.cache.Set(key, attempt, cache.DefaultExpiration)
+	return attempt, nil
+}
+
+func (r *memoryRepository) Lock(key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt := Attempt{Key: key}
+	if x, found := r.cache.Get(key); found {
+		attempt = x.(Attempt)
+	}
+	attempt.LockedUntil = &until
+	attempt.Failures = 0
+	r.cache.Set(key, attempt, time.Until(until)+time.Hour)
+	return nil
+}
+
+func (r *memoryRepository) Reset(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache.Delete(key)
+	return nil
+}
+
+func applyFailure(attempt *Attempt, now time.Time, window time.Duration) {
+	if now.Sub(attempt.LastFailureAt) > window {
+		attempt.Failures = 0
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = now
+}
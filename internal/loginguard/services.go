@@ -0,0 +1,124 @@
+package loginguard
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// Jumlah kegagalan yang dibiarkan tanpa jeda.
+	freeFailures = 3
+	// Jeda awal setelah freeFailures, naik dua kali lipat setiap kegagalan berikutnya.
+	baseDelay = time.Second
+	maxDelay  = 30 * time.Second
+
+	// Counter dimulai ulang jika tidak ada kegagalan selama window.
+	failureWindow = 15 * time.Minute
+
+	maxAccountFailures = 5
+	maxIPFailures      = 20
+)
+
+// LockDuration adalah lama akun atau IP dikunci setelah melewati batas kegagalan.
+const LockDuration = 15 * time.Minute
+
+type Service interface {
+	// Check dipanggil sebelum password diperiksa. Mengembalikan *LimitError jika harus ditolak.
+	Check(email, ip string) error
+	// RecordFailure mencatat login gagal. accountLocked bernilai true jika akun baru saja dikunci.
+	RecordFailure(email, ip string) (accountLocked bool, err error)
+	RecordSuccess(email, ip string) error
+	Unlock(email string) error
+}
+
+type service struct {
+	repository Repository
+}
+
+func NewService(repository Repository) *service {
+	return &service{repository}
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func (s *service) Check(email, ip string) error {
+	now := time.Now()
+	for _, key := range []string{accountKey(email), ipKey(ip)} {
+		attempt, err := s.repository.Find(key)
+		if err != nil {
+			return fmt.Errorf("gagal memeriksa percobaan login: %w", err)
+		}
+
+		if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+			return &LimitError{Locked: true, RetryAfter: attempt.LockedUntil.Sub(now)}
+		}
+
+		if now.Sub(attempt.LastFailureAt) > failureWindow {
+			continue
+		}
+		if wait := delayFor(attempt.Failures) - now.Sub(attempt.LastFailureAt); wait > 0 {
+			return &LimitError{RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+func (s *service) RecordFailure(email, ip string) (bool, error) {
+	now := time.Now()
+
+	account, err := s.repository.RegisterFailure(accountKey(email), now, failureWindow)
+	if err != nil {
+		return false, fmt.Errorf("gagal mencatat percobaan login: %w", err)
+	}
+	address, err := s.repository.RegisterFailure(ipKey(ip), now, failureWindow)
+	if err != nil {
+		return false, fmt.Errorf("gagal mencatat percobaan login: %w", err)
+	}
+
+	if address.Failures >= maxIPFailures {
+		if err := s.repository.Lock(address.Key, now.Add(LockDuration)); err != nil {
+			return false, err
+		}
+	}
+
+	if account.Failures >= maxAccountFailures {
+		if err := s.repository.Lock(account.Key, now.Add(LockDuration)); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// RecordSuccess hanya mereset counter akun. Counter IP sengaja tidak direset agar
+// penyerang tidak bisa menghapusnya dengan login ke akunnya sendiri.
+func (s *service) RecordSuccess(email, ip string) error {
+	return s.repository.Reset(accountKey(email))
+}
+
+func (s *service) Unlock(email string) error {
+	return s.repository.Reset(accountKey(email))
+}
+
+// delayFor menghitung jeda minimum setelah sejumlah kegagalan: 0 untuk
+// freeFailures pertama, lalu 1s, 2s, 4s, ... maksimal 30s.
+func delayFor(failures int) time.Duration {
+	if failures < freeFailures {
+		return 0
+	}
+	d := baseDelay
+	for i := freeFailures; i < failures; i++ {
+		d *= 2
+		if d >= maxDelay {
+			return maxDelay
+		}
+	}
+	return d
+}
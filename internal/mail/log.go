@@ -0,0 +1,53 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// logMailer tidak mengirim email sungguhan. Pesan ditulis ke log dan, jika dir
+// diisi, disimpan sebagai file .eml/.html/.txt agar template bisa dipratinjau di browser.
+type logMailer struct {
+	dir string
+}
+
+func NewLogMailer(dir string) *logMailer {
+	return &logMailer{dir: dir}
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+func (m *logMailer) Send(msg Message) error {
+	log.Printf("[mail] To: %s | Subject: %s\n%s", strings.Join(msg.To, ", "), msg.Subject, msg.TextBody)
+
+	if m.dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("gagal membuat direktori outbox: %w", err)
+	}
+
+	base := fmt.Sprintf("%s-%s", time.Now().Format("20060102-150405.000"), unsafeFileChars.ReplaceAllString(msg.Subject, "_"))
+	raw, err := buildMIME("outbox@localhost", msg)
+	if err != nil {
+		return fmt.Errorf("gagal menyusun email: %w", err)
+	}
+
+	files := map[string][]byte{
+		base + ".eml":  raw,
+		base + ".html": []byte(msg.HTMLBody),
+		base + ".txt":  []byte(msg.TextBody),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(m.dir, name), content, 0o644); err != nil {
+			return fmt.Errorf("gagal menulis %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package mail
+
+// Message adalah satu email yang siap dikirim. HTMLBody dan TextBody dikirim
+// bersama sebagai multipart/alternative agar client email bisa memilih.
+type Message struct {
+	To       []string
+	Subject  string
+	HTMLBody string
+	TextBody string
+}
+
+// Mailer adalah abstraksi pengiriman email. Implementasi yang tersedia:
+// SMTP (produksi), log/file outbox (pengembangan lokal) dan in-memory (testing).
+type Mailer interface {
+	Send(msg Message) error
+}
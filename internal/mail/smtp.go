@@ -0,0 +1,88 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+)
+
+// SMTPConfig berisi konfigurasi server SMTP. Dibaca sekali saat startup.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+type smtpMailer struct {
+	config SMTPConfig
+}
+
+func NewSMTPMailer(config SMTPConfig) *smtpMailer {
+	return &smtpMailer{config: config}
+}
+
+func (m *smtpMailer) Send(msg Message) error {
+	addr := fmt.Sprintf("%s:%s", m.config.Host, m.config.Port)
+	auth := smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+
+	body, err := buildMIME(m.config.From, msg)
+	if err != nil {
+		return fmt.Errorf("gagal menyusun email: %w", err)
+	}
+
+	if err := smtp.SendMail(addr, auth, m.config.From, msg.To, body); err != nil {
+		return fmt.Errorf("gagal mengirim email ke %s: %w", strings.Join(msg.To, ", "), err)
+	}
+	return nil
+}
+
+// buildMIME menyusun pesan multipart/alternative dengan bagian text/plain dan text/html.
+func buildMIME(from string, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", writer.Boundary())
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain", msg.TextBody},
+		{"text/html", msg.HTMLBody},
+	}
+	for _, p := range parts {
+		if p.body == "" {
+			continue
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", p.contentType+"; charset=\"UTF-8\"")
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		partWriter, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(partWriter)
+		if _, err := qp.Write([]byte(p.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,101 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// DefaultLanguage dipakai jika user belum memilih bahasa atau template
+// untuk bahasa tersebut belum ada.
+const DefaultLanguage = "id"
+
+// Setiap template berada di templates/<nama>/<bahasa>.html dan <bahasa>.txt.
+// File .txt wajib mendefinisikan blok "subject".
+//
+//go:embed templates
+var templateFS embed.FS
+
+type Renderer struct {
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+// NewRenderer mem-parse semua template yang di-embed. Error di sini berarti
+// ada template yang rusak, jadi sebaiknya aplikasi berhenti saat startup.
+func NewRenderer() (*Renderer, error) {
+	r := &Renderer{
+		html: make(map[string]*htmltemplate.Template),
+		text: make(map[string]*texttemplate.Template),
+	}
+
+	err := fs.WalkDir(templateFS, "templates", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		name := path.Base(path.Dir(p))
+		lang := strings.TrimSuffix(path.Base(p), path.Ext(p))
+		key := name + "/" + lang
+
+		switch path.Ext(p) {
+		case ".html":
+			t, err := htmltemplate.ParseFS(templateFS, p)
+			if err != nil {
+				return fmt.Errorf("template %s: %w", p, err)
+			}
+			r.html[key] = t
+		case ".txt":
+			t, err := texttemplate.ParseFS(templateFS, p)
+			if err != nil {
+				return fmt.Errorf("template %s: %w", p, err)
+			}
+			if t.Lookup("subject") == nil {
+				return fmt.Errorf("template %s tidak memiliki blok subject", p)
+			}
+			r.text[key] = t
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Render menghasilkan Message (tanpa penerima) dari template name dalam bahasa lang.
+func (r *Renderer) Render(name, lang string, data any) (Message, error) {
+	key := name + "/" + lang
+	if _, ok := r.text[key]; !ok {
+		key = name + "/" + DefaultLanguage
+	}
+
+	textTmpl, ok := r.text[key]
+	if !ok {
+		return Message{}, fmt.Errorf("template email %q tidak ditemukan", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := textTmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("gagal render subject %s: %w", key, err)
+	}
+	if err := textTmpl.Execute(&text, data); err != nil {
+		return Message{}, fmt.Errorf("gagal render teks %s: %w", key, err)
+	}
+	if htmlTmpl, ok := r.html[key]; ok {
+		if err := htmlTmpl.Execute(&html, data); err != nil {
+			return Message{}, fmt.Errorf("gagal render html %s: %w", key, err)
+		}
+	}
+
+	return Message{
+		Subject:  strings.TrimSpace(subject.String()),
+		TextBody: strings.TrimSpace(text.String()) + "\n",
+		HTMLBody: html.String(),
+	}, nil
+}
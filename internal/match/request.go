@@ -1,7 +1,6 @@
 package match
 
 type MatchRequest struct {
-	UserID     int      // Akan diisi oleh handler, bukan dari request body
 	Age        int      `json:"age" binding:"required"`
 	Gender     Gender   `json:"gender" binding:"required,oneof=boy girl"`
 	Interested Interest `json:"interested" binding:"required,oneof=boy girl any"`
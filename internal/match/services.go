@@ -1,8 +1,11 @@
 package match
 
 import (
+	"example/hello/internal/audit"
+	"example/hello/internal/policy"
 	"fmt"
 	"log"
+	"strconv"
 	"time"
 
 	"github.com/patrickmn/go-cache"
@@ -12,13 +15,14 @@ type Service interface {
 	GetAll() ([]Match, error)
 	FindByID(ID int) (Match, error)
 	FindByCity(city string) ([]Match, error)
-	Create(matchRequest MatchRequest) (Match, error)
-	Update(ID int, match MatchRequest) (Match, error)
-	Delete(ID int) error
+	Create(actor policy.Actor, matchRequest MatchRequest) (Match, error)
+	Update(actor policy.Actor, ID int, match MatchRequest) (Match, error)
+	Delete(actor policy.Actor, ID int) error
 }
 
 type service struct {
 	repository Repository
+	audit      audit.Recorder
 	cache      *cache.Cache
 }
 
@@ -27,21 +31,22 @@ const (
 	matchByIDCacheKeyPrefix = "match_by_url_"
 )
 
-func NewService(repository Repository) *service {
+func NewService(repository Repository, auditRecorder audit.Recorder) *service {
 	// Inisialisasi cache:
 	// - 5 menit (5*time.Minute) untuk default expiration
 	// - 10 menit (10*time.Minute) untuk cleanup interval (seberapa sering item kadaluarsa dihapus)
 	c := cache.New(5*time.Minute, 10*time.Minute)
 	return &service{
 		repository: repository,
+		audit:      auditRecorder,
 		cache:      c,
 	}
 }
 
-func (s *service) Create(matchRequest MatchRequest) (Match, error) {
+func (s *service) Create(actor policy.Actor, matchRequest MatchRequest) (Match, error) {
 
 	data := Match{
-		UserID:     matchRequest.UserID,
+		UserID:     actor.UserID,
 		Age:        matchRequest.Age,
 		Gender:     matchRequest.Gender,
 		Interested: matchRequest.Interested,
@@ -57,23 +62,30 @@ func (s *service) Create(matchRequest MatchRequest) (Match, error) {
 	}
 
 	s.cache.Delete(allMatchsCacheKey)
+	s.recordEvent(audit.ActionMatchCreated, actor, created.ID, created.UserID)
 
 	return created, nil
 }
 
-func (s *service) Delete(ID int) error {
+func (s *service) Delete(actor policy.Actor, ID int) error {
 	// Pastikan match ada sebelum menghapus
-	_, err := s.repository.FindByID(ID)
+	existing, err := s.repository.FindByID(ID)
 	if err != nil {
 		return fmt.Errorf("match dengan ID %d tidak ditemukan: %w", ID, err)
 	}
 
+	if err := policy.CanModify(actor, existing.UserID, "profile"); err != nil {
+		return err
+	}
+
 	if err := s.repository.Delete(ID); err != nil {
 		return err
 	}
 
 	// Hapus cache yang relevan
 	s.cache.Delete(allMatchsCacheKey)
+	s.cache.Delete(fmt.Sprintf("%s%d", matchByIDCacheKeyPrefix, ID))
+	s.recordEvent(audit.ActionMatchDeleted, actor, ID, existing.UserID)
 	return nil
 }
 
@@ -112,12 +124,16 @@ func (s *service) FindByID(ID int) (Match, error) {
 	return match, nil
 }
 
-func (s *service) Update(ID int, match MatchRequest) (Match, error) {
+func (s *service) Update(actor policy.Actor, ID int, match MatchRequest) (Match, error) {
 	data, err := s.repository.FindByID(ID)
 	if err != nil {
 		return Match{}, err
 	}
 
+	if err := policy.CanModify(actor, data.UserID, "profile"); err != nil {
+		return Match{}, err
+	}
+
 	data.Age = match.Age
 	data.Gender = match.Gender
 	data.Interested = match.Interested
@@ -135,6 +151,19 @@ func (s *service) Update(ID int, match MatchRequest) (Match, error) {
 	s.cache.Delete(allMatchsCacheKey)
 	// 2. Hapus cache untuk match spesifik yang baru saja di-update.
 	s.cache.Delete(fmt.Sprintf("%s%d", matchByIDCacheKeyPrefix, ID))
+	s.recordEvent(audit.ActionMatchUpdated, actor, ID, data.UserID)
 
 	return updatedMatch, nil
 }
+
+// recordEvent mencatat perubahan profil match ke audit log.
+func (s *service) recordEvent(action audit.Action, actor policy.Actor, matchID, ownerID int) {
+	s.audit.Record(audit.Entry{
+		ActorID:    actor.UserID,
+		Action:     action,
+		TargetType: "match",
+		TargetID:   strconv.Itoa(matchID),
+		Origin:     audit.ActorOrigin(actor),
+		Metadata:   map[string]any{"owner_id": ownerID},
+	})
+}
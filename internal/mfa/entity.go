@@ -0,0 +1,13 @@
+package mfa
+
+import "time"
+
+// RecoveryCode adalah kode cadangan sekali pakai untuk login saat authenticator tidak tersedia.
+// Hanya hash-nya yang disimpan.
+type RecoveryCode struct {
+	ID        int
+	UserID    int    `gorm:"index"`
+	CodeHash  string `gorm:"type:varchar(64);index"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
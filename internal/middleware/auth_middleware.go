@@ -1,7 +1,10 @@
 package middleware
 
 import (
+	"errors"
+	"example/hello/internal/apikey"
 	"example/hello/internal/auth"
+	"example/hello/internal/session"
 	"fmt"
 	"net/http"
 	"strings"
@@ -9,8 +12,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware memverifikasi Bearer Token JWT dari header Authorization.
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware memverifikasi Bearer Token dari header Authorization. Token bisa
+// berupa JWT, yang ditolak jika jti-nya sudah dicabut (logout atau reuse refresh
+// token) atau sesinya sudah dicabut dari daftar sesi user, atau API key berawalan
+// apikey.TokenPrefix yang hanya diterima di rute yang memasang RequireScope.
+func AuthMiddleware(sessionService session.Service, apiKeyService apikey.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -25,6 +31,11 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if strings.HasPrefix(tokenString, apikey.TokenPrefix) {
+			authenticateAPIKey(c, apiKeyService, tokenString)
+			return
+		}
+
 		// Validasi token
 		claims, err := auth.ValidateToken(tokenString)
 		if err != nil {
@@ -32,10 +43,66 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		revoked, err := sessionService.IsRevoked(claims.ID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check token status", "status": false})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked", "status": false})
+			return
+		}
+
+		// Token yang diterbitkan sebelum ada tabel sessions tidak membawa sid.
+		if claims.SessionID != "" {
+			active, err := sessionService.IsSessionActive(claims.SessionID, c.ClientIP())
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check session status", "status": false})
+				return
+			}
+			if !active {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked", "status": false})
+				return
+			}
+		}
+
 		// Simpan informasi user dari klaim di konteks Gin
 		c.Set("userID", claims.UserID)
 		c.Set("verified", claims.Verified)
+		c.Set("role", claims.Role)
+		c.Set("claims", claims)
 
 		c.Next() // Lanjutkan ke handler berikutnya
 	}
 }
+
+// authenticateAPIKey memvalidasi API key dan scope yang diminta rute. Rute tanpa
+// RequireScope menolak API key, jadi endpoint baru tidak otomatis terbuka untuk token.
+func authenticateAPIKey(c *gin.Context, apiKeyService apikey.Service, rawToken string) {
+	principal, err := apiKeyService.Authenticate(rawToken, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, apikey.ErrInvalidAPIKey) || errors.Is(err, apikey.ErrAPIKeyExpired) || errors.Is(err, apikey.ErrOwnerNotAllowed) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "status": false})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check API key", "status": false})
+		return
+	}
+
+	required, declared := requiredScope(c)
+	if !declared {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key cannot be used for this endpoint", "status": false})
+		return
+	}
+	if !principal.Key.HasScope(required) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("API key is missing required scope %s", required), "status": false})
+		return
+	}
+
+	c.Set("userID", fmt.Sprintf("%d", principal.UserID))
+	c.Set("verified", principal.Verified)
+	c.Set("role", principal.Role)
+	c.Set("apiKey", principal.Key)
+
+	c.Next()
+}
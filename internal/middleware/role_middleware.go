@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"example/hello/internal/user"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole hanya meloloskan request dari user dengan salah satu role yang diberikan.
+// Harus dipasang setelah AuthMiddleware karena role dibaca dari konteks.
+func RequireRole(roles ...user.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roleVal, exists := c.Get("role")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated", "status": false})
+			return
+		}
+
+		role, _ := roleVal.(string)
+		for _, allowed := range roles {
+			if user.Role(role) == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this resource", "status": false})
+	}
+}
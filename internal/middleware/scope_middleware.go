@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"example/hello/internal/apikey"
+
+	"github.com/gin-gonic/gin"
+)
+
+const requiredScopeKey = "requiredScope"
+
+// RequireScope menandai scope yang harus dimiliki API key untuk mengakses rute.
+// Harus dipasang sebelum AuthMiddleware karena pemeriksaannya dilakukan di sana.
+// Request dengan JWT tidak dibatasi scope.
+func RequireScope(scope apikey.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(requiredScopeKey, scope)
+		c.Next()
+	}
+}
+
+func requiredScope(c *gin.Context) (apikey.Scope, bool) {
+	val, exists := c.Get(requiredScopeKey)
+	if !exists {
+		return "", false
+	}
+	scope, ok := val.(apikey.Scope)
+	return scope, ok
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerificationChecker membaca status verifikasi email terbaru dari server.
+type VerificationChecker interface {
+	IsEmailVerified(userID int) (bool, error)
+}
+
+// RequireVerified hanya meloloskan user yang email-nya sudah terverifikasi.
+// Harus dipasang setelah AuthMiddleware. Klaim "verified" di access token bisa
+// basi (token diterbitkan sebelum user klik link verifikasi), jadi klaim false
+// diperiksa ulang ke server lewat checker yang memakai cache.
+func RequireVerified(checker VerificationChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool("verified") {
+			c.Next()
+			return
+		}
+
+		userID, err := strconv.Atoi(c.GetString("userID"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated", "status": false})
+			return
+		}
+
+		verified, err := checker.IsEmailVerified(userID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check email verification", "status": false})
+			return
+		}
+		if !verified {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Email verification required", "code": "email_not_verified", "status": false})
+			return
+		}
+
+		c.Set("verified", true)
+		c.Next()
+	}
+}
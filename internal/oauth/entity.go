@@ -0,0 +1,20 @@
+package oauth
+
+import "time"
+
+// PendingState adalah flow login atau handoff yang menunggu diambil. Disimpan
+// di database agar callback dan exchange bisa ditangani instance mana pun.
+// Hanya hash state/code yang disimpan dan Payload dienkripsi dengan kunci
+// turunan dari state/code tersebut, jadi isi tabel saja tidak cukup untuk
+// memakai flow atau membaca token di dalam handoff.
+type PendingState struct {
+	ID        int
+	KeyHash   string    `gorm:"type:varchar(64);uniqueIndex"`
+	Payload   []byte    `gorm:"type:blob"`
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
+
+func (PendingState) TableName() string {
+	return "oauth_states"
+}
@@ -0,0 +1,228 @@
+package oauth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"example/hello/internal/securetoken"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// ErrorCode adalah kode error yang dikirim ke SPA lewat parameter ?error=
+// atau field error_code, sehingga frontend bisa menampilkan pesan yang tepat.
+type ErrorCode string
+
+const (
+	ErrorUnknownProvider    ErrorCode = "unknown_provider"
+	ErrorInvalidRedirectURI ErrorCode = "invalid_redirect_uri"
+	ErrorInvalidState       ErrorCode = "invalid_state"
+	ErrorAccessDenied       ErrorCode = "access_denied"
+	ErrorExchangeFailed     ErrorCode = "exchange_failed"
+	ErrorInvalidNonce       ErrorCode = "invalid_nonce"
+	ErrorInvalidCode        ErrorCode = "invalid_code"
+	ErrorEmailNotVerified   ErrorCode = "email_not_verified"
+	ErrorServer             ErrorCode = "server_error"
+)
+
+const (
+	// FlowTTL adalah batas waktu user menyelesaikan login di halaman provider.
+	FlowTTL = 10 * time.Minute
+	// HandoffTTL adalah masa berlaku code sekali pakai yang ditukar SPA dengan token.
+	HandoffTTL = time.Minute
+)
+
+// Flow adalah data satu proses login yang disimpan di server, dicari dengan state.
+type Flow struct {
+	Provider    string
+	Verifier    string // PKCE code verifier
+	Nonce       string
+	RedirectURI string // Kosong berarti callback membalas JSON (client non-browser)
+	Intent      string // "link" untuk menautkan provider ke akun yang sedang login
+}
+
+// StateStore menyimpan Flow dan hasil login (handoff) di database, sehingga
+// login bisa dimulai dan diselesaikan di instance yang berbeda. Keduanya hanya
+// bisa diambil sekali.
+type StateStore struct {
+	repository Repository
+}
+
+func NewStateStore(repository Repository) *StateStore {
+	return &StateStore{repository: repository}
+}
+
+// NewFlow membuat state, PKCE verifier dan nonce baru lalu menyimpan flow-nya.
+func (s *StateStore) NewFlow(provider, redirectURI, intent string) (string, Flow, error) {
+	state, err := securetoken.Generate()
+	if err != nil {
+		return "", Flow{}, err
+	}
+	nonce, err := securetoken.Generate()
+	if err != nil {
+		return "", Flow{}, err
+	}
+
+	flow := Flow{
+		Provider:    provider,
+		Verifier:    oauth2.GenerateVerifier(),
+		Nonce:       nonce,
+		RedirectURI: redirectURI,
+		Intent:      intent,
+	}
+	if err := s.save("flow", state, flow, FlowTTL); err != nil {
+		return "", Flow{}, err
+	}
+
+	if err := s.repository.DeleteExpired(time.Now()); err != nil {
+		log.Printf("Gagal membersihkan state OAuth kadaluarsa: %v", err)
+	}
+	return state, flow, nil
+}
+
+// TakeFlow mengambil lalu menghapus flow, sehingga state tidak bisa dipakai ulang.
+func (s *StateStore) TakeFlow(state string) (Flow, bool, error) {
+	var flow Flow
+	ok, err := s.take("flow", state, &flow)
+	return flow, ok, err
+}
+
+// SaveHandoff menyimpan hasil login dan mengembalikan code sekali pakai untuk SPA.
+func (s *StateStore) SaveHandoff(result any) (string, error) {
+	code, err := securetoken.Generate()
+	if err != nil {
+		return "", err
+	}
+	if err := s.save("handoff", code, result, HandoffTTL); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// TakeHandoff menukar code sekali pakai dengan hasil login, yang dibaca ke result.
+func (s *StateStore) TakeHandoff(code string, result any) (bool, error) {
+	return s.take("handoff", code, result)
+}
+
+func (s *StateStore) save(kind, key string, value any, ttl time.Duration) error {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	payload, err := seal(kind, key, plaintext)
+	if err != nil {
+		return err
+	}
+	return s.repository.Create(PendingState{
+		KeyHash:   securetoken.Hash(kind + ":" + key),
+		Payload:   payload,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+}
+
+func (s *StateStore) take(kind, key string, value any) (bool, error) {
+	if key == "" {
+		return false, nil
+	}
+	state, ok, err := s.repository.Take(securetoken.Hash(kind+":"+key), time.Now())
+	if err != nil || !ok {
+		return false, err
+	}
+	plaintext, err := open(kind, key, state.Payload)
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(plaintext, value); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// sealKey menurunkan kunci AES-256 dari state/code. Berbeda dari KeyHash yang
+// disimpan, sehingga kunci tidak bisa didapat dari isi tabel.
+func sealKey(kind, key string) []byte {
+	sum := sha256.Sum256([]byte("seal:" + kind + ":" + key))
+	return sum[:]
+}
+
+func seal(kind, key string, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(sealKey(kind, key))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(kind, key string, payload []byte) ([]byte, error) {
+	block, err := aes.NewCipher(sealKey(kind, key))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) < gcm.NonceSize() {
+		return nil, errors.New("payload state OAuth tidak valid")
+	}
+	nonce, ciphertext := payload[:gcm.NonceSize()], payload[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// RedirectAllowlist berisi redirect_uri SPA yang boleh menerima hasil login.
+// Dicocokkan persis pada scheme, host dan path; query string boleh berbeda.
+type RedirectAllowlist []string
+
+// ParseRedirectAllowlist membaca daftar URI yang dipisah koma.
+func ParseRedirectAllowlist(s string) RedirectAllowlist {
+	var list RedirectAllowlist
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func (a RedirectAllowlist) Allows(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil || u.Fragment != "" || u.User != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	target := u.Scheme + "://" + u.Host + u.Path
+	for _, allowed := range a {
+		if target == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// RedirectWith menambahkan parameter ke redirect_uri tanpa membuang query yang sudah ada.
+func RedirectWith(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,113 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+// GitHubConfig adalah konfigurasi OAuth App GitHub. GitHub bukan issuer OIDC
+// untuk login user, jadi profil diambil dari REST API.
+type GitHubConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Endpoint dan APIURL bisa diganti untuk GitHub Enterprise atau test.
+	Endpoint   oauth2.Endpoint
+	APIURL     string
+	HTTPClient *http.Client
+}
+
+type githubProvider struct {
+	config oauth2.Config
+	apiURL string
+	client *http.Client
+}
+
+func NewGitHubProvider(cfg GitHubConfig) Provider {
+	if cfg.Endpoint.AuthURL == "" {
+		cfg.Endpoint = github.Endpoint
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = "https://api.github.com"
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &githubProvider{
+		config: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     cfg.Endpoint,
+			Scopes:       []string{"read:user", "user:email"},
+		},
+		apiURL: strings.TrimSuffix(cfg.APIURL, "/"),
+		client: cfg.HTTPClient,
+	}
+}
+
+func (p *githubProvider) Name() string {
+	return "github"
+}
+
+// AuthCodeURL mengirim challenge PKCE. GitHub bukan OIDC sehingga nonce tidak dipakai.
+func (p *githubProvider) AuthCodeURL(state, verifier, _ string) string {
+	return p.config.AuthCodeURL(state, authCodeOptions(verifier, "")...)
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, code, verifier, _ string) (Profile, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+	token, err := p.config.Exchange(ctx, code, exchangeOptions(verifier)...)
+	if err != nil {
+		return Profile{}, fmt.Errorf("code exchange gagal: %w", err)
+	}
+
+	var account struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, p.client, p.apiURL+"/user", token.AccessToken, &account); err != nil {
+		return Profile{}, fmt.Errorf("gagal mengambil profil GitHub: %w", err)
+	}
+	if account.ID == 0 {
+		return Profile{}, errors.New("profil GitHub tidak memiliki id")
+	}
+
+	// Email di /user bisa kosong atau tidak terverifikasi, jadi pakai email utama
+	// yang sudah diverifikasi dari /user/emails.
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, p.client, p.apiURL+"/user/emails", token.AccessToken, &emails); err != nil {
+		return Profile{}, fmt.Errorf("gagal mengambil email GitHub: %w", err)
+	}
+
+	profile := Profile{
+		Provider: p.Name(),
+		Subject:  strconv.FormatInt(account.ID, 10),
+		Name:     account.Name,
+	}
+	if profile.Name == "" {
+		profile.Name = account.Login
+	}
+	for _, e := range emails {
+		if e.Primary {
+			profile.Email = e.Email
+			profile.EmailVerified = e.Verified
+			break
+		}
+	}
+	return profile, nil
+}
@@ -0,0 +1,236 @@
+package oauth
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// OIDCConfig adalah konfigurasi client untuk satu issuer OpenID Connect.
+type OIDCConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Scopes tambahan selain "openid", "email" dan "profile".
+	Scopes []string
+	// HTTPClient opsional, berguna untuk test dengan server OIDC palsu.
+	HTTPClient *http.Client
+}
+
+// discoveryDocument adalah bagian dari /.well-known/openid-configuration yang kita pakai.
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcProvider struct {
+	name        string
+	config      oauth2.Config
+	issuers     []string
+	userInfoURL string
+	client      *http.Client
+	keys        *keySet
+}
+
+// Discover membuat provider OIDC dari discovery document milik issuer.
+func Discover(ctx context.Context, cfg OIDCConfig) (Provider, error) {
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	wellKnown := strings.TrimSuffix(cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	var doc discoveryDocument
+	if err := getJSON(ctx, client, wellKnown, "", &doc); err != nil {
+		return nil, fmt.Errorf("discovery OIDC %s gagal: %w", cfg.Issuer, err)
+	}
+	// Issuer di dokumen wajib sama persis dengan yang dikonfigurasi (OIDC Discovery 4.3).
+	if doc.Issuer != cfg.Issuer {
+		return nil, fmt.Errorf("issuer discovery %q tidak sama dengan %q", doc.Issuer, cfg.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery OIDC %s tidak lengkap", cfg.Issuer)
+	}
+
+	return newOIDCProvider(cfg, client, oauth2.Endpoint{
+		AuthURL:  doc.AuthorizationEndpoint,
+		TokenURL: doc.TokenEndpoint,
+	}, []string{doc.Issuer}, doc.JWKSURI, doc.UserInfoEndpoint), nil
+}
+
+// NewGoogleProvider membuat provider Google tanpa discovery saat startup
+// karena endpoint Google sudah diketahui.
+func NewGoogleProvider(clientID, clientSecret, redirectURL string) Provider {
+	cfg := OIDCConfig{
+		Name:         "google",
+		Issuer:       "https://accounts.google.com",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+	}
+	// Google menerbitkan ID token dengan iss dengan atau tanpa "https://".
+	return newOIDCProvider(cfg, &http.Client{Timeout: 10 * time.Second}, google.Endpoint,
+		[]string{"https://accounts.google.com", "accounts.google.com"},
+		"https://www.googleapis.com/oauth2/v3/certs",
+		"https://openidconnect.googleapis.com/v1/userinfo")
+}
+
+func newOIDCProvider(cfg OIDCConfig, client *http.Client, endpoint oauth2.Endpoint, issuers []string, jwksURL, userInfoURL string) *oidcProvider {
+	return &oidcProvider{
+		name: cfg.Name,
+		config: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     endpoint,
+			Scopes:       append([]string{"openid", "email", "profile"}, cfg.Scopes...),
+		},
+		issuers:     issuers,
+		userInfoURL: userInfoURL,
+		client:      client,
+		keys:        newKeySet(client, jwksURL),
+	}
+}
+
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+func (p *oidcProvider) AuthCodeURL(state, verifier, nonce string) string {
+	return p.config.AuthCodeURL(state, authCodeOptions(verifier, nonce)...)
+}
+
+// idTokenClaims adalah klaim standar OIDC yang kita butuhkan dari ID token.
+type idTokenClaims struct {
+	Email string `json:"email"`
+	// Beberapa provider mengirim email_verified sebagai string "true".
+	EmailVerified any    `json:"email_verified"`
+	Name          string `json:"name"`
+	Nonce         string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// Exchange menukar authorization code dengan token, lalu memverifikasi ID token
+// (tanda tangan dari JWKS issuer, iss, aud, exp, nonce) sebelum profil dipercaya.
+func (p *oidcProvider) Exchange(ctx context.Context, code, verifier, nonce string) (Profile, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+	token, err := p.config.Exchange(ctx, code, exchangeOptions(verifier)...)
+	if err != nil {
+		return Profile{}, fmt.Errorf("code exchange gagal: %w", err)
+	}
+
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" {
+		return Profile{}, errors.New("respons token tidak berisi id_token")
+	}
+
+	claims := &idTokenClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.keys.key(ctx, kid, t.Method.Alg())
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return Profile{}, fmt.Errorf("id_token tidak valid: %w", err)
+	}
+	if !p.validIssuer(claims.Issuer) {
+		return Profile{}, fmt.Errorf("issuer id_token %q tidak dikenal", claims.Issuer)
+	}
+	if claims.Subject == "" {
+		return Profile{}, errors.New("id_token tidak memiliki sub")
+	}
+	if nonce != "" && subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return Profile{}, ErrNonceMismatch
+	}
+
+	profile := Profile{
+		Provider:      p.name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: isTrue(claims.EmailVerified),
+		Name:          claims.Name,
+	}
+
+	// Sebagian issuer tidak menaruh email di ID token, ambil dari userinfo.
+	if profile.Email == "" && p.userInfoURL != "" {
+		var info struct {
+			Sub           string `json:"sub"`
+			Email         string `json:"email"`
+			EmailVerified any    `json:"email_verified"`
+			Name          string `json:"name"`
+		}
+		if err := getJSON(ctx, p.client, p.userInfoURL, token.AccessToken, &info); err != nil {
+			return Profile{}, fmt.Errorf("gagal mengambil userinfo: %w", err)
+		}
+		// Userinfo wajib untuk subject yang sama (OIDC Core 5.3.2).
+		if info.Sub != profile.Subject {
+			return Profile{}, errors.New("sub userinfo tidak sama dengan id_token")
+		}
+		profile.Email = info.Email
+		profile.EmailVerified = isTrue(info.EmailVerified)
+		if profile.Name == "" {
+			profile.Name = info.Name
+		}
+	}
+
+	return profile, nil
+}
+
+func (p *oidcProvider) validIssuer(iss string) bool {
+	for _, allowed := range p.issuers {
+		if iss == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func isTrue(v any) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	}
+	return false
+}
+
+// getJSON melakukan GET dan men-decode respons JSON. bearer opsional.
+func getJSON(ctx context.Context, client *http.Client, url, bearer string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,93 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrUnknownProvider dikembalikan jika nama provider tidak terdaftar di Registry.
+	ErrUnknownProvider = errors.New("provider OAuth tidak dikenal")
+	// ErrNonceMismatch dikembalikan jika nonce di ID token tidak sama dengan yang dikirim saat login.
+	ErrNonceMismatch = errors.New("nonce id_token tidak cocok")
+)
+
+// Profile adalah identitas user yang sudah diverifikasi oleh provider.
+// Subject unik dan stabil per provider, berbeda dengan email yang bisa berubah.
+type Profile struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider adalah satu penyedia login OAuth2/OIDC.
+//
+// verifier adalah PKCE code verifier (dikirim sebagai challenge S256) dan nonce
+// dicocokkan dengan ID token oleh provider OIDC. Provider non-OIDC mengabaikan nonce.
+type Provider interface {
+	Name() string
+	AuthCodeURL(state, verifier, nonce string) string
+	Exchange(ctx context.Context, code, verifier, nonce string) (Profile, error)
+}
+
+// authCodeOptions menyusun parameter PKCE dan nonce untuk URL otorisasi.
+func authCodeOptions(verifier, nonce string) []oauth2.AuthCodeOption {
+	var opts []oauth2.AuthCodeOption
+	if verifier != "" {
+		opts = append(opts, oauth2.S256ChallengeOption(verifier))
+	}
+	if nonce != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("nonce", nonce))
+	}
+	return opts
+}
+
+// exchangeOptions menyertakan PKCE code verifier saat menukar code.
+func exchangeOptions(verifier string) []oauth2.AuthCodeOption {
+	if verifier == "" {
+		return nil
+	}
+	return []oauth2.AuthCodeOption{oauth2.VerifierOption(verifier)}
+}
+
+// Registry menyimpan provider yang aktif berdasarkan nama (misalnya "google", "github").
+type Registry struct {
+	providers map[string]Provider
+}
+
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: make(map[string]Provider)}
+	for _, p := range providers {
+		r.Register(p)
+	}
+	return r
+}
+
+// Register menambahkan provider. Provider dengan nama yang sama akan diganti.
+func (r *Registry) Register(p Provider) {
+	r.providers[p.Name()] = p
+}
+
+func (r *Registry) Get(name string) (Provider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, name)
+	}
+	return p, nil
+}
+
+// Names mengembalikan nama semua provider yang terdaftar, urut abjad.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,50 @@
+package oauth
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(state PendingState) error
+	// Take mengambil lalu menghapus state yang belum kadaluarsa. false berarti
+	// state tidak ada, sudah kadaluarsa atau lebih dulu diambil request lain.
+	Take(keyHash string, now time.Time) (PendingState, bool, error)
+	DeleteExpired(before time.Time) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) Create(state PendingState) error {
+	return r.db.Create(&state).Error
+}
+
+func (r *repository) Take(keyHash string, now time.Time) (PendingState, bool, error) {
+	var state PendingState
+	err := r.db.Where("key_hash = ? AND expires_at > ?", keyHash, now).First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return PendingState{}, false, nil
+	}
+	if err != nil {
+		return PendingState{}, false, err
+	}
+
+	// Hanya request yang berhasil menghapus baris yang boleh memakainya.
+	result := r.db.Where("id = ?", state.ID).Delete(&PendingState{})
+	if result.Error != nil {
+		return PendingState{}, false, result.Error
+	}
+	return state, result.RowsAffected == 1, nil
+}
+
+func (r *repository) DeleteExpired(before time.Time) error {
+	return r.db.Where("expires_at < ?", before).Delete(&PendingState{}).Error
+}
@@ -0,0 +1,6 @@
+package oauth
+
+// ExchangeRequest adalah body POST /v1/auth/exchange dari SPA.
+type ExchangeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
@@ -0,0 +1,33 @@
+package outbox
+
+import "time"
+
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusSent    Status = "sent"
+	StatusDead    Status = "dead" // Gagal terus sampai MaxAttempts, menunggu retry manual dari admin
+)
+
+// Email adalah satu baris antrean email. Ditulis dalam transaksi yang sama dengan
+// perubahan data user, lalu dikirim oleh Worker di background.
+type Email struct {
+	ID            int
+	Recipient     string `gorm:"type:varchar(255);not null"`
+	Subject       string `gorm:"type:varchar(255)"`
+	HTMLBody      string `gorm:"type:text"`
+	TextBody      string `gorm:"type:text"`
+	Status        Status `gorm:"type:varchar(20);index;default:pending"`
+	Attempts      int
+	NextAttemptAt time.Time  `gorm:"index"`
+	LockedUntil   *time.Time // Klaim sementara agar satu email tidak dikirim dua worker sekaligus
+	LastError     string     `gorm:"type:text"`
+	SentAt        *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (Email) TableName() string {
+	return "email_outbox"
+}
@@ -0,0 +1,103 @@
+package outbox
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(email Email) (Email, error)
+	FindByID(ID int) (Email, error)
+	FindDue(now time.Time, limit int) ([]Email, error)
+	FindByStatus(status Status, limit int) ([]Email, error)
+	Claim(ID int, now time.Time, until time.Time) (bool, error)
+	Update(email Email) (Email, error)
+	// Requeue mengembalikan email dead, atau pending yang sudah pernah gagal dan
+	// tidak sedang dikunci worker, ke antrean. false berarti email tidak memenuhi syarat.
+	Requeue(ID int, now time.Time) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository juga dipakai dengan *gorm.DB transaksi (tx) agar email
+// tersimpan atomik bersama perubahan data lain.
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) Create(email Email) (Email, error) {
+	if err := r.db.Create(&email).Error; err != nil {
+		return Email{}, err
+	}
+	return email, nil
+}
+
+func (r *repository) FindByID(ID int) (Email, error) {
+	var email Email
+	if err := r.db.First(&email, ID).Error; err != nil {
+		return Email{}, err
+	}
+	return email, nil
+}
+
+func (r *repository) FindDue(now time.Time, limit int) ([]Email, error) {
+	var emails []Email
+	err := r.db.Where("status = ? AND next_attempt_at <= ? AND (locked_until IS NULL OR locked_until < ?)", StatusPending, now, now).
+		Order("next_attempt_at asc").
+		Limit(limit).
+		Find(&emails).Error
+	if err != nil {
+		return nil, err
+	}
+	return emails, nil
+}
+
+func (r *repository) FindByStatus(status Status, limit int) ([]Email, error) {
+	var emails []Email
+	query := r.db.Order("id desc").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&emails).Error; err != nil {
+		return nil, err
+	}
+	return emails, nil
+}
+
+// Claim mengunci email sampai waktu until. Mengembalikan false jika email
+// sudah diklaim worker lain.
+func (r *repository) Claim(ID int, now time.Time, until time.Time) (bool, error) {
+	result := r.db.Model(&Email{}).
+		Where("id = ? AND status = ? AND (locked_until IS NULL OR locked_until < ?)", ID, StatusPending, now).
+		Update("locked_until", until)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *repository) Update(email Email) (Email, error) {
+	if err := r.db.Save(&email).Error; err != nil {
+		return Email{}, err
+	}
+	return email, nil
+}
+
+func (r *repository) Requeue(ID int, now time.Time) (bool, error) {
+	result := r.db.Model(&Email{}).
+		Where("id = ? AND (status = ? OR (status = ? AND attempts > 0 AND (locked_until IS NULL OR locked_until < ?)))", ID, StatusDead, StatusPending, now).
+		Updates(map[string]any{
+			"status":          StatusPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"locked_until":    nil,
+			"last_error":      "",
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,76 @@
+package outbox
+
+import (
+	"example/hello/internal/mail"
+	"fmt"
+	"time"
+)
+
+type Service interface {
+	List(status Status, limit int) ([]Email, error)
+	Retry(ID int) (Email, error)
+}
+
+type service struct {
+	repository Repository
+}
+
+func NewService(repository Repository) *service {
+	return &service{repository}
+}
+
+// Enqueue menyimpan pesan ke antrean, satu baris per penerima. Gunakan repository
+// yang dibuat dari transaksi agar email hanya tersimpan jika perubahan data berhasil.
+func Enqueue(repository Repository, msg mail.Message) error {
+	for _, recipient := range msg.To {
+		email := Email{
+			Recipient:     recipient,
+			Subject:       msg.Subject,
+			HTMLBody:      msg.HTMLBody,
+			TextBody:      msg.TextBody,
+			Status:        StatusPending,
+			NextAttemptAt: time.Now(),
+		}
+		if _, err := repository.Create(email); err != nil {
+			return fmt.Errorf("gagal menyimpan email ke outbox: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *service) List(status Status, limit int) ([]Email, error) {
+	return s.repository.FindByStatus(status, limit)
+}
+
+// Retry mengembalikan email yang gagal (dead, atau pending yang sudah pernah gagal)
+// ke antrean dengan hitungan percobaan dari nol. Email yang sedang dikirim worker
+// atau sudah terkirim tidak disentuh.
+func (s *service) Retry(ID int) (Email, error) {
+	email, err := s.repository.FindByID(ID)
+	if err != nil {
+		return Email{}, fmt.Errorf("email dengan ID %d tidak ditemukan: %w", ID, err)
+	}
+
+	if email.Status == StatusSent {
+		return Email{}, fmt.Errorf("email dengan ID %d sudah terkirim", ID)
+	}
+
+	requeued, err := s.repository.Requeue(ID, time.Now())
+	if err != nil {
+		return Email{}, fmt.Errorf("gagal mengembalikan email %d ke antrean: %w", ID, err)
+	}
+	if !requeued {
+		return Email{}, fmt.Errorf("email dengan ID %d belum gagal atau sedang dikirim", ID)
+	}
+
+	return s.repository.FindByID(ID)
+}
+
+func toMessage(email Email) mail.Message {
+	return mail.Message{
+		To:       []string{email.Recipient},
+		Subject:  email.Subject,
+		HTMLBody: email.HTMLBody,
+		TextBody: email.TextBody,
+	}
+}
@@ -0,0 +1,105 @@
+package outbox
+
+import (
+	"example/hello/internal/mail"
+	"log"
+	"time"
+)
+
+const (
+	// MaxAttempts adalah batas percobaan sebelum email ditandai dead.
+	MaxAttempts = 8
+
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+	lockTimeout = 2 * time.Minute
+	batchSize   = 20
+)
+
+// Worker mengirim email dari outbox secara berkala dengan exponential backoff.
+type Worker struct {
+	repository Repository
+	mailer     mail.Mailer
+	interval   time.Duration
+}
+
+func NewWorker(repository Repository, mailer mail.Mailer, interval time.Duration) *Worker {
+	return &Worker{
+		repository: repository,
+		mailer:     mailer,
+		interval:   interval,
+	}
+}
+
+// Run menjalankan worker dalam sebuah goroutine, mirip dengan Hub.Run.
+func (w *Worker) Run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.processDue()
+		<-ticker.C
+	}
+}
+
+func (w *Worker) processDue() {
+	now := time.Now()
+	emails, err := w.repository.FindDue(now, batchSize)
+	if err != nil {
+		log.Printf("Outbox: gagal mengambil email yang jatuh tempo: %v", err)
+		return
+	}
+
+	for _, email := range emails {
+		claimed, err := w.repository.Claim(email.ID, now, now.Add(lockTimeout))
+		if err != nil {
+			log.Printf("Outbox: gagal mengklaim email %d: %v", email.ID, err)
+			continue
+		}
+		if !claimed {
+			continue // Sedang dikirim worker lain
+		}
+		w.send(email)
+	}
+}
+
+func (w *Worker) send(email Email) {
+	email.LockedUntil = nil
+	email.Attempts++
+
+	if err := w.mailer.Send(toMessage(email)); err != nil {
+		email.LastError = err.Error()
+		if email.Attempts >= MaxAttempts {
+			email.Status = StatusDead
+			log.Printf("Outbox: email %d ke %s gagal %d kali, ditandai dead: %v", email.ID, email.Recipient, email.Attempts, err)
+		} else {
+			email.NextAttemptAt = time.Now().Add(backoff(email.Attempts))
+			log.Printf("Outbox: email %d ke %s gagal (percobaan %d), dicoba lagi %s: %v", email.ID, email.Recipient, email.Attempts, email.NextAttemptAt.Format(time.RFC3339), err)
+		}
+	} else {
+		now := time.Now()
+		email.Status = StatusSent
+		email.SentAt = &now
+		email.LastError = ""
+		// Isi email bisa memuat link token; tidak perlu disimpan setelah terkirim.
+		email.HTMLBody = ""
+		email.TextBody = ""
+		log.Printf("Outbox: email %d berhasil dikirim ke %s", email.ID, email.Recipient)
+	}
+
+	if _, err := w.repository.Update(email); err != nil {
+		log.Printf("Outbox: gagal memperbarui status email %d: %v", email.ID, err)
+	}
+}
+
+// backoff menghitung jeda sebelum percobaan berikutnya: 30s, 1m, 2m, 4m, ... maksimal 1 jam.
+func backoff(attempts int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
@@ -0,0 +1,137 @@
+// Package pagination berisi cursor, keyset dan offset yang dipakai bersama oleh
+// daftar yang bisa dipaging (katalog buku dan direktori user).
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxOffset membatasi paging dengan offset. Halaman yang lebih jauh harus
+// memakai cursor, karena offset besar memindai semua baris sebelumnya.
+const MaxOffset = 10000
+
+var (
+	ErrInvalidCursor = errors.New("cursor tidak valid")
+	ErrPageTooLarge  = fmt.Errorf("page melewati %d baris pertama, gunakan cursor", MaxOffset)
+)
+
+// Cursor menyimpan nilai kolom sort dan ID baris terakhir di halaman, sehingga
+// halaman berikutnya tetap benar walau ada baris baru di antaranya.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// Encode menyusun cursor untuk baris terakhir halaman. value adalah nilai kolom
+// sort baris tersebut: string, int, float64 atau time.Time.
+func Encode(sort string, value any, id int) string {
+	c := Cursor{Sort: sort, ID: id}
+	switch v := value.(type) {
+	case time.Time:
+		c.Value = v.UTC().Format(time.RFC3339Nano)
+	case int:
+		c.Value = strconv.Itoa(v)
+	case float64:
+		c.Value = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		c.Value = fmt.Sprint(v)
+	}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// Decode membaca cursor dan memastikan cursor dibuat untuk sort yang sama.
+func Decode(raw string, sort string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID < 1 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if c.Sort != sort {
+		return Cursor{}, fmt.Errorf("%w: cursor dibuat untuk sort %s", ErrInvalidCursor, c.Sort)
+	}
+	return c, nil
+}
+
+func (c Cursor) Time() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return t, nil
+}
+
+func (c Cursor) Int() (int, error) {
+	n, err := strconv.Atoi(c.Value)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	return n, nil
+}
+
+func (c Cursor) Float() (float64, error) {
+	n, err := strconv.ParseFloat(c.Value, 64)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	return n, nil
+}
+
+// Keyset adalah urutan menurut Column lalu id. Column disusun langsung ke SQL,
+// jadi harus berasal dari daftar kolom yang sudah divalidasi pemanggil.
+type Keyset struct {
+	Column     string
+	Descending bool
+}
+
+// After hanya mengambil baris setelah (value, id) menurut urutan keyset.
+func (k Keyset) After(value any, id int) func(*gorm.DB) *gorm.DB {
+	comparator := ">"
+	if k.Descending {
+		comparator = "<"
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(
+			fmt.Sprintf("(%[1]s %[2]s ?) OR (%[1]s = ? AND id %[2]s ?)", k.Column, comparator),
+			value, value, id,
+		)
+	}
+}
+
+// Order adalah klausa ORDER BY untuk keyset, dengan id sebagai pemisah nilai kembar.
+func (k Keyset) Order() string {
+	direction := "asc"
+	if k.Descending {
+		direction = "desc"
+	}
+	return fmt.Sprintf("%s %s, id %s", k.Column, direction, direction)
+}
+
+// Offset menghitung offset halaman page (mulai dari 1). Halaman yang melewati
+// MaxOffset ditolak, sehingga perkalian page dan limit juga tidak bisa overflow.
+func Offset(page, limit int) (int, error) {
+	if page <= 1 || limit < 1 {
+		return 0, nil
+	}
+	if page-1 > MaxOffset/limit {
+		return 0, ErrPageTooLarge
+	}
+	return (page - 1) * limit, nil
+}
+
+// EscapeLike meloloskan karakter wildcard LIKE agar input dicari apa adanya.
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
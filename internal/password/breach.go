@@ -0,0 +1,64 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BreachList memeriksa apakah password ada di daftar password yang pernah bocor.
+type BreachList interface {
+	IsBreached(password string) (bool, error)
+}
+
+// NopBreachList menganggap semua password aman, dipakai jika daftar tidak dikonfigurasi.
+type NopBreachList struct{}
+
+func (NopBreachList) IsBreached(string) (bool, error) { return false, nil }
+
+// fileBreachList membaca daftar hash SHA-1 lokal dengan format k-anonymity
+// Pwned Passwords: satu file per 5 karakter awal hash (misal "5BAA6.txt"),
+// berisi baris "SUFFIX:COUNT". Hanya file prefix yang relevan yang dibaca dan
+// tidak ada request ke jaringan.
+type fileBreachList struct {
+	dir string
+}
+
+// NewFileBreachList memakai direktori berisi file prefix, misalnya hasil
+// PwnedPasswordsDownloader. Prefix yang filenya tidak ada dianggap tidak bocor.
+func NewFileBreachList(dir string) *fileBreachList {
+	return &fileBreachList{dir: dir}
+}
+
+func (l *fileBreachList) IsBreached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	f, err := os.Open(filepath.Join(l.dir, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("gagal membaca daftar password bocor: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(line, suffix) {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("gagal membaca daftar password bocor: %w", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,138 @@
+package password
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// SHA-1 dari "password" adalah 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8.
+const (
+	passwordPrefix = "5BAA6"
+	passwordSuffix = "1E4C9B93F3F0682250B6CF8331B7EE68FD8"
+)
+
+func writeBreachFile(t *testing.T, dir, prefix, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, prefix+".txt"), []byte(content), 0o600); err != nil {
+		t.Fatalf("write breach file: %v", err)
+	}
+}
+
+func TestFileBreachList(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		password string
+		want     bool
+	}{
+		{"suffix huruf besar", "0018A45C4D1DEF81644B54AB7F969B88D65:1\n" + passwordSuffix + ":3730471\n", "password", true},
+		{"suffix huruf kecil", "1e4c9b93f3f0682250b6cf8331b7ee68fd8:10\n", "password", true},
+		{"baris CRLF", "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" + passwordSuffix + ":3\r\n", "password", true},
+		{"spasi di sekitar baris", "  " + passwordSuffix + ":3  \n", "password", true},
+		{"baris tanpa count", passwordSuffix + "\n", "password", true},
+		{"baris terakhir tanpa newline", "0018A45C4D1DEF81644B54AB7F969B88D65:1\n" + passwordSuffix + ":3", "password", true},
+		{"suffix tidak ada di file", "0018A45C4D1DEF81644B54AB7F969B88D65:1\n", "password", false},
+		{"suffix hanya awalan baris", passwordSuffix + "00:1\n", "password", false},
+		// Password tidak dinormalisasi: beda huruf besar berarti hash lain.
+		{"password beda huruf besar", passwordSuffix + ":3\n", "Password", false},
+		{"password dengan spasi", passwordSuffix + ":3\n", " password ", false},
+		{"file kosong", "", "password", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeBreachFile(t, dir, passwordPrefix, tt.content)
+
+			got, err := NewFileBreachList(dir).IsBreached(tt.password)
+			if err != nil {
+				t.Fatalf("IsBreached: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsBreached(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileBreachListMissingFile(t *testing.T) {
+	tests := []struct {
+		name string
+		dir  string
+	}{
+		{"prefix tanpa file", t.TempDir()},
+		{"direktori tidak ada", filepath.Join(t.TempDir(), "tidak-ada")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewFileBreachList(tt.dir).IsBreached("password")
+			if err != nil || got {
+				t.Errorf("IsBreached = %v, %v; want false, nil", got, err)
+			}
+		})
+	}
+}
+
+func TestFileBreachListUnreadableFile(t *testing.T) {
+	dir := t.TempDir()
+	// Direktori dengan nama file prefix tidak bisa dibaca sebagai daftar.
+	if err := os.Mkdir(filepath.Join(dir, passwordPrefix+".txt"), 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if _, err := NewFileBreachList(dir).IsBreached("password"); err == nil {
+		t.Error("IsBreached tanpa error untuk file yang tidak bisa dibaca")
+	}
+}
+
+type fakeBreachList struct {
+	breached bool
+	err      error
+}
+
+func (l fakeBreachList) IsBreached(string) (bool, error) { return l.breached, l.err }
+
+func TestCheckerCheck(t *testing.T) {
+	policy := Policy{MinLength: 8, MaxLength: MaxLength, RequireDigit: true}
+	readErr := errors.New("disk error")
+
+	tests := []struct {
+		name      string
+		breaches  BreachList
+		password  string
+		wantCodes []string
+		wantErr   error
+	}{
+		{"lolos", nil, "password1", nil, nil},
+		{"bocor", fakeBreachList{breached: true}, "password1", []string{CodeBreached}, nil},
+		{"bocor dan melanggar policy", fakeBreachList{breached: true}, "pass", []string{CodeTooShort, CodeMissingDigit, CodeBreached}, nil},
+		{"daftar tidak bisa dibaca", fakeBreachList{err: readErr}, "password1", nil, readErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewChecker(policy, tt.breaches).Check(tt.password, UserInfo{})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Check error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantCodes == nil {
+				if err != nil {
+					t.Fatalf("Check error = %v, want nil", err)
+				}
+				return
+			}
+			var policyErr *PolicyError
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("Check error = %v, want *PolicyError", err)
+			}
+			if got := violationCodes(policyErr.Violations); !reflect.DeepEqual(got, tt.wantCodes) {
+				t.Errorf("violations = %v, want %v", got, tt.wantCodes)
+			}
+		})
+	}
+}
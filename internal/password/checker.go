@@ -0,0 +1,38 @@
+package password
+
+// Checker menggabungkan Policy dan BreachList. Riwayat password diperiksa
+// terpisah oleh pemanggil karena butuh hash yang tersimpan di database.
+type Checker struct {
+	policy   Policy
+	breaches BreachList
+}
+
+func NewChecker(policy Policy, breaches BreachList) *Checker {
+	if breaches == nil {
+		breaches = NopBreachList{}
+	}
+	return &Checker{policy: policy, breaches: breaches}
+}
+
+func (c *Checker) Policy() Policy {
+	return c.policy
+}
+
+// Check mengembalikan *PolicyError jika password melanggar aturan. Error lain
+// berarti daftar password bocor tidak bisa dibaca.
+func (c *Checker) Check(password string, info UserInfo) error {
+	violations := c.policy.check(password, info)
+
+	breached, err := c.breaches.IsBreached(password)
+	if err != nil {
+		return err
+	}
+	if breached {
+		violations = append(violations, violation(CodeBreached, 0))
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package password
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MaxLength adalah batas bcrypt: byte setelah 72 diabaikan atau ditolak.
+const MaxLength = 72
+
+// Policy adalah aturan password baru, biasanya dibaca dari environment lewat PolicyFromEnv.
+type Policy struct {
+	MinLength     int  `json:"min_length"`
+	MaxLength     int  `json:"max_length"`
+	RequireLower  bool `json:"require_lowercase"`
+	RequireUpper  bool `json:"require_uppercase"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+	// HistorySize adalah jumlah password terakhir yang tidak boleh dipakai ulang.
+	HistorySize int `json:"history_size"`
+}
+
+// DefaultPolicy dipakai untuk nilai yang tidak di-set di environment.
+var DefaultPolicy = Policy{
+	MinLength:    8,
+	MaxLength:    MaxLength,
+	RequireLower: true,
+	RequireUpper: true,
+	RequireDigit: true,
+	HistorySize:  5,
+}
+
+// PolicyFromEnv membaca PASSWORD_MIN_LENGTH, PASSWORD_REQUIRE_LOWERCASE,
+// PASSWORD_REQUIRE_UPPERCASE, PASSWORD_REQUIRE_DIGIT, PASSWORD_REQUIRE_SYMBOL
+// dan PASSWORD_HISTORY_SIZE. Nilai yang kosong atau tidak valid memakai DefaultPolicy.
+func PolicyFromEnv() Policy {
+	policy := DefaultPolicy
+	if n, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); err == nil && n > 0 && n <= MaxLength {
+		policy.MinLength = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("PASSWORD_HISTORY_SIZE")); err == nil && n >= 0 {
+		policy.HistorySize = n
+	}
+	policy.RequireLower = envBool("PASSWORD_REQUIRE_LOWERCASE", policy.RequireLower)
+	policy.RequireUpper = envBool("PASSWORD_REQUIRE_UPPERCASE", policy.RequireUpper)
+	policy.RequireDigit = envBool("PASSWORD_REQUIRE_DIGIT", policy.RequireDigit)
+	policy.RequireSymbol = envBool("PASSWORD_REQUIRE_SYMBOL", policy.RequireSymbol)
+	return policy
+}
+
+func envBool(key string, fallback bool) bool {
+	if v, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return v
+	}
+	return fallback
+}
+
+// UserInfo adalah data pribadi yang tidak boleh muncul di dalam password.
+type UserInfo struct {
+	Emails []string
+	Name   string
+}
+
+// minPersonalPartLength mencegah bagian yang terlalu pendek (misal nama "Al")
+// menolak terlalu banyak password.
+const minPersonalPartLength = 3
+
+// check mengembalikan semua aturan yang dilanggar, bukan hanya yang pertama,
+// agar client bisa menampilkan semuanya sekaligus.
+func (p Policy) check(password string, info UserInfo) []Violation {
+	var violations []Violation
+
+	length := len([]rune(password))
+	if length < p.MinLength {
+		violations = append(violations, violation(CodeTooShort, p.MinLength))
+	}
+	if len(password) > p.MaxLength {
+		violations = append(violations, violation(CodeTooLong, p.MaxLength))
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireLower && !hasLower {
+		violations = append(violations, violation(CodeMissingLower, 0))
+	}
+	if p.RequireUpper && !hasUpper {
+		violations = append(violations, violation(CodeMissingUpper, 0))
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, violation(CodeMissingDigit, 0))
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, violation(CodeMissingSymbol, 0))
+	}
+
+	lowered := strings.ToLower(password)
+	for _, email := range info.Emails {
+		local, _, _ := strings.Cut(strings.ToLower(email), "@")
+		if len(local) >= minPersonalPartLength && strings.Contains(lowered, local) {
+			violations = append(violations, violation(CodeContainsEmail, 0))
+			break
+		}
+	}
+	for _, part := range strings.Fields(strings.ToLower(info.Name)) {
+		if len([]rune(part)) >= minPersonalPartLength && strings.Contains(lowered, part) {
+			violations = append(violations, violation(CodeContainsName, 0))
+			break
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,161 @@
+package password
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func violationCodes(violations []Violation) []string {
+	codes := []string{}
+	for _, v := range violations {
+		codes = append(codes, v.Code)
+	}
+	return codes
+}
+
+func TestPolicyCheck(t *testing.T) {
+	strict := Policy{
+		MinLength:     8,
+		MaxLength:     MaxLength,
+		RequireLower:  true,
+		RequireUpper:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+	}
+	lengthOnly := Policy{MinLength: 8, MaxLength: MaxLength}
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		password string
+		info     UserInfo
+		want     []string
+	}{
+		{"memenuhi semua aturan", strict, "Rahasia1!", UserInfo{}, []string{}},
+		{"tepat panjang minimum", lengthOnly, "abcdefgh", UserInfo{}, []string{}},
+		{"kurang satu karakter", lengthOnly, "abcdefg", UserInfo{}, []string{CodeTooShort}},
+		{"password kosong", strict, "", UserInfo{}, []string{CodeTooShort, CodeMissingLower, CodeMissingUpper, CodeMissingDigit, CodeMissingSymbol}},
+		{"tepat 72 byte", lengthOnly, strings.Repeat("a", MaxLength), UserInfo{}, []string{}},
+		{"73 byte", lengthOnly, strings.Repeat("a", MaxLength+1), UserInfo{}, []string{CodeTooLong}},
+		// Panjang minimum dihitung per karakter, batas maksimum per byte (bcrypt).
+		{"unicode dihitung per karakter", lengthOnly, "пароль12", UserInfo{}, []string{}},
+		{"unicode pendek walau bytenya banyak", lengthOnly, "日本語パス", UserInfo{}, []string{CodeTooShort}},
+		{"unicode melewati batas byte", lengthOnly, strings.Repeat("é", 37), UserInfo{}, []string{CodeTooLong}},
+		{"huruf besar dan kecil non-latin", strict, "Ärger-über1", UserInfo{}, []string{}},
+		{"huruf tanpa kapitalisasi bukan huruf kecil", strict, "日本語パス1A!", UserInfo{}, []string{CodeMissingLower}},
+		{"angka non-latin dihitung angka", strict, "Rahasia٣!", UserInfo{}, []string{}},
+		{"emoji dihitung simbol", strict, "Rahasia1🔒", UserInfo{}, []string{}},
+		{"spasi dihitung simbol", strict, "Rahasia 1", UserInfo{}, []string{}},
+		{"tanpa huruf besar", strict, "rahasia1!", UserInfo{}, []string{CodeMissingUpper}},
+		{"tanpa huruf kecil", strict, "RAHASIA1!", UserInfo{}, []string{CodeMissingLower}},
+		{"tanpa angka", strict, "Rahasia!!", UserInfo{}, []string{CodeMissingDigit}},
+		{"tanpa simbol", strict, "Rahasia12", UserInfo{}, []string{CodeMissingSymbol}},
+		{"kelas karakter tidak diwajibkan", lengthOnly, "12345678", UserInfo{}, []string{}},
+		{
+			"mengandung bagian lokal email tanpa melihat huruf besar",
+			lengthOnly, "xBudi.Santoso99",
+			UserInfo{Emails: []string{"budi.santoso@example.com"}},
+			[]string{CodeContainsEmail},
+		},
+		{
+			"email lama juga diperiksa",
+			lengthOnly, "lamaku2024",
+			UserInfo{Emails: []string{"baru@example.com", "lamaku@example.com"}},
+			[]string{CodeContainsEmail},
+		},
+		{
+			"bagian lokal email terlalu pendek diabaikan",
+			lengthOnly, "abracadabra",
+			UserInfo{Emails: []string{"ab@example.com"}},
+			[]string{},
+		},
+		{
+			"mengandung salah satu bagian nama",
+			lengthOnly, "sayaSANTOSO1",
+			UserInfo{Name: "Budi Santoso"},
+			[]string{CodeContainsName},
+		},
+		{
+			"bagian nama terlalu pendek diabaikan",
+			lengthOnly, "alhamdulillah",
+			UserInfo{Name: "Al Bu"},
+			[]string{},
+		},
+		{
+			"nama unicode",
+			lengthOnly, "sandiзоя2024",
+			UserInfo{Name: "Зоя"},
+			[]string{CodeContainsName},
+		},
+		{
+			"email dan nama dilaporkan sekaligus",
+			lengthOnly, "budisantoso",
+			UserInfo{Emails: []string{"budi@example.com"}, Name: "Santoso"},
+			[]string{CodeContainsEmail, CodeContainsName},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violationCodes(tt.policy.check(tt.password, tt.info))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("check(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestViolationMessageIncludesLimit(t *testing.T) {
+	violations := Policy{MinLength: 10, MaxLength: MaxLength}.check("pendek", UserInfo{})
+	if len(violations) != 1 || violations[0].Message != "password minimal 10 karakter" {
+		t.Errorf("violations = %+v", violations)
+	}
+}
+
+func TestPolicyFromEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Policy
+	}{
+		{"tanpa environment", nil, DefaultPolicy},
+		{
+			"semua di-set",
+			map[string]string{
+				"PASSWORD_MIN_LENGTH":        "12",
+				"PASSWORD_HISTORY_SIZE":      "0",
+				"PASSWORD_REQUIRE_LOWERCASE": "false",
+				"PASSWORD_REQUIRE_UPPERCASE": "0",
+				"PASSWORD_REQUIRE_DIGIT":     "true",
+				"PASSWORD_REQUIRE_SYMBOL":    "1",
+			},
+			Policy{MinLength: 12, MaxLength: MaxLength, RequireDigit: true, RequireSymbol: true},
+		},
+		{
+			"nilai tidak valid memakai default",
+			map[string]string{
+				"PASSWORD_MIN_LENGTH":     "100",
+				"PASSWORD_HISTORY_SIZE":   "-1",
+				"PASSWORD_REQUIRE_SYMBOL": "ya",
+			},
+			DefaultPolicy,
+		},
+		{"panjang minimum nol ditolak", map[string]string{"PASSWORD_MIN_LENGTH": "0"}, DefaultPolicy},
+	}
+
+	keys := []string{
+		"PASSWORD_MIN_LENGTH", "PASSWORD_HISTORY_SIZE", "PASSWORD_REQUIRE_LOWERCASE",
+		"PASSWORD_REQUIRE_UPPERCASE", "PASSWORD_REQUIRE_DIGIT", "PASSWORD_REQUIRE_SYMBOL",
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range keys {
+				t.Setenv(key, tt.env[key])
+			}
+			if got := PolicyFromEnv(); got != tt.want {
+				t.Errorf("PolicyFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kode alasan penolakan password yang dikirim ke client.
+const (
+	CodeTooShort      = "too_short"
+	CodeTooLong       = "too_long"
+	CodeMissingLower  = "missing_lowercase"
+	CodeMissingUpper  = "missing_uppercase"
+	CodeMissingDigit  = "missing_digit"
+	CodeMissingSymbol = "missing_symbol"
+	CodeContainsEmail = "contains_email"
+	CodeContainsName  = "contains_name"
+	CodeBreached      = "breached"
+	CodeReused        = "reused"
+)
+
+var violationMessages = map[string]string{
+	CodeTooShort:      "password minimal %d karakter",
+	CodeTooLong:       "password maksimal %d byte",
+	CodeMissingLower:  "password harus mengandung huruf kecil",
+	CodeMissingUpper:  "password harus mengandung huruf besar",
+	CodeMissingDigit:  "password harus mengandung angka",
+	CodeMissingSymbol: "password harus mengandung simbol",
+	CodeContainsEmail: "password tidak boleh mengandung alamat email",
+	CodeContainsName:  "password tidak boleh mengandung nama",
+	CodeBreached:      "password ini pernah bocor di kebocoran data lain, pilih password lain",
+	CodeReused:        "password tidak boleh sama dengan %d password terakhir",
+}
+
+// Violation adalah satu aturan yang dilanggar. Code stabil untuk dipakai client,
+// Message untuk ditampilkan langsung.
+type Violation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func violation(code string, limit int) Violation {
+	msg := violationMessages[code]
+	if strings.Contains(msg, "%d") {
+		msg = fmt.Sprintf(msg, limit)
+	}
+	return Violation{Code: code, Message: msg}
+}
+
+// ReusedViolation dipakai pemanggil yang memeriksa riwayat password sendiri.
+func ReusedViolation(historySize int) Violation {
+	return violation(CodeReused, historySize)
+}
+
+// PolicyError dikembalikan saat password baru ditolak, berisi semua alasannya.
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Message)
+	}
+	return strings.Join(messages, "; ")
+}
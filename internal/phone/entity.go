@@ -0,0 +1,32 @@
+package phone
+
+import (
+	"fmt"
+	"time"
+)
+
+// OTP adalah kode verifikasi yang dikirim lewat SMS. Seperti token lain,
+// hanya hash-nya yang disimpan.
+type OTP struct {
+	ID         int
+	UserID     int    `gorm:"index"`
+	Phone      string `gorm:"type:varchar(16);index"` // Nomor E.164 tujuan kode
+	CodeHash   string `gorm:"type:varchar(64)"`
+	Attempts   int    // Jumlah percobaan verifikasi yang salah
+	ExpiresAt  time.Time
+	ConsumedAt *time.Time // Diisi saat kode berhasil dipakai atau diganti kode baru
+	CreatedAt  time.Time
+}
+
+func (OTP) TableName() string {
+	return "phone_otps"
+}
+
+// RateLimitError dikembalikan saat pengiriman atau verifikasi kode terlalu sering.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("terlalu banyak permintaan kode, coba lagi dalam %d detik", int(e.RetryAfter.Seconds())+1)
+}
@@ -0,0 +1,64 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+// DefaultCountryCode dipakai untuk nomor lokal tanpa kode negara (misal "0812...").
+const DefaultCountryCode = "62"
+
+var ErrInvalidNumber = errors.New("nomor telepon tidak valid")
+
+// Normalize mengubah nomor ke format E.164 (misal "+6281234567890").
+// Spasi, tanda hubung, titik dan kurung diabaikan. Nomor lokal Indonesia
+// ("0812...", "812...", "62812..." atau "0062812...") diberi kode +62;
+// nomor dengan awalan "+" atau "00" dianggap sudah memakai kode negara.
+func Normalize(raw string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(raw))
+
+	international := false
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+		international = true
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+		international = true
+	}
+
+	if digits == "" || strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return "", ErrInvalidNumber
+	}
+
+	if !international {
+		switch {
+		case strings.HasPrefix(digits, "0"):
+			digits = DefaultCountryCode + digits[1:]
+		case strings.HasPrefix(digits, DefaultCountryCode):
+			// Sudah diawali 62 tanpa "+"
+		default:
+			digits = DefaultCountryCode + digits
+		}
+	}
+
+	// E.164: kode negara tidak diawali 0 dan total maksimal 15 digit.
+	if strings.HasPrefix(digits, "0") || len(digits) < 8 || len(digits) > 15 {
+		return "", ErrInvalidNumber
+	}
+
+	// Nomor Indonesia: nomor nasional 8-12 digit dan tidak diawali 0 (misal "+620812...").
+	if national, ok := strings.CutPrefix(digits, DefaultCountryCode); ok {
+		if strings.HasPrefix(national, "0") || len(national) < 8 || len(national) > 12 {
+			return "", ErrInvalidNumber
+		}
+	}
+
+	return "+" + digits, nil
+}
@@ -0,0 +1,94 @@
+package phone
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(otp OTP) (OTP, error)
+	Delete(ID int) error
+	// FindLatest mengambil kode terakhir yang dikirim ke user untuk nomor tersebut.
+	FindLatest(userID int, phone string) (OTP, error)
+	CountSentByUserSince(userID int, since time.Time) (int64, error)
+	CountSentToPhoneSince(phone string, since time.Time) (int64, error)
+	// FindOldestSentByUserSince dipakai untuk menghitung kapan kuota kirim terbuka lagi.
+	FindOldestSentByUserSince(userID int, since time.Time) (OTP, error)
+	// IncrementAttempts menambah percobaan hanya selama masih di bawah max dan
+	// kode belum terpakai. false berarti jatah percobaan sudah habis.
+	IncrementAttempts(ID int, max int) (bool, error)
+	// Consume menandai kode terpakai hanya jika belum terpakai, sehingga dua
+	// request bersamaan tidak bisa memakai kode yang sama.
+	Consume(ID int, at time.Time) (bool, error)
+	ConsumeAllForUser(userID int, at time.Time) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) Create(otp OTP) (OTP, error) {
+	if err := r.db.Create(&otp).Error; err != nil {
+		return OTP{}, err
+	}
+	return otp, nil
+}
+
+func (r *repository) Delete(ID int) error {
+	return r.db.Delete(&OTP{}, ID).Error
+}
+
+func (r *repository) FindLatest(userID int, phone string) (OTP, error) {
+	var otp OTP
+	if err := r.db.Where("user_id = ? AND phone = ?", userID, phone).Order("id desc").First(&otp).Error; err != nil {
+		return OTP{}, err
+	}
+	return otp, nil
+}
+
+func (r *repository) CountSentByUserSince(userID int, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&OTP{}).Where("user_id = ? AND created_at >= ?", userID, since).Count(&count).Error
+	return count, err
+}
+
+func (r *repository) CountSentToPhoneSince(phone string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&OTP{}).Where("phone = ? AND created_at >= ?", phone, since).Count(&count).Error
+	return count, err
+}
+
+func (r *repository) FindOldestSentByUserSince(userID int, since time.Time) (OTP, error) {
+	var otp OTP
+	if err := r.db.Where("user_id = ? AND created_at >= ?", userID, since).Order("id asc").First(&otp).Error; err != nil {
+		return OTP{}, err
+	}
+	return otp, nil
+}
+
+func (r *repository) IncrementAttempts(ID int, max int) (bool, error) {
+	result := r.db.Model(&OTP{}).
+		Where("id = ? AND attempts < ? AND consumed_at IS NULL", ID, max).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *repository) Consume(ID int, at time.Time) (bool, error) {
+	result := r.db.Model(&OTP{}).Where("id = ? AND consumed_at IS NULL", ID).Update("consumed_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *repository) ConsumeAllForUser(userID int, at time.Time) error {
+	return r.db.Model(&OTP{}).Where("user_id = ? AND consumed_at IS NULL", userID).Update("consumed_at", at).Error
+}
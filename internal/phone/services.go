@@ -0,0 +1,193 @@
+package phone
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// CodeTTL adalah masa berlaku satu kode OTP.
+	CodeTTL = 5 * time.Minute
+	// resendCooldown adalah jeda minimal sebelum kode baru boleh diminta.
+	resendCooldown = time.Minute
+	// Dalam satu sendWindow, satu user maupun satu nomor hanya boleh menerima
+	// maxSendsPerWindow kode agar SMS tidak bisa dipakai untuk spam.
+	sendWindow        = time.Hour
+	maxSendsPerWindow = 5
+	// maxAttempts adalah jumlah tebakan salah sebelum kode hangus.
+	maxAttempts = 5
+	codeDigits  = 6
+)
+
+var (
+	ErrNoCode          = errors.New("minta kode verifikasi terlebih dahulu")
+	ErrInvalidCode     = errors.New("kode verifikasi salah")
+	ErrCodeExpired     = errors.New("kode verifikasi sudah kadaluarsa, minta kode baru")
+	ErrTooManyAttempts = errors.New("terlalu banyak percobaan salah, minta kode baru")
+)
+
+type Service interface {
+	// SendCode mengirim kode OTP baru ke nomor E.164 dan mengembalikan waktu kadaluarsanya.
+	// Kode sebelumnya untuk user yang sama langsung tidak berlaku.
+	SendCode(userID int, number string, language string) (time.Time, error)
+	// VerifyCode memeriksa kode terakhir yang dikirim ke nomor tersebut.
+	VerifyCode(userID int, number string, code string) error
+}
+
+type service struct {
+	repository Repository
+	sender     SMSSender
+}
+
+func NewService(repository Repository, sender SMSSender) *service {
+	return &service{repository: repository, sender: sender}
+}
+
+func (s *service) SendCode(userID int, number string, language string) (time.Time, error) {
+	now := time.Now()
+	if err := s.checkSendLimit(userID, number, now); err != nil {
+		return time.Time{}, err
+	}
+
+	code, err := generateCode()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("gagal membuat kode verifikasi: %w", err)
+	}
+
+	if err := s.repository.ConsumeAllForUser(userID, now); err != nil {
+		return time.Time{}, fmt.Errorf("gagal membatalkan kode lama: %w", err)
+	}
+	otp, err := s.repository.Create(OTP{
+		UserID:    userID,
+		Phone:     number,
+		CodeHash:  hashCode(number, code),
+		ExpiresAt: now.Add(CodeTTL),
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("gagal menyimpan kode verifikasi: %w", err)
+	}
+
+	if err := s.sender.Send(SMS{To: number, Body: codeMessage(code, language)}); err != nil {
+		// Kode yang tidak terkirim tidak dihitung ke batas pengiriman.
+		if delErr := s.repository.Delete(otp.ID); delErr != nil {
+			return time.Time{}, fmt.Errorf("gagal mengirim SMS: %w (dan gagal menghapus kode: %v)", err, delErr)
+		}
+		return time.Time{}, fmt.Errorf("gagal mengirim SMS: %w", err)
+	}
+
+	return otp.ExpiresAt, nil
+}
+
+func (s *service) checkSendLimit(userID int, number string, now time.Time) error {
+	latest, err := s.repository.FindLatest(userID, number)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("gagal mengambil kode terakhir: %w", err)
+	}
+	if err == nil {
+		if elapsed := now.Sub(latest.CreatedAt); elapsed < resendCooldown {
+			return &RateLimitError{RetryAfter: resendCooldown - elapsed}
+		}
+	}
+
+	since := now.Add(-sendWindow)
+	sentByUser, err := s.repository.CountSentByUserSince(userID, since)
+	if err != nil {
+		return fmt.Errorf("gagal menghitung kode terkirim: %w", err)
+	}
+	if sentByUser >= maxSendsPerWindow {
+		retryAfter := sendWindow
+		if oldest, err := s.repository.FindOldestSentByUserSince(userID, since); err == nil {
+			retryAfter = oldest.CreatedAt.Add(sendWindow).Sub(now)
+		}
+		return &RateLimitError{RetryAfter: retryAfter}
+	}
+
+	sentToPhone, err := s.repository.CountSentToPhoneSince(number, since)
+	if err != nil {
+		return fmt.Errorf("gagal menghitung kode terkirim: %w", err)
+	}
+	if sentToPhone >= maxSendsPerWindow {
+		return &RateLimitError{RetryAfter: sendWindow}
+	}
+	return nil
+}
+
+func (s *service) VerifyCode(userID int, number string, code string) error {
+	otp, err := s.repository.FindLatest(userID, number)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNoCode
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mengambil kode verifikasi: %w", err)
+	}
+	if otp.ConsumedAt != nil {
+		return ErrNoCode
+	}
+	if otp.ExpiresAt.Before(time.Now()) {
+		return ErrCodeExpired
+	}
+	if otp.Attempts >= maxAttempts {
+		return ErrTooManyAttempts
+	}
+
+	// Percobaan dicatat sebelum kode dibandingkan agar request paralel tidak
+	// bisa menebak melebihi maxAttempts.
+	counted, err := s.repository.IncrementAttempts(otp.ID, maxAttempts)
+	if err != nil {
+		return fmt.Errorf("gagal mencatat percobaan verifikasi: %w", err)
+	}
+	if !counted {
+		return ErrTooManyAttempts
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashCode(number, code)), []byte(otp.CodeHash)) != 1 {
+		if otp.Attempts+1 >= maxAttempts {
+			return ErrTooManyAttempts
+		}
+		return ErrInvalidCode
+	}
+
+	consumed, err := s.repository.Consume(otp.ID, time.Now())
+	if err != nil {
+		return fmt.Errorf("gagal memproses kode verifikasi: %w", err)
+	}
+	if !consumed {
+		return ErrNoCode
+	}
+	return nil
+}
+
+func codeMessage(code string, language string) string {
+	minutes := int(CodeTTL.Minutes())
+	if language == "en" {
+		return fmt.Sprintf("Your verification code is %s. It expires in %d minutes. Do not share this code with anyone.", code, minutes)
+	}
+	return fmt.Sprintf("Kode verifikasi Anda: %s. Berlaku %d menit. Jangan berikan kode ini kepada siapa pun.", code, minutes)
+}
+
+// generateCode membuat kode angka acak sepanjang codeDigits, termasuk nol di depan.
+func generateCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < codeDigits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", codeDigits, n.Int64()), nil
+}
+
+// hashCode mengikat kode ke nomor tujuan agar kode untuk nomor lain tidak bisa dipakai.
+func hashCode(number, code string) string {
+	sum := sha256.Sum256([]byte(number + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
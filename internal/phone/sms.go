@@ -0,0 +1,66 @@
+package phone
+
+import (
+	"log"
+	"sync"
+)
+
+// SMS adalah satu pesan singkat yang siap dikirim ke nomor E.164.
+type SMS struct {
+	To   string
+	Body string
+}
+
+// SMSSender adalah abstraksi pengiriman SMS. Implementasi yang tersedia:
+// log (pengembangan lokal) dan in-memory (testing). Provider SMS sungguhan
+// cukup mengimplementasikan interface ini.
+type SMSSender interface {
+	Send(msg SMS) error
+}
+
+type logSender struct{}
+
+// NewLogSender menulis SMS ke log aplikasi, termasuk kode OTP-nya.
+// Jangan dipakai di produksi.
+func NewLogSender() *logSender {
+	return &logSender{}
+}
+
+func (logSender) Send(msg SMS) error {
+	log.Printf("[sms] To: %s\n%s", msg.To, msg.Body)
+	return nil
+}
+
+// MemorySender menyimpan semua SMS di memori. Berguna untuk testing alur
+// verifikasi nomor telepon tanpa provider SMS.
+type MemorySender struct {
+	mu   sync.Mutex
+	sent []SMS
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (m *MemorySender) Send(msg SMS) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// Sent mengembalikan salinan semua SMS yang sudah "dikirim".
+func (m *MemorySender) Sent() []SMS {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]SMS, len(m.sent))
+	copy(out, m.sent)
+	return out
+}
+
+// Reset menghapus semua SMS yang tersimpan.
+func (m *MemorySender) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = nil
+}
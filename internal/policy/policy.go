@@ -0,0 +1,28 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrForbidden dikembalikan service saat actor mencoba mengubah resource milik orang lain.
+// Handler memetakan error ini ke HTTP 403.
+var ErrForbidden = errors.New("forbidden")
+
+// Actor adalah user yang sedang melakukan aksi, diambil dari klaim JWT oleh handler
+// lalu diteruskan ke service.
+type Actor struct {
+	UserID  int
+	IsAdmin bool
+	// IP dan UserAgent asal request, dicatat di audit log.
+	IP        string
+	UserAgent string
+}
+
+// CanModify memastikan actor adalah pemilik resource atau admin.
+func CanModify(actor Actor, ownerID int, resource string) error {
+	if actor.IsAdmin || actor.UserID == ownerID {
+		return nil
+	}
+	return fmt.Errorf("%w: you do not own this %s", ErrForbidden, resource)
+}
@@ -0,0 +1,14 @@
+package route
+
+import (
+	"example/hello/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AccountRoutes(r *gin.Engine, accountHandler *handler.AccountHandler, authMiddleware gin.HandlerFunc) {
+	accountGroup := r.Group("/v1/user/me")
+	accountGroup.Use(authMiddleware)
+
+	accountGroup.GET("/export", accountHandler.ExportMyData)
+}
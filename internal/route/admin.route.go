@@ -0,0 +1,20 @@
+package route
+
+import (
+	"example/hello/internal/handler"
+	"example/hello/internal/middleware"
+	"example/hello/internal/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AdminRoutes(r *gin.Engine, outboxHandler *handler.OutboxHandler, auditHandler *handler.AuditHandler, authMiddleware gin.HandlerFunc) {
+	// Semua rute admin membutuhkan login dan role admin
+	adminGroup := r.Group("/v1/admin")
+	adminGroup.Use(authMiddleware, middleware.RequireRole(user.RoleAdmin))
+
+	adminGroup.GET("/emails", outboxHandler.ListEmails)
+	adminGroup.POST("/emails/:id/retry", outboxHandler.RetryEmail)
+	adminGroup.GET("/audit-events", auditHandler.ListEvents)
+	adminGroup.GET("/audit-events/export", auditHandler.ExportEvents)
+}
@@ -0,0 +1,17 @@
+package route
+
+import (
+	"example/hello/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyRoutes sengaja tanpa RequireScope: API key tidak bisa membuat atau mencabut API key.
+func APIKeyRoutes(r *gin.Engine, apiKeyHandler *handler.APIKeyHandler, authMiddleware gin.HandlerFunc) {
+	tokenGroup := r.Group("/v1/user/me/tokens")
+	tokenGroup.Use(authMiddleware)
+
+	tokenGroup.GET("", apiKeyHandler.ListAPIKeys)
+	tokenGroup.POST("", apiKeyHandler.CreateAPIKey)
+	tokenGroup.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+}
@@ -6,9 +6,15 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AuthRoutes(r *gin.Engine, authHandler *handler.AuthHandler) {
+func AuthRoutes(r *gin.Engine, authHandler *handler.AuthHandler, authMiddleware gin.HandlerFunc) {
 	authGroup := r.Group("/v1/auth")
 
-	authGroup.GET("/google/login", authHandler.GoogleLogin)
-	authGroup.GET("/google/callback", authHandler.GoogleCallback)
+	authGroup.GET("/providers", authHandler.ListProviders)
+	authGroup.GET("/:provider/login", authHandler.ProviderLogin)
+	authGroup.GET("/:provider/callback", authHandler.ProviderCallback)
+	authGroup.POST("/exchange", authHandler.ExchangeCode)
+	authGroup.POST("/refresh", authHandler.RefreshToken)
+	authGroup.POST("/logout", authMiddleware, authHandler.Logout)
+
+	r.GET("/.well-known/jwks.json", authHandler.JWKS)
 }
@@ -1,19 +1,59 @@
 package route
 
 import (
+	"example/hello/internal/apikey"
 	"example/hello/internal/handler"
+	"example/hello/internal/middleware"
+	"example/hello/internal/user"
 
 	"github.com/gin-gonic/gin"
 )
 
-func BookRoutes(r *gin.Engine, bookHandler *handler.BookHandler) {
+func BookRoutes(r *gin.Engine, bookHandler *handler.BookHandler, authMiddleware gin.HandlerFunc, verifiedMiddleware gin.HandlerFunc) {
 	// Create a new group for book routes
 	bookGroup := r.Group("/v1")
 
 	// Define a simple GET endpoint
 	bookGroup.GET("/get-books", bookHandler.GetBooks)
 	bookGroup.GET("/get-book/:id", bookHandler.GetBookById)
-	bookGroup.PUT("/book/:id", bookHandler.UpdateBook)
-	bookGroup.DELETE("/book/:id", bookHandler.DeleteBook)
-	bookGroup.POST("/book", bookHandler.CreateBook)
+
+	// Hanya moderator dan admin yang boleh mengubah katalog buku
+	editor := bookGroup.Group("/book")
+	editor.Use(middleware.RequireScope(apikey.ScopeBooksWrite), authMiddleware, middleware.RequireRole(user.RoleModerator, user.RoleAdmin))
+
+	editor.PUT("/:id", bookHandler.UpdateBook)
+	editor.DELETE("/:id", bookHandler.DeleteBook)
+	editor.PUT("/:id/cover", bookHandler.UploadCover)
+	editor.DELETE("/:id/cover", bookHandler.DeleteCover)
+	editor.POST("", bookHandler.CreateBook)
+	editor.POST("/import", bookHandler.ImportBooks)
+	editor.GET("/export", bookHandler.ExportBooks)
+
+	// Penulis, genre dan penerbit bisa dibaca publik, diubah oleh editor yang sama
+	bookGroup.GET("/authors", bookHandler.GetAuthors)
+	bookGroup.GET("/authors/:id", bookHandler.GetAuthor)
+	bookGroup.GET("/genres", bookHandler.GetGenres)
+	bookGroup.GET("/genres/:id", bookHandler.GetGenre)
+	bookGroup.GET("/publishers", bookHandler.GetPublishers)
+	bookGroup.GET("/publishers/:id", bookHandler.GetPublisher)
+
+	editor.POST("/authors", bookHandler.CreateAuthor)
+	editor.PUT("/authors/:id", bookHandler.UpdateAuthor)
+	editor.DELETE("/authors/:id", bookHandler.DeleteAuthor)
+	editor.POST("/genres", bookHandler.CreateGenre)
+	editor.PUT("/genres/:id", bookHandler.UpdateGenre)
+	editor.DELETE("/genres/:id", bookHandler.DeleteGenre)
+	editor.POST("/publishers", bookHandler.CreatePublisher)
+	editor.PUT("/publishers/:id", bookHandler.UpdatePublisher)
+	editor.DELETE("/publishers/:id", bookHandler.DeletePublisher)
+	editor.DELETE("/reviews/:id", bookHandler.ModerateReview)
+
+	// Ulasan: siapa pun bisa membaca, user terverifikasi mengelola ulasannya sendiri
+	bookGroup.GET("/books/:id/reviews", bookHandler.GetReviews)
+	reviews := bookGroup.Group("/books/:id/reviews")
+	reviews.Use(authMiddleware, verifiedMiddleware)
+
+	reviews.POST("", bookHandler.CreateReview)
+	reviews.PUT("/me", bookHandler.UpdateMyReview)
+	reviews.DELETE("/me", bookHandler.DeleteMyReview)
 }
@@ -1,20 +1,29 @@
 package route
 
 import (
+	"example/hello/internal/apikey"
 	"example/hello/internal/handler"
+	"example/hello/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
-func MatchRoutes(r *gin.Engine, matchHandler *handler.MatchHandler) {
+func MatchRoutes(r *gin.Engine, matchHandler *handler.MatchHandler, authMiddleware gin.HandlerFunc, verifiedMiddleware gin.HandlerFunc) {
 
-	matchGroup := r.Group("/v1/match")
+	// Scope API key dibedakan antara membaca dan mengubah profile
+	readGroup := r.Group("/v1/match")
+	readGroup.Use(middleware.RequireScope(apikey.ScopeMatchesRead), authMiddleware)
 
 	// Define a simple GET endpoint
-	matchGroup.GET("/:city", matchHandler.GetMatchByCity)
-	matchGroup.POST("/", matchHandler.CreateMatch)
-	matchGroup.PUT("/:id", matchHandler.UpdateMatchUrl)
-	matchGroup.DELETE("/:id", matchHandler.DeleteMatchUrl)
-	matchGroup.GET("/all", matchHandler.GetAllMatchUrls)
-	matchGroup.GET("/find/:id", matchHandler.GetMatchUrlByID)
+	readGroup.GET("/:city", matchHandler.GetMatchByCity)
+	readGroup.GET("/all", matchHandler.GetAllMatchUrls)
+	readGroup.GET("/find/:id", matchHandler.GetMatchUrlByID)
+
+	writeGroup := r.Group("/v1/match")
+	writeGroup.Use(middleware.RequireScope(apikey.ScopeMatchesWrite), authMiddleware)
+
+	// Hanya user dengan email terverifikasi yang boleh membuat profile
+	writeGroup.POST("/", verifiedMiddleware, matchHandler.CreateMatch)
+	writeGroup.PUT("/:id", matchHandler.UpdateMatchUrl)
+	writeGroup.DELETE("/:id", matchHandler.DeleteMatchUrl)
 }
@@ -8,17 +8,28 @@ import (
 
 func SetupRoutes(
 	r *gin.Engine,
+	authMiddleware gin.HandlerFunc,
+	verifiedMiddleware gin.HandlerFunc,
 	authHandler *handler.AuthHandler,
 	userHandler *handler.UserHandler,
 	bookHandler *handler.BookHandler,
 	shortHandler *handler.ShortUrlHandler,
 	webSocketHandler *handler.WebSocketHandler,
 	matchHandler *handler.MatchHandler,
+	outboxHandler *handler.OutboxHandler,
+	auditHandler *handler.AuditHandler,
+	accountHandler *handler.AccountHandler,
+	sessionHandler *handler.SessionHandler,
+	apiKeyHandler *handler.APIKeyHandler,
 ) {
-	AuthRoutes(r, authHandler)
-	UserRoutes(r, userHandler)
-	BookRoutes(r, bookHandler)
-	ShortRoutes(r, shortHandler)
-	WebSocketRoutes(r, webSocketHandler)
-	MatchRoutes(r, matchHandler)
+	AuthRoutes(r, authHandler, authMiddleware)
+	UserRoutes(r, userHandler, authMiddleware)
+	BookRoutes(r, bookHandler, authMiddleware, verifiedMiddleware)
+	ShortRoutes(r, shortHandler, authMiddleware, verifiedMiddleware)
+	WebSocketRoutes(r, webSocketHandler, authMiddleware, verifiedMiddleware)
+	MatchRoutes(r, matchHandler, authMiddleware, verifiedMiddleware)
+	AdminRoutes(r, outboxHandler, auditHandler, authMiddleware)
+	AccountRoutes(r, accountHandler, authMiddleware)
+	SessionRoutes(r, sessionHandler, authMiddleware)
+	APIKeyRoutes(r, apiKeyHandler, authMiddleware)
 }
@@ -0,0 +1,16 @@
+package route
+
+import (
+	"example/hello/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SessionRoutes(r *gin.Engine, sessionHandler *handler.SessionHandler, authMiddleware gin.HandlerFunc) {
+	sessionGroup := r.Group("/v1/user/me/sessions")
+	sessionGroup.Use(authMiddleware)
+
+	sessionGroup.GET("", sessionHandler.ListSessions)
+	sessionGroup.DELETE("", sessionHandler.RevokeAllSessions)
+	sessionGroup.DELETE("/:id", sessionHandler.RevokeSession)
+}
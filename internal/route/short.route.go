@@ -1,20 +1,33 @@
 package route
 
 import (
+	"example/hello/internal/apikey"
 	"example/hello/internal/handler"
+	"example/hello/internal/middleware"
+	"example/hello/internal/user"
 
 	"github.com/gin-gonic/gin"
 )
 
-func ShortRoutes(r *gin.Engine, shortHandler *handler.ShortUrlHandler) {
+func ShortRoutes(r *gin.Engine, shortHandler *handler.ShortUrlHandler, authMiddleware gin.HandlerFunc, verifiedMiddleware gin.HandlerFunc) {
 
 	shortGroup := r.Group("/v1")
 
 	// Define a simple GET endpoint
 	shortGroup.GET("/:url", shortHandler.GetShortUrl)
-	shortGroup.POST("/shorten", shortHandler.CreateShortUrl)
-	shortGroup.PUT("/:id", shortHandler.UpdateShortUrl)
-	shortGroup.DELETE("/:id", shortHandler.DeleteShortUrl)
-	shortGroup.GET("/all", shortHandler.GetAllShortUrls)
-	shortGroup.GET("/find/:id", shortHandler.GetShortUrlByID)
+
+	// Membuat short link butuh email terverifikasi, sedangkan mengelola semua link khusus moderator/admin
+	shortGroup.POST("/shorten", middleware.RequireScope(apikey.ScopeLinksWrite), authMiddleware, verifiedMiddleware, shortHandler.CreateShortUrl)
+
+	moderation := shortGroup.Group("")
+	moderation.Use(middleware.RequireScope(apikey.ScopeLinksWrite), authMiddleware, middleware.RequireRole(user.RoleModerator, user.RoleAdmin))
+
+	moderation.PUT("/:id", shortHandler.UpdateShortUrl)
+	moderation.DELETE("/:id", shortHandler.DeleteShortUrl)
+
+	moderationRead := shortGroup.Group("")
+	moderationRead.Use(middleware.RequireScope(apikey.ScopeLinksRead), authMiddleware, middleware.RequireRole(user.RoleModerator, user.RoleAdmin))
+
+	moderationRead.GET("/all", shortHandler.GetAllShortUrls)
+	moderationRead.GET("/find/:id", shortHandler.GetShortUrlByID)
 }
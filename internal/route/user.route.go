@@ -3,28 +3,45 @@ package route
 import (
 	"example/hello/internal/handler"
 	"example/hello/internal/middleware"
+	"example/hello/internal/user"
 
 	"github.com/gin-gonic/gin"
 )
 
-func UserRoutes(r *gin.Engine, userHandler *handler.UserHandler) {
+func UserRoutes(r *gin.Engine, userHandler *handler.UserHandler, authMiddleware gin.HandlerFunc) {
 	// Create a new group for user routes
 	userGroup := r.Group("/v1")
 
 	userGroup.POST("/register", userHandler.RegisterUser)
 	userGroup.POST("/login", userHandler.LoginUser)
+	userGroup.POST("/login/mfa", userHandler.VerifyMFALogin)
 	userGroup.GET("/verify-email", userHandler.VerifyEmail)
+	userGroup.GET("/unlock-account", userHandler.UnlockAccount)
+	userGroup.GET("/confirm-email-change", userHandler.ConfirmEmailChange)
+	userGroup.GET("/revert-email-change", userHandler.RevertEmailChange)
 	userGroup.POST("/resend-verification", userHandler.ResendVerificationEmail)
 	userGroup.POST("/forgot-password", userHandler.ForgotPassword)
 	userGroup.POST("/reset-password", userHandler.ResetPassword)
+	userGroup.GET("/password-policy", userHandler.GetPasswordPolicy)
 
 	// Rute Terlindungi (membutuhkan Bearer Token JWT)
 	protected := r.Group("/v1/user")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(authMiddleware)
 
-	protected.GET("/", userHandler.GetUsers)
+	protected.GET("/", middleware.RequireRole(user.RoleModerator, user.RoleAdmin), userHandler.GetUsers)
 	protected.GET("/me", userHandler.MyAccount)
+	protected.DELETE("/me", userHandler.DeleteMyAccount)
+	protected.POST("/me/mfa/totp", userHandler.BeginTOTPEnrollment)
+	protected.POST("/me/mfa/totp/confirm", userHandler.ConfirmTOTPEnrollment)
+	protected.POST("/me/mfa/totp/disable", userHandler.DisableTOTP)
+	protected.POST("/me/mfa/recovery-codes", userHandler.RegenerateRecoveryCodes)
+	protected.POST("/me/phone/verification", userHandler.SendPhoneVerification)
+	protected.POST("/me/phone/verify", userHandler.VerifyPhone)
+	protected.GET("/me/identities", userHandler.ListIdentities)
+	protected.POST("/me/identities", userHandler.LinkIdentity)
+	protected.DELETE("/me/identities/:provider", userHandler.UnlinkIdentity)
 	protected.GET("/:id", userHandler.GetUserById)
 	protected.PUT("/:id", userHandler.UpdateUser)
 	protected.DELETE("/:id", userHandler.DeleteUser)
+	protected.PUT("/:id/role", middleware.RequireRole(user.RoleAdmin), userHandler.UpdateUserRole)
 }
@@ -2,15 +2,14 @@ package route
 
 import (
 	"example/hello/internal/handler"
-	"example/hello/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
-func WebSocketRoutes(r *gin.Engine, webSocketHandler *handler.WebSocketHandler) {
-	// Rute Terlindungi (membutuhkan Bearer Token JWT)
+func WebSocketRoutes(r *gin.Engine, webSocketHandler *handler.WebSocketHandler, authMiddleware gin.HandlerFunc, verifiedMiddleware gin.HandlerFunc) {
+	// Rute Terlindungi (membutuhkan Bearer Token JWT dan email terverifikasi untuk chat)
 	protected := r.Group("/v1")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(authMiddleware, verifiedMiddleware)
 
 	protected.GET("/ws", webSocketHandler.ServeWs)
 }
@@ -0,0 +1,26 @@
+// Package securetoken membuat token acak yang dikirim ke user (link email,
+// refresh token, API key, state OAuth) dan hash-nya untuk disimpan di database.
+package securetoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// Generate membuat token acak 32 byte yang aman untuk URL.
+func Generate() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash menghasilkan SHA-256 (hex) dari token. Hanya hash yang disimpan, jadi
+// kebocoran database tidak membocorkan token yang masih berlaku.
+func Hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
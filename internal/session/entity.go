@@ -0,0 +1,51 @@
+package session
+
+import "time"
+
+// RefreshToken menyimpan refresh token dalam bentuk hash (SHA-256).
+// Token mentah hanya dikirim sekali ke client dan tidak pernah disimpan.
+// Setiap rotasi menghasilkan token baru dengan FamilyID yang sama, sehingga
+// pemakaian ulang token lama bisa mencabut seluruh keluarga token.
+type RefreshToken struct {
+	ID            int
+	UserID        int    `gorm:"index"`
+	FamilyID      string `gorm:"type:varchar(36);index"`
+	TokenHash     string `gorm:"type:varchar(64);uniqueIndex"`
+	AccessTokenID string `gorm:"type:varchar(36)"` // jti access token yang diterbitkan bersama token ini
+	ExpiresAt     time.Time
+	UsedAt        *time.Time // Diisi saat token sudah ditukar (rotasi)
+	RevokedAt     *time.Time // Diisi saat token dicabut (logout atau reuse)
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Session adalah satu login di satu perangkat. ID-nya sama dengan FamilyID
+// refresh token, sehingga mencabut sesi berarti mencabut keluarga token-nya.
+type Session struct {
+	ID         string `gorm:"type:varchar(36);primaryKey"`
+	UserID     int    `gorm:"index"`
+	Device     string `gorm:"type:varchar(100)"` // Ringkasan user agent, misal "Chrome di Windows"
+	UserAgent  string `gorm:"type:varchar(255)"`
+	IP         string `gorm:"type:varchar(45)"` // IP terakhir yang memakai sesi ini
+	LastSeenAt time.Time
+	ExpiresAt  time.Time  // Diperpanjang setiap refresh token dirotasi
+	RevokedAt  *time.Time // Diisi saat logout, dicabut dari daftar sesi atau reuse refresh token
+	CreatedAt  time.Time
+}
+
+// RevokedToken adalah daftar jti access token yang sudah dicabut.
+// Baris bisa dihapus setelah ExpiresAt karena token-nya sudah tidak valid.
+type RevokedToken struct {
+	ID        int
+	TokenID   string `gorm:"type:varchar(36);uniqueIndex"`
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// Pair adalah pasangan access token dan refresh token yang dikirim ke client.
+type Pair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+	FamilyID     string
+}
@@ -0,0 +1,151 @@
+package session
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	CreateRefreshToken(token RefreshToken) (RefreshToken, error)
+	FindRefreshTokenByHash(hash string) (RefreshToken, error)
+	// MarkRefreshTokenUsed mengisi used_at hanya jika token belum dipakai dan
+	// belum dicabut. false berarti token sudah lebih dulu dipakai (reuse).
+	MarkRefreshTokenUsed(ID int, usedAt time.Time) (bool, error)
+	FindByFamily(familyID string) ([]RefreshToken, error)
+	FindActiveFamiliesByUser(userID int, now time.Time) ([]string, error)
+	RevokeFamily(familyID string, revokedAt time.Time) error
+	RevokeTokenID(revoked RevokedToken) error
+	IsTokenIDRevoked(tokenID string) (bool, error)
+	DeleteExpiredRevokedTokens(before time.Time) error
+	CreateSession(session Session) (Session, error)
+	UpdateSession(session Session) (Session, error)
+	FindSession(ID string) (Session, error)
+	FindActiveSessionsByUser(userID int, now time.Time) ([]Session, error)
+	TouchSession(ID string, ip string, at time.Time) error
+	RevokeSession(ID string, revokedAt time.Time) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) CreateRefreshToken(token RefreshToken) (RefreshToken, error) {
+	if err := r.db.Create(&token).Error; err != nil {
+		return RefreshToken{}, err
+	}
+	return token, nil
+}
+
+func (r *repository) FindRefreshTokenByHash(hash string) (RefreshToken, error) {
+	var token RefreshToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return RefreshToken{}, err
+	}
+	return token, nil
+}
+
+func (r *repository) MarkRefreshTokenUsed(ID int, usedAt time.Time) (bool, error) {
+	result := r.db.Model(&RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", ID).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *repository) FindByFamily(familyID string) ([]RefreshToken, error) {
+	var tokens []RefreshToken
+	if err := r.db.Where("family_id = ?", familyID).Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *repository) FindActiveFamiliesByUser(userID int, now time.Time) ([]string, error) {
+	var families []string
+	err := r.db.Model(&RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Distinct().
+		Pluck("family_id", &families).Error
+	if err != nil {
+		return nil, err
+	}
+	return families, nil
+}
+
+func (r *repository) RevokeFamily(familyID string, revokedAt time.Time) error {
+	return r.db.Model(&RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", revokedAt).Error
+}
+
+func (r *repository) RevokeTokenID(revoked RevokedToken) error {
+	// Abaikan jika jti sudah ada di daftar (logout dua kali, dsb); unique index
+	// token_id menjaga logout yang bersamaan tetap satu baris.
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&revoked).Error
+}
+
+func (r *repository) IsTokenIDRevoked(tokenID string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&RevokedToken{}).Where("token_id = ?", tokenID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *repository) DeleteExpiredRevokedTokens(before time.Time) error {
+	return r.db.Where("expires_at < ?", before).Delete(&RevokedToken{}).Error
+}
+
+func (r *repository) CreateSession(session Session) (Session, error) {
+	if err := r.db.Create(&session).Error; err != nil {
+		return Session{}, err
+	}
+	return session, nil
+}
+
+func (r *repository) UpdateSession(session Session) (Session, error) {
+	if err := r.db.Save(&session).Error; err != nil {
+		return Session{}, err
+	}
+	return session, nil
+}
+
+func (r *repository) FindSession(ID string) (Session, error) {
+	var session Session
+	if err := r.db.Where("id = ?", ID).First(&session).Error; err != nil {
+		return Session{}, err
+	}
+	return session, nil
+}
+
+func (r *repository) FindActiveSessionsByUser(userID int, now time.Time) ([]Session, error) {
+	var sessions []Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at desc").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// TouchSession hanya memperbarui waktu dan IP terakhir, kolom lain tidak disentuh.
+func (r *repository) TouchSession(ID string, ip string, at time.Time) error {
+	return r.db.Model(&Session{}).
+		Where("id = ?", ID).
+		Updates(map[string]any{"last_seen_at": at, "ip": ip}).Error
+}
+
+func (r *repository) RevokeSession(ID string, revokedAt time.Time) error {
+	return r.db.Model(&Session{}).
+		Where("id = ? AND revoked_at IS NULL", ID).
+		Update("revoked_at", revokedAt).Error
+}
@@ -0,0 +1,373 @@
+package session
+
+import (
+	"errors"
+	"example/hello/internal/audit"
+	"example/hello/internal/auth"
+	"example/hello/internal/policy"
+	"example/hello/internal/securetoken"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/patrickmn/go-cache"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenTTL adalah masa berlaku satu refresh token.
+// Setiap rotasi menerbitkan token baru dengan masa berlaku penuh.
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	ErrInvalidRefreshToken = errors.New("refresh token tidak valid")
+	ErrRefreshTokenExpired = errors.New("refresh token sudah kadaluarsa")
+	ErrRefreshTokenReused  = errors.New("refresh token sudah pernah digunakan, semua sesi terkait dicabut")
+	ErrSessionNotFound     = errors.New("sesi tidak ditemukan")
+)
+
+// touchInterval membatasi seberapa sering LastSeenAt ditulis ke database.
+const touchInterval = time.Minute
+
+type Service interface {
+	Issue(userID int, verified bool, role string, familyID string, origin audit.Origin) (Pair, error)
+	Rotate(rawRefreshToken string) (RefreshToken, error)
+	RevokeAccessToken(tokenID string, expiresAt time.Time) error
+	RevokeByRefreshToken(rawRefreshToken string) error
+	RevokeAllForUser(userID int) error
+	IsRevoked(tokenID string) (bool, error)
+	// IsSessionActive dipanggil AuthMiddleware di setiap request dan sekaligus
+	// memperbarui waktu terakhir sesi dipakai.
+	IsSessionActive(sessionID string, ip string) (bool, error)
+	ListSessions(userID int) ([]Session, error)
+	RevokeSession(actor policy.Actor, sessionID string) error
+	// RevokeOtherSessions mencabut semua sesi user kecuali keepSessionID (boleh kosong).
+	RevokeOtherSessions(actor policy.Actor, keepSessionID string) (int, error)
+}
+
+type service struct {
+	repository Repository
+	audit      audit.Recorder
+	cache      *cache.Cache
+}
+
+const (
+	revokedCacheKeyPrefix = "revoked_jti_"
+	sessionCacheKeyPrefix = "session_active_"
+)
+
+func NewService(repository Repository, auditRecorder audit.Recorder) *service {
+	// Cache status pencabutan jti agar middleware tidak query database di setiap request.
+	// Status "tidak dicabut" hanya di-cache sebentar supaya logout dari instance lain cepat berlaku.
+	c := cache.New(30*time.Second, 10*time.Minute)
+	return &service{
+		repository: repository,
+		audit:      auditRecorder,
+		cache:      c,
+	}
+}
+
+// Issue menerbitkan access token dan refresh token baru.
+// Jika familyID kosong, keluarga token dan sesi baru dibuat (login baru);
+// jika tidak, sesi yang sama diperpanjang (rotasi refresh token).
+func (s *service) Issue(userID int, verified bool, role string, familyID string, origin audit.Origin) (Pair, error) {
+	if familyID == "" {
+		familyID = uuid.New().String()
+	}
+	if err := s.saveSession(userID, familyID, origin); err != nil {
+		return Pair{}, err
+	}
+
+	accessToken, claims, err := auth.GenerateToken(fmt.Sprintf("%d", userID), verified, role, familyID)
+	if err != nil {
+		return Pair{}, err
+	}
+
+	rawRefreshToken, err := securetoken.Generate()
+	if err != nil {
+		return Pair{}, fmt.Errorf("gagal membuat refresh token: %w", err)
+	}
+
+	refreshToken := RefreshToken{
+		UserID:        userID,
+		FamilyID:      familyID,
+		TokenHash:     securetoken.Hash(rawRefreshToken),
+		AccessTokenID: claims.ID,
+		ExpiresAt:     time.Now().Add(RefreshTokenTTL),
+	}
+	if _, err := s.repository.CreateRefreshToken(refreshToken); err != nil {
+		return Pair{}, fmt.Errorf("gagal menyimpan refresh token: %w", err)
+	}
+
+	return Pair{
+		AccessToken:  accessToken,
+		RefreshToken: rawRefreshToken,
+		ExpiresAt:    claims.ExpiresAt.Time,
+		FamilyID:     familyID,
+	}, nil
+}
+
+// Rotate menandai refresh token sebagai sudah dipakai dan mengembalikan datanya,
+// sehingga pemanggil bisa menerbitkan pasangan token baru dalam keluarga yang sama.
+// Token yang sudah dipakai atau dicabut dianggap dicuri: seluruh keluarga dicabut.
+func (s *service) Rotate(rawRefreshToken string) (RefreshToken, error) {
+	token, err := s.repository.FindRefreshTokenByHash(securetoken.Hash(rawRefreshToken))
+	if err != nil {
+		return RefreshToken{}, ErrInvalidRefreshToken
+	}
+
+	if token.UsedAt != nil || token.RevokedAt != nil {
+		return RefreshToken{}, s.handleReuse(token)
+	}
+
+	if token.ExpiresAt.Before(time.Now()) {
+		return RefreshToken{}, ErrRefreshTokenExpired
+	}
+
+	// Update bersyarat: dari dua refresh bersamaan dengan token yang sama hanya
+	// satu yang berhasil, yang lain diperlakukan sebagai reuse.
+	now := time.Now()
+	marked, err := s.repository.MarkRefreshTokenUsed(token.ID, now)
+	if err != nil {
+		return RefreshToken{}, fmt.Errorf("gagal memperbarui refresh token: %w", err)
+	}
+	if !marked {
+		return RefreshToken{}, s.handleReuse(token)
+	}
+
+	token.UsedAt = &now
+	return token, nil
+}
+
+// handleReuse mencatat pemakaian ulang refresh token dan mencabut seluruh keluarganya.
+func (s *service) handleReuse(token RefreshToken) error {
+	log.Printf("Refresh token reuse terdeteksi untuk user %d, family %s dicabut", token.UserID, token.FamilyID)
+	s.audit.Record(audit.Entry{
+		Action:     audit.ActionRefreshTokenReuse,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(token.UserID),
+		Metadata:   map[string]any{"family_id": token.FamilyID},
+	})
+	if err := s.revokeFamily(token.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// RevokeAccessToken memasukkan jti ke daftar cabut sampai token tersebut kadaluarsa.
+func (s *service) RevokeAccessToken(tokenID string, expiresAt time.Time) error {
+	if err := s.repository.RevokeTokenID(RevokedToken{TokenID: tokenID, ExpiresAt: expiresAt}); err != nil {
+		return fmt.Errorf("gagal mencabut access token: %w", err)
+	}
+	s.cache.Set(revokedCacheKeyPrefix+tokenID, true, time.Until(expiresAt))
+	return nil
+}
+
+// RevokeByRefreshToken mencabut seluruh keluarga dari refresh token yang diberikan.
+func (s *service) RevokeByRefreshToken(rawRefreshToken string) error {
+	token, err := s.repository.FindRefreshTokenByHash(securetoken.Hash(rawRefreshToken))
+	if err != nil {
+		return ErrInvalidRefreshToken
+	}
+	return s.revokeFamily(token.FamilyID)
+}
+
+// RevokeAllForUser mencabut semua sesi aktif milik user, misalnya setelah reset password.
+func (s *service) RevokeAllForUser(userID int) error {
+	families, err := s.repository.FindActiveFamiliesByUser(userID, time.Now())
+	if err != nil {
+		return fmt.Errorf("gagal mengambil sesi user: %w", err)
+	}
+	for _, familyID := range families {
+		if err := s.revokeFamily(familyID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) IsRevoked(tokenID string) (bool, error) {
+	cacheKey := revokedCacheKeyPrefix + tokenID
+	if x, found := s.cache.Get(cacheKey); found {
+		return x.(bool), nil
+	}
+
+	revoked, err := s.repository.IsTokenIDRevoked(tokenID)
+	if err != nil {
+		return false, err
+	}
+
+	s.cache.Set(cacheKey, revoked, cache.DefaultExpiration)
+	return revoked, nil
+}
+
+// saveSession membuat sesi baru atau memperpanjang sesi yang sudah ada.
+// Keluarga token dari sebelum tabel sessions ada dibuatkan sesi saat refresh pertama.
+func (s *service) saveSession(userID int, sessionID string, origin audit.Origin) error {
+	now := time.Now()
+	existing, err := s.repository.FindSession(sessionID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("gagal mengambil sesi: %w", err)
+	}
+
+	if err == nil {
+		existing.IP = origin.IP
+		existing.LastSeenAt = now
+		existing.ExpiresAt = now.Add(RefreshTokenTTL)
+		if _, err := s.repository.UpdateSession(existing); err != nil {
+			return fmt.Errorf("gagal memperbarui sesi: %w", err)
+		}
+		return nil
+	}
+
+	userAgent := origin.UserAgent
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	if _, err := s.repository.CreateSession(Session{
+		ID:         sessionID,
+		UserID:     userID,
+		Device:     describeDevice(origin.UserAgent),
+		UserAgent:  userAgent,
+		IP:         origin.IP,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(RefreshTokenTTL),
+	}); err != nil {
+		return fmt.Errorf("gagal menyimpan sesi: %w", err)
+	}
+	return nil
+}
+
+// IsSessionActive memeriksa status sesi. Seperti IsRevoked, status "aktif" hanya
+// di-cache sebentar; setiap cache habis, LastSeenAt dan IP diperbarui jika sudah
+// lebih dari touchInterval. Sesi yang dicabut di instance ini langsung ditolak.
+func (s *service) IsSessionActive(sessionID string, ip string) (bool, error) {
+	cacheKey := sessionCacheKeyPrefix + sessionID
+	if x, found := s.cache.Get(cacheKey); found {
+		return x.(bool), nil
+	}
+
+	session, err := s.repository.FindSession(sessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s.cache.Set(cacheKey, false, auth.AccessTokenTTL)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	active := session.RevokedAt == nil && session.ExpiresAt.After(now)
+	if !active {
+		s.cache.Set(cacheKey, false, auth.AccessTokenTTL)
+		return false, nil
+	}
+
+	if now.Sub(session.LastSeenAt) >= touchInterval || session.IP != ip {
+		if err := s.repository.TouchSession(sessionID, ip, now); err != nil {
+			log.Printf("Gagal memperbarui waktu terakhir sesi %s: %v", sessionID, err)
+		}
+	}
+	s.cache.Set(cacheKey, true, cache.DefaultExpiration)
+	return true, nil
+}
+
+func (s *service) ListSessions(userID int) ([]Session, error) {
+	sessions, err := s.repository.FindActiveSessionsByUser(userID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil sesi user: %w", err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession mencabut satu sesi milik actor. Sesi milik user lain dianggap
+// tidak ada agar ID sesi orang lain tidak bisa ditebak.
+func (s *service) RevokeSession(actor policy.Actor, sessionID string) error {
+	session, err := s.repository.FindSession(sessionID)
+	if err != nil || session.UserID != actor.UserID || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+	if err := s.revokeFamily(sessionID); err != nil {
+		return err
+	}
+	s.recordSessionEvent(audit.ActionSessionRevoked, actor, map[string]any{"session_id": sessionID, "device": session.Device})
+	return nil
+}
+
+func (s *service) RevokeOtherSessions(actor policy.Actor, keepSessionID string) (int, error) {
+	families, err := s.repository.FindActiveFamiliesByUser(actor.UserID, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("gagal mengambil sesi user: %w", err)
+	}
+	sessions, err := s.repository.FindActiveSessionsByUser(actor.UserID, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("gagal mengambil sesi user: %w", err)
+	}
+	// Keluarga token dari sebelum tabel sessions ada tidak punya baris sesi, jadi keduanya digabung.
+	for _, session := range sessions {
+		families = append(families, session.ID)
+	}
+
+	revoked := map[string]bool{}
+	for _, familyID := range families {
+		if familyID == keepSessionID || revoked[familyID] {
+			continue
+		}
+		if err := s.revokeFamily(familyID); err != nil {
+			return len(revoked), err
+		}
+		revoked[familyID] = true
+	}
+
+	s.recordSessionEvent(audit.ActionAllSessionsRevoked, actor, map[string]any{"revoked": len(revoked), "kept_current": keepSessionID != ""})
+	return len(revoked), nil
+}
+
+func (s *service) recordSessionEvent(action audit.Action, actor policy.Actor, metadata map[string]any) {
+	s.audit.Record(audit.Entry{
+		ActorID:    actor.UserID,
+		Action:     action,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(actor.UserID),
+		Origin:     audit.ActorOrigin(actor),
+		Metadata:   metadata,
+	})
+}
+
+// revokeFamily mencabut semua refresh token dalam satu keluarga beserta
+// access token yang diterbitkan bersamanya, lalu menandai sesinya dicabut.
+func (s *service) revokeFamily(familyID string) error {
+	tokens, err := s.repository.FindByFamily(familyID)
+	if err != nil {
+		return fmt.Errorf("gagal mengambil keluarga token: %w", err)
+	}
+
+	now := time.Now()
+	if err := s.repository.RevokeFamily(familyID, now); err != nil {
+		return fmt.Errorf("gagal mencabut keluarga token: %w", err)
+	}
+	if err := s.repository.RevokeSession(familyID, now); err != nil {
+		return fmt.Errorf("gagal mencabut sesi: %w", err)
+	}
+	s.cache.Set(sessionCacheKeyPrefix+familyID, false, auth.AccessTokenTTL)
+
+	for _, token := range tokens {
+		if token.AccessTokenID == "" {
+			continue
+		}
+		// Access token berlaku paling lama AccessTokenTTL sejak diterbitkan.
+		expiresAt := token.CreatedAt.Add(auth.AccessTokenTTL)
+		if expiresAt.Before(now) {
+			continue
+		}
+		if err := s.RevokeAccessToken(token.AccessTokenID, expiresAt); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repository.DeleteExpiredRevokedTokens(now); err != nil {
+		log.Printf("Gagal membersihkan jti kadaluarsa: %v", err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import "time"
 
 type Short struct {
 	ID        int
+	UserID    *int `gorm:"index"` // Pembuat link; NULL untuk link lama sebelum kolom ini ada
 	Original  string
 	Shortened string
 	CreatedAt time.Time
@@ -3,4 +3,5 @@ package short
 type ShortRequest struct {
 	Original  string `json:"original" binding:"required"`
 	Shortened string `json:"shortened" binding:"required"`
+	UserID    int    `json:"-"` // Diisi handler dari user yang sedang login
 }
@@ -43,7 +43,7 @@ func (s *service) Create(shortRequest ShortRequest) (Short, error) {
 
 	// 1. Coba ambil dari cache
 	if x, found := s.cache.Get(cacheKey); found {
-		log.Printf("Service: Cache HIT for %d", shortRequest.Shortened)
+		log.Printf("Service: Cache HIT for %s", shortRequest.Shortened)
 		// Ingat untuk membersihkan password hash jika Anda menyimpannya dalam cache
 		userFromCache := x.(Short)
 		return userFromCache, fmt.Errorf("shortened URL already exists")
@@ -59,6 +59,9 @@ func (s *service) Create(shortRequest ShortRequest) (Short, error) {
 		Shortened: shortRequest.Shortened,
 		Original:  shortRequest.Original,
 	}
+	if shortRequest.UserID != 0 {
+		data.UserID = &shortRequest.UserID
+	}
 
 	created, err := s.repository.Create(data)
 	if err != nil {
@@ -0,0 +1,30 @@
+package user
+
+import (
+	"example/hello/internal/audit"
+	"example/hello/internal/policy"
+	"strconv"
+)
+
+// recordEvent mencatat aksi terhadap akun targetUserID ke audit log.
+// actorID 0 berarti pelaku belum terautentikasi; targetUserID 0 berarti akunnya
+// tidak ditemukan (misalnya login dengan email yang tidak terdaftar).
+func (s *service) recordEvent(action audit.Action, actorID, targetUserID int, origin audit.Origin, metadata map[string]any) {
+	entry := audit.Entry{
+		ActorID:  actorID,
+		Action:   action,
+		Origin:   origin,
+		Metadata: metadata,
+	}
+	if targetUserID != 0 {
+		entry.TargetType = "user"
+		entry.TargetID = strconv.Itoa(targetUserID)
+	}
+	s.audit.Record(entry)
+}
+
+// originActor membuat policy.Actor tanpa user untuk request yang belum login
+// (misal link reset password), agar audit tetap mencatat asal request.
+func originActor(origin audit.Origin) policy.Actor {
+	return policy.Actor{IP: origin.IP, UserAgent: origin.UserAgent}
+}
@@ -0,0 +1,123 @@
+package user
+
+import (
+	"errors"
+	"example/hello/internal/audit"
+	"example/hello/internal/outbox"
+	"example/hello/internal/policy"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DeletionGracePeriod adalah masa tunggu sebelum akun yang dihapus benar-benar
+// dihapus permanen oleh purge job. Jika pemilik akun sendiri yang menghapus,
+// login dalam masa ini membatalkan penghapusan.
+const DeletionGracePeriod = 30 * 24 * time.Hour
+
+// ErrDeletionScheduledByAdmin menolak login ke akun yang penghapusannya
+// dijadwalkan admin; penghapusan seperti ini tidak bisa dibatalkan dengan login.
+var ErrDeletionScheduledByAdmin = errors.New("akun ini dijadwalkan untuk dihapus oleh admin")
+
+// Delete menjadwalkan penghapusan akun. Semua sesi dicabut dan user mendapat email
+// berisi tanggal penghapusan permanen; data baru dihapus setelah DeletionGracePeriod.
+func (s *service) Delete(actor policy.Actor, ID int) (User, error) {
+	if err := policy.CanModify(actor, ID, "account"); err != nil {
+		return User{}, err
+	}
+
+	user, err := s.repository.FindByID(ID)
+	if err != nil {
+		return User{}, fmt.Errorf("error finding user for deletion: %w", err)
+	}
+	if user.DeletionScheduledAt != nil {
+		// Sudah dijadwalkan, jadwal awal tidak diperpanjang. Jika admin ikut
+		// menghapus akun yang dijadwalkan pemiliknya, login tidak lagi membatalkannya.
+		if actor.UserID != ID && user.deletionSelfScheduled() {
+			user.DeletionScheduledBy = &actor.UserID
+			if user, err = s.repository.Update(user); err != nil {
+				return User{}, fmt.Errorf("error scheduling user deletion: %w", err)
+			}
+			s.invalidateUserCache(ID)
+		}
+		user.Password = ""
+		return user, nil
+	}
+
+	purgeAt := time.Now().Add(DeletionGracePeriod)
+	user.DeletionScheduledAt = &purgeAt
+	user.DeletionScheduledBy = &actor.UserID
+
+	msg, err := s.renderEmail(user, "account_deletion", map[string]any{
+		"Name":      user.Name,
+		"PurgeDate": purgeAt.Format("2006-01-02"),
+		"GraceDays": int(DeletionGracePeriod.Hours() / 24),
+		"Link":      s.appURL,
+	})
+	if err != nil {
+		return User{}, fmt.Errorf("gagal menyusun email penghapusan akun: %w", err)
+	}
+
+	var updatedUser User
+	err = s.repository.Transaction(func(txRepo Repository, txOutbox outbox.Repository) error {
+		updated, err := txRepo.Update(user)
+		if err != nil {
+			return err
+		}
+		updatedUser = updated
+		return outbox.Enqueue(txOutbox, msg)
+	})
+	if err != nil {
+		return User{}, fmt.Errorf("error scheduling user deletion: %w", err)
+	}
+	s.invalidateUserCache(ID)
+
+	if err := s.sessionService.RevokeAllForUser(ID); err != nil {
+		return User{}, fmt.Errorf("penghapusan dijadwalkan, tetapi gagal mencabut sesi: %w", err)
+	}
+
+	s.recordEvent(audit.ActionUserDeletionScheduled, actor.UserID, ID, audit.ActorOrigin(actor), map[string]any{"purge_at": purgeAt})
+
+	updatedUser.Password = ""
+	return updatedUser, nil
+}
+
+// deletionSelfScheduled bernilai true jika penghapusan dijadwalkan pemilik akun.
+// Jadwal lama tanpa DeletionScheduledBy dianggap dari pemiliknya.
+func (u User) deletionSelfScheduled() bool {
+	return u.DeletionScheduledBy == nil || *u.DeletionScheduledBy == u.ID
+}
+
+// checkScheduledDeletion menolak login jika penghapusan akun dijadwalkan admin.
+// Dipanggil sebelum tantangan 2FA diterbitkan.
+func checkScheduledDeletion(user User) error {
+	if user.DeletionScheduledAt != nil && !user.deletionSelfScheduled() {
+		return ErrDeletionScheduledByAdmin
+	}
+	return nil
+}
+
+// cancelScheduledDeletion membatalkan penghapusan akun ketika pemiliknya berhasil
+// login lagi dalam masa tunggu. Penghapusan oleh admin tidak dibatalkan.
+func (s *service) cancelScheduledDeletion(user *User, origin audit.Origin) error {
+	if user.DeletionScheduledAt == nil {
+		return nil
+	}
+	if err := checkScheduledDeletion(*user); err != nil {
+		return err
+	}
+	cancelled, err := s.repository.CancelDeletion(user.ID)
+	if err != nil {
+		return fmt.Errorf("gagal membatalkan penghapusan akun: %w", err)
+	}
+	if !cancelled {
+		// Jadwal berubah sejak user dibaca (admin menjadwalkan ulang).
+		return ErrDeletionScheduledByAdmin
+	}
+	user.DeletionScheduledAt = nil
+	user.DeletionScheduledBy = nil
+	s.invalidateUserCache(user.ID)
+	s.recordEvent(audit.ActionUserDeletionCancelled, user.ID, user.ID, origin, nil)
+	log.Printf("Penghapusan akun user %d dibatalkan karena user login kembali", user.ID)
+	return nil
+}
@@ -0,0 +1,218 @@
+package user
+
+import (
+	"errors"
+	"example/hello/internal/pagination"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DirectorySort adalah urutan daftar user: nama kolom, diawali "-" untuk urutan menurun.
+type DirectorySort string
+
+const (
+	SortCreatedAtDesc DirectorySort = "-created_at"
+	SortCreatedAtAsc  DirectorySort = "created_at"
+	SortNameAsc       DirectorySort = "name"
+	SortNameDesc      DirectorySort = "-name"
+	SortEmailAsc      DirectorySort = "email"
+	SortEmailDesc     DirectorySort = "-email"
+)
+
+// DefaultDirectorySort menampilkan user terbaru lebih dulu.
+const DefaultDirectorySort = SortCreatedAtDesc
+
+const (
+	DefaultDirectoryLimit = 20
+	MaxDirectoryLimit     = 100
+)
+
+var (
+	ErrInvalidSort   = errors.New("sort harus salah satu dari created_at, -created_at, name, -name, email, -email")
+	ErrInvalidCursor = pagination.ErrInvalidCursor
+)
+
+// column mengembalikan kolom database dan arah urutan untuk sort.
+func (s DirectorySort) column() (string, bool, error) {
+	switch s {
+	case SortCreatedAtAsc, SortCreatedAtDesc, SortNameAsc, SortNameDesc, SortEmailAsc, SortEmailDesc:
+		return strings.TrimPrefix(string(s), "-"), strings.HasPrefix(string(s), "-"), nil
+	}
+	return "", false, ErrInvalidSort
+}
+
+// DirectoryFilter membatasi user yang ditampilkan. Field kosong tidak dipakai.
+type DirectoryFilter struct {
+	Verified    *bool
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	NamePrefix  string
+	EmailPrefix string
+}
+
+// DirectoryQuery adalah satu permintaan halaman. Cursor berasal dari
+// NextCursor halaman sebelumnya dan hanya berlaku untuk Sort yang sama.
+type DirectoryQuery struct {
+	Filter DirectoryFilter
+	Sort   DirectorySort
+	Cursor string
+	Limit  int
+}
+
+// DirectoryPage adalah satu halaman daftar user. NextCursor kosong berarti halaman terakhir.
+// Total adalah jumlah semua user yang cocok dengan filter, bukan hanya di halaman ini.
+type DirectoryPage struct {
+	Users      []User
+	NextCursor string
+	Total      int64
+}
+
+// DirectoryPageQuery adalah DirectoryQuery yang sudah divalidasi untuk repository.
+// AfterID 0 berarti halaman pertama; selain itu hanya baris setelah
+// (AfterValue, AfterID) menurut Column dan arah urutan yang diambil.
+type DirectoryPageQuery struct {
+	Filter     DirectoryFilter
+	Column     string
+	Descending bool
+	AfterValue any
+	AfterID    int
+	Limit      int
+}
+
+// encodeCursor menyimpan nilai kolom sort dan ID user terakhir (keyset pagination),
+// sehingga halaman berikutnya tetap benar walau ada user baru di antaranya.
+func encodeCursor(sort DirectorySort, column string, last User) string {
+	var value any
+	switch column {
+	case "created_at":
+		value = last.CreatedAt
+	case "name":
+		value = last.Name
+	case "email":
+		value = last.Email
+	}
+	return pagination.Encode(string(sort), value, last.ID)
+}
+
+// decodeCursor mengembalikan nilai kolom dalam tipe yang sesuai untuk query.
+// ID 0 berarti halaman pertama.
+func decodeCursor(raw string, sort DirectorySort, column string) (any, int, error) {
+	if raw == "" {
+		return nil, 0, nil
+	}
+	c, err := pagination.Decode(raw, string(sort))
+	if err != nil {
+		return nil, 0, err
+	}
+	if column != "created_at" {
+		return c.Value, c.ID, nil
+	}
+	createdAt, err := c.Time()
+	if err != nil {
+		return nil, 0, err
+	}
+	return createdAt, c.ID, nil
+}
+
+// directoryCacheKey menyusun kunci cache dari generasi dan semua parameter query.
+// Setiap perubahan data user menaikkan generasi, jadi semua halaman lama
+// otomatis tidak terpakai lagi dan hilang saat kadaluarsa.
+func directoryCacheKey(kind string, generation uint64, filter DirectoryFilter, extra ...string) string {
+	verified := ""
+	if filter.Verified != nil {
+		verified = fmt.Sprintf("%t", *filter.Verified)
+	}
+	parts := []string{
+		fmt.Sprintf("%s%s_%d", directoryCacheKeyPrefix, kind, generation),
+		verified,
+		formatTimeKey(filter.CreatedFrom),
+		formatTimeKey(filter.CreatedTo),
+		strings.ToLower(filter.NamePrefix),
+		strings.ToLower(filter.EmailPrefix),
+	}
+	return strings.Join(append(parts, extra...), "|")
+}
+
+func formatTimeKey(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// ListUsers mengembalikan satu halaman daftar user beserta total yang cocok dengan filter.
+func (s *service) ListUsers(query DirectoryQuery) (DirectoryPage, error) {
+	if query.Sort == "" {
+		query.Sort = DefaultDirectorySort
+	}
+	column, descending, err := query.Sort.column()
+	if err != nil {
+		return DirectoryPage{}, err
+	}
+	if query.Limit < 1 || query.Limit > MaxDirectoryLimit {
+		query.Limit = DefaultDirectoryLimit
+	}
+	afterValue, afterID, err := decodeCursor(query.Cursor, query.Sort, column)
+	if err != nil {
+		return DirectoryPage{}, err
+	}
+
+	generation := s.directoryGeneration.Load()
+	pageKey := directoryCacheKey("page", generation, query.Filter, string(query.Sort), query.Cursor, fmt.Sprint(query.Limit))
+	if x, found := s.cache.Get(pageKey); found {
+		return x.(DirectoryPage), nil
+	}
+
+	// Ambil satu baris lebih untuk mengetahui apakah masih ada halaman berikutnya.
+	pageQuery := DirectoryPageQuery{
+		Filter:     query.Filter,
+		Column:     column,
+		Descending: descending,
+		AfterValue: afterValue,
+		AfterID:    afterID,
+		Limit:      query.Limit + 1,
+	}
+	users, err := s.repository.FindDirectory(pageQuery)
+	if err != nil {
+		return DirectoryPage{}, fmt.Errorf("error finding users: %w", err)
+	}
+
+	page := DirectoryPage{}
+	if len(users) > query.Limit {
+		users = users[:query.Limit]
+		page.NextCursor = encodeCursor(query.Sort, column, users[len(users)-1])
+	}
+	for i := range users {
+		users[i].Password = ""
+	}
+	page.Users = users
+
+	total, err := s.countDirectory(generation, query.Filter)
+	if err != nil {
+		return DirectoryPage{}, err
+	}
+	page.Total = total
+
+	s.cache.Set(pageKey, page, directoryCacheTTL)
+	return page, nil
+}
+
+// countDirectory di-cache terpisah dari halaman karena sama untuk semua cursor.
+func (s *service) countDirectory(generation uint64, filter DirectoryFilter) (int64, error) {
+	countKey := directoryCacheKey("count", generation, filter)
+	if x, found := s.cache.Get(countKey); found {
+		return x.(int64), nil
+	}
+	total, err := s.repository.CountDirectory(filter)
+	if err != nil {
+		return 0, fmt.Errorf("error counting users: %w", err)
+	}
+	s.cache.Set(countKey, total, directoryCacheTTL)
+	return total, nil
+}
+
+// invalidateDirectory membuat semua halaman daftar user yang ter-cache tidak terpakai lagi.
+func (s *service) invalidateDirectory() {
+	s.directoryGeneration.Add(1)
+}
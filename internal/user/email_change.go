@@ -0,0 +1,172 @@
+package user
+
+import (
+	"errors"
+	"example/hello/internal/audit"
+	"example/hello/internal/mail"
+	"example/hello/internal/outbox"
+	"example/hello/internal/securetoken"
+	"fmt"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// emailChangeTTL adalah masa berlaku link konfirmasi yang dikirim ke alamat baru.
+	emailChangeTTL = 24 * time.Hour
+	// emailRevertTTL adalah masa berlaku link "bukan saya" yang dikirim ke alamat lama.
+	emailRevertTTL = 7 * 24 * time.Hour
+)
+
+// startEmailChange menyimpan newEmail sebagai email tertunda dan menyusun email
+// konfirmasi ke alamat baru. Pemanggil menyimpan user dan mengantrekan email
+// dalam satu transaksi.
+func (s *service) startEmailChange(user *User, newEmail string) (mail.Message, error) {
+	if !isValidEmail(newEmail) {
+		return mail.Message{}, fmt.Errorf("invalid email format")
+	}
+	if err := s.ensureEmailAvailable(newEmail, user.ID); err != nil {
+		return mail.Message{}, err
+	}
+
+	token, err := securetoken.Generate()
+	if err != nil {
+		return mail.Message{}, fmt.Errorf("gagal memulai perubahan email")
+	}
+	tokenHash := securetoken.Hash(token)
+	expiresAt := time.Now().Add(emailChangeTTL)
+	user.PendingEmail = &newEmail
+	user.EmailChangeTokenHash = &tokenHash
+	user.EmailChangeExpiresAt = &expiresAt
+
+	msg, err := s.renderEmail(*user, "email_change", map[string]any{
+		"Name":           user.Name,
+		"NewEmail":       newEmail,
+		"Link":           fmt.Sprintf("%s/v1/confirm-email-change?token=%s", s.appURL, url.QueryEscape(token)),
+		"ExpiresInHours": int(emailChangeTTL.Hours()),
+	})
+	if err != nil {
+		return mail.Message{}, fmt.Errorf("gagal menyusun email konfirmasi: %w", err)
+	}
+	// Konfirmasi dikirim ke alamat baru untuk membuktikan alamat itu milik user.
+	msg.To = []string{newEmail}
+	return msg, nil
+}
+
+// ConfirmEmailChange mengganti email user dengan email tertunda, lalu mengirim
+// notifikasi berisi link pembatalan ke alamat lama.
+func (s *service) ConfirmEmailChange(token string, origin audit.Origin) error {
+	user, err := s.repository.FindByEmailChangeToken(securetoken.Hash(token))
+	if err != nil {
+		return fmt.Errorf("token perubahan email tidak valid atau sudah digunakan")
+	}
+	if user.PendingEmail == nil || user.EmailChangeExpiresAt == nil || user.EmailChangeExpiresAt.Before(time.Now()) {
+		return fmt.Errorf("token perubahan email sudah kadaluarsa")
+	}
+
+	// Cek ulang: alamat bisa saja sudah didaftarkan orang lain sejak permintaan dibuat.
+	newEmail := *user.PendingEmail
+	if err := s.ensureEmailAvailable(newEmail, user.ID); err != nil {
+		return err
+	}
+
+	revertToken, err := securetoken.Generate()
+	if err != nil {
+		return fmt.Errorf("gagal memproses perubahan email")
+	}
+	revertHash := securetoken.Hash(revertToken)
+	revertExpiresAt := time.Now().Add(emailRevertTTL)
+
+	oldUser := user
+	oldEmail := user.Email
+	user.Email = newEmail
+	user.Verivied = true // Alamat baru terbukti milik user karena link dibuka dari sana
+	user.PendingEmail = nil
+	user.EmailChangeTokenHash = nil
+	user.EmailChangeExpiresAt = nil
+	user.EmailRevertAddress = &oldEmail
+	user.EmailRevertTokenHash = &revertHash
+	user.EmailRevertExpiresAt = &revertExpiresAt
+
+	// Notifikasi dikirim ke alamat lama, dengan bahasa dan nama yang sama.
+	msg, err := s.renderEmail(oldUser, "email_changed", map[string]any{
+		"Name":          user.Name,
+		"NewEmail":      newEmail,
+		"Link":          fmt.Sprintf("%s/v1/revert-email-change?token=%s", s.appURL, url.QueryEscape(revertToken)),
+		"ExpiresInDays": int(emailRevertTTL.Hours() / 24),
+	})
+	if err != nil {
+		return fmt.Errorf("gagal menyusun email notifikasi: %w", err)
+	}
+
+	err = s.repository.Transaction(func(txRepo Repository, txOutbox outbox.Repository) error {
+		if _, err := txRepo.Update(user); err != nil {
+			return err
+		}
+		return outbox.Enqueue(txOutbox, msg)
+	})
+	if err != nil {
+		return fmt.Errorf("gagal memperbarui email: %w", err)
+	}
+
+	s.invalidateUserCache(user.ID)
+	s.recordEvent(audit.ActionEmailChanged, 0, user.ID, origin, map[string]any{"old_email": oldEmail, "new_email": newEmail})
+	return nil
+}
+
+// RevertEmailChange mengembalikan email lama lewat link di email notifikasi.
+// Karena perubahan ini kemungkinan dilakukan orang lain, semua sesi dicabut.
+func (s *service) RevertEmailChange(token string, origin audit.Origin) error {
+	user, err := s.repository.FindByEmailRevertToken(securetoken.Hash(token))
+	if err != nil {
+		return fmt.Errorf("token pembatalan tidak valid atau sudah digunakan")
+	}
+	if user.EmailRevertAddress == nil || user.EmailRevertExpiresAt == nil || user.EmailRevertExpiresAt.Before(time.Now()) {
+		return fmt.Errorf("token pembatalan sudah kadaluarsa")
+	}
+
+	oldEmail := *user.EmailRevertAddress
+	if err := s.ensureEmailAvailable(oldEmail, user.ID); err != nil {
+		return err
+	}
+
+	changedEmail := user.Email
+	user.Email = oldEmail
+	user.EmailRevertAddress = nil
+	user.EmailRevertTokenHash = nil
+	user.EmailRevertExpiresAt = nil
+	user.PendingEmail = nil
+	user.EmailChangeTokenHash = nil
+	user.EmailChangeExpiresAt = nil
+	// Link reset password yang mungkin dikirim ke alamat penyerang tidak berlaku lagi
+	user.PasswordResetTokenHash = nil
+	user.PasswordResetExpiresAt = nil
+	if _, err := s.repository.Update(user); err != nil {
+		return fmt.Errorf("gagal mengembalikan email: %w", err)
+	}
+	s.recordEvent(audit.ActionEmailChangeReverted, 0, user.ID, origin, map[string]any{"reverted_email": changedEmail, "restored_email": oldEmail})
+
+	if err := s.sessionService.RevokeAllForUser(user.ID); err != nil {
+		return fmt.Errorf("email dikembalikan, tetapi gagal mencabut sesi lama: %w", err)
+	}
+	if _, err := s.apiKeys.RevokeAllForUser(originActor(origin), user.ID); err != nil {
+		return fmt.Errorf("email dikembalikan, tetapi gagal mencabut API key: %w", err)
+	}
+
+	s.invalidateUserCache(user.ID)
+	return nil
+}
+
+// ensureEmailAvailable memastikan email belum dipakai user lain.
+func (s *service) ensureEmailAvailable(email string, userID int) error {
+	existingUser, err := s.repository.FindByEmail(email)
+	if err == nil && existingUser.ID != userID {
+		return fmt.Errorf("email already registered")
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("error checking email: %w", err)
+	}
+	return nil
+}
@@ -2,15 +2,70 @@ package user
 
 import "time"
 
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
 type User struct {
 	ID                         int
 	Name                       string
 	Email                      string
 	Password                   string
-	Phone                      string
+	Phone                      string     // Format E.164, misal "+6281234567890"
+	PhoneVerifiedAt            *time.Time // Diisi setelah kode OTP untuk Phone dikonfirmasi; NULL jika nomor diganti
 	Verivied                   bool       `gorm:"default:false"`
-	VerificationToken          *string    `gorm:"uniqueIndex"` // Pointer agar bisa NULL
+	Role                       Role       `gorm:"type:varchar(20);default:user"`
+	Language                   string     `gorm:"type:varchar(5);default:id"` // Bahasa untuk email (id, en)
+	VerificationToken          *string    `gorm:"uniqueIndex"`                // Pointer agar bisa NULL
 	VerificationTokenExpiresAt *time.Time // Pointer agar bisa NULL
+	PasswordResetTokenHash     *string    `gorm:"type:varchar(64);uniqueIndex"` // SHA-256 dari token reset, token mentah hanya ada di email
+	PasswordResetExpiresAt     *time.Time
+	UnlockToken                *string `gorm:"uniqueIndex"` // Token untuk membuka kunci akun setelah terlalu banyak login gagal
+	UnlockTokenExpiresAt       *time.Time
+	TOTPSecret                 *string `gorm:"type:varchar(64)"` // Secret base32; terisi sejak enrollment dimulai
+	TOTPEnabled                bool    `gorm:"default:false"`    // true setelah enrollment dikonfirmasi dengan kode
+	TOTPLastUsedStep           int64   // Langkah waktu TOTP terakhir yang dipakai, mencegah kode dipakai ulang
+	PendingEmail               *string // Email baru yang menunggu konfirmasi dari alamat tersebut
+	EmailChangeTokenHash       *string `gorm:"type:varchar(64);uniqueIndex"`
+	EmailChangeExpiresAt       *time.Time
+	EmailRevertAddress         *string // Email lama, bisa dikembalikan lewat link di email notifikasi
+	EmailRevertTokenHash       *string `gorm:"type:varchar(64);uniqueIndex"`
+	EmailRevertExpiresAt       *time.Time
+	DeletionScheduledAt        *time.Time `gorm:"index"` // Akun dihapus permanen setelah waktu ini; NULL berarti aktif
+	DeletionScheduledBy        *int       // User yang menjadwalkan penghapusan; selain pemilik akun berarti admin
 	CreatedAt                  time.Time
 	UpdatedAt                  time.Time
 }
+
+// Identity menghubungkan user dengan akun di provider OAuth/OIDC. Identitas
+// dicari berdasarkan provider+subject, bukan email, karena email di provider bisa berubah.
+type Identity struct {
+	ID        int
+	UserID    int    `gorm:"not null;uniqueIndex:idx_identity_user_provider"`
+	Provider  string `gorm:"type:varchar(50);not null;uniqueIndex:idx_identity_provider_subject;uniqueIndex:idx_identity_user_provider"`
+	Subject   string `gorm:"type:varchar(255);not null;uniqueIndex:idx_identity_provider_subject"`
+	Email     string `gorm:"type:varchar(255)"` // Email di provider saat terakhir login, hanya informasi
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Identity) TableName() string {
+	return "user_identities"
+}
+
+// PasswordHistory menyimpan hash bcrypt dari password yang pernah dipasang user,
+// dipakai untuk menolak pemakaian ulang beberapa password terakhir.
+type PasswordHistory struct {
+	ID           int
+	UserID       int    `gorm:"index"`
+	PasswordHash string `gorm:"type:varchar(255)"`
+	CreatedAt    time.Time
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
@@ -0,0 +1,221 @@
+package user
+
+import (
+	"errors"
+	"example/hello/internal/audit"
+	"example/hello/internal/auth"
+	"example/hello/internal/outbox"
+	"example/hello/internal/policy"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// ErrProviderEmailNotVerified berarti provider tidak menjamin pemilik email,
+// sehingga akun baru tidak dibuat dari profil tersebut.
+var ErrProviderEmailNotVerified = errors.New("email dari provider belum diverifikasi, verifikasi email di provider atau daftar dengan email dan password")
+
+// LoginWithProvider login atau mendaftarkan user dari profil provider OAuth/OIDC.
+//   - Identitas provider+subject sudah tertaut: login sebagai user tersebut.
+//   - Email belum terdaftar: akun baru dibuat dan identitas langsung ditautkan,
+//     hanya jika provider menyatakan email sudah diverifikasi.
+//   - Email sudah dipakai akun lain: TIDAK ditautkan otomatis, user harus login ke
+//     akun tersebut lalu mengonfirmasi lewat LinkIdentity.
+func (s *service) LoginWithProvider(input ProviderLoginInput, origin audit.Origin) (LoginResult, error) {
+	identity, err := s.repository.FindIdentity(input.Provider, input.Subject)
+	if err == nil {
+		user, err := s.repository.FindByID(identity.UserID)
+		if err != nil {
+			return LoginResult{}, fmt.Errorf("error finding linked user: %w", err)
+		}
+		if input.Email != "" && identity.Email != input.Email {
+			identity.Email = input.Email
+			if _, err := s.repository.UpdateIdentity(identity); err != nil {
+				log.Printf("Gagal memperbarui email identitas %s: %v", input.Provider, err)
+			}
+		}
+		return s.CompleteLogin(user, origin, input.Provider)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return LoginResult{}, fmt.Errorf("error finding identity: %w", err)
+	}
+
+	if input.Email == "" {
+		return LoginResult{}, fmt.Errorf("provider %s tidak memberikan alamat email", input.Provider)
+	}
+
+	existingUser, err := s.repository.FindByEmail(input.Email)
+	if err == nil {
+		if isLegacyGoogleAccount(existingUser, input) {
+			return s.linkAndLogin(existingUser, input, origin)
+		}
+
+		linkToken, err := s.CreateLinkToken(input)
+		if err != nil {
+			return LoginResult{}, err
+		}
+		return LoginResult{LinkRequired: true, LinkToken: linkToken}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return LoginResult{}, fmt.Errorf("error finding user by email: %w", err)
+	}
+
+	// Tanpa email terverifikasi, siapa pun bisa menyiapkan akun atas email orang
+	// lain lalu tetap memegangnya lewat identitas provider.
+	if !input.EmailVerified {
+		return LoginResult{}, ErrProviderEmailNotVerified
+	}
+	return s.registerFromProvider(input, origin)
+}
+
+// isLegacyGoogleAccount mengenali akun yang dibuat lewat login Google sebelum
+// tabel identitas ada: tanpa password dan email Google-nya terverifikasi.
+// Akun seperti ini tidak punya cara lain untuk login, jadi langsung ditautkan.
+func isLegacyGoogleAccount(user User, input ProviderLoginInput) bool {
+	return input.Provider == "google" && input.EmailVerified && user.Password == ""
+}
+
+// linkAndLogin menautkan identitas ke user lalu melanjutkan login.
+func (s *service) linkAndLogin(user User, input ProviderLoginInput, origin audit.Origin) (LoginResult, error) {
+	if _, err := s.repository.CreateIdentity(Identity{
+		UserID:   user.ID,
+		Provider: input.Provider,
+		Subject:  input.Subject,
+		Email:    input.Email,
+	}); err != nil {
+		return LoginResult{}, fmt.Errorf("failed to link %s account: %w", input.Provider, err)
+	}
+	s.recordEvent(audit.ActionIdentityLinked, user.ID, user.ID, origin, map[string]any{"provider": input.Provider, "automatic": true})
+	return s.CompleteLogin(user, origin, input.Provider)
+}
+
+// registerFromProvider membuat akun baru beserta identitasnya dalam satu transaksi.
+// Email-nya sudah diverifikasi provider, jadi akun langsung terverifikasi.
+func (s *service) registerFromProvider(input ProviderLoginInput, origin audit.Origin) (LoginResult, error) {
+	name := input.Name
+	if name == "" {
+		name = input.Email
+	}
+	newUser := User{
+		Name:     name,
+		Email:    input.Email,
+		Verivied: true,
+		Role:     RoleUser,
+		// Password dibiarkan kosong sehingga akun ini tidak bisa login dengan password
+		// sampai user mengaturnya sendiri.
+	}
+
+	var createdUser User
+	err := s.repository.Transaction(func(txRepo Repository, txOutbox outbox.Repository) error {
+		created, err := txRepo.RegisterUser(newUser)
+		if err != nil {
+			return err
+		}
+		if _, err := txRepo.CreateIdentity(Identity{
+			UserID:   created.ID,
+			Provider: input.Provider,
+			Subject:  input.Subject,
+			Email:    input.Email,
+		}); err != nil {
+			return err
+		}
+		createdUser = created
+		return nil
+	})
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("failed to create %s user: %w", input.Provider, err)
+	}
+	s.invalidateDirectory()
+	s.recordEvent(audit.ActionUserRegistered, createdUser.ID, createdUser.ID, origin, map[string]any{"method": input.Provider})
+
+	return s.CompleteLogin(createdUser, origin, input.Provider)
+}
+
+// CreateLinkToken membuat token untuk menautkan identitas provider ke akun yang sedang login.
+func (s *service) CreateLinkToken(input ProviderLoginInput) (string, error) {
+	linkToken, err := auth.GenerateLinkToken(input.Provider, input.Subject, input.Email, input.EmailVerified, input.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate link token")
+	}
+	return linkToken, nil
+}
+
+// LinkIdentity menautkan identitas dari token penautan ke user yang sedang login.
+// Karena user harus login ke akunnya sendiri, penautan ini sudah dikonfirmasi secara eksplisit.
+func (s *service) LinkIdentity(actor policy.Actor, linkToken string) (Identity, error) {
+	userID := actor.UserID
+	claims, err := auth.ValidateLinkToken(linkToken)
+	if err != nil {
+		return Identity{}, fmt.Errorf("token penautan tidak valid atau sudah kadaluarsa")
+	}
+
+	identity, err := s.repository.FindIdentity(claims.LinkProvider, claims.LinkSubject)
+	if err == nil {
+		if identity.UserID == userID {
+			return identity, nil
+		}
+		return Identity{}, fmt.Errorf("akun %s ini sudah ditautkan ke pengguna lain", claims.LinkProvider)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return Identity{}, fmt.Errorf("error finding identity: %w", err)
+	}
+
+	identities, err := s.repository.FindIdentitiesByUser(userID)
+	if err != nil {
+		return Identity{}, fmt.Errorf("error finding identities: %w", err)
+	}
+	for _, existing := range identities {
+		if existing.Provider == claims.LinkProvider {
+			return Identity{}, fmt.Errorf("akun Anda sudah tertaut dengan akun %s lain, lepaskan dulu", claims.LinkProvider)
+		}
+	}
+
+	identity, err = s.repository.CreateIdentity(Identity{
+		UserID:   userID,
+		Provider: claims.LinkProvider,
+		Subject:  claims.LinkSubject,
+		Email:    claims.Email,
+	})
+	if err != nil {
+		return Identity{}, fmt.Errorf("failed to link %s account: %w", claims.LinkProvider, err)
+	}
+	s.recordEvent(audit.ActionIdentityLinked, userID, userID, audit.ActorOrigin(actor), map[string]any{"provider": claims.LinkProvider})
+	return identity, nil
+}
+
+func (s *service) FindIdentities(userID int) ([]Identity, error) {
+	identities, err := s.repository.FindIdentitiesByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding identities: %w", err)
+	}
+	return identities, nil
+}
+
+// UnlinkIdentity melepas identitas provider. Identitas terakhir dari akun tanpa
+// password tidak boleh dilepas karena user tidak akan bisa login lagi.
+func (s *service) UnlinkIdentity(actor policy.Actor, provider string) error {
+	userID := actor.UserID
+	user, err := s.repository.FindByID(userID)
+	if err != nil {
+		return fmt.Errorf("error finding user: %w", err)
+	}
+
+	identities, err := s.repository.FindIdentitiesByUser(userID)
+	if err != nil {
+		return fmt.Errorf("error finding identities: %w", err)
+	}
+	if user.Password == "" && len(identities) <= 1 {
+		return fmt.Errorf("tidak bisa melepas satu-satunya metode login, atur password terlebih dahulu")
+	}
+
+	deleted, err := s.repository.DeleteIdentity(userID, provider)
+	if err != nil {
+		return fmt.Errorf("failed to unlink %s account: %w", provider, err)
+	}
+	if !deleted {
+		return fmt.Errorf("akun %s tidak tertaut", provider)
+	}
+	s.recordEvent(audit.ActionIdentityUnlinked, userID, userID, audit.ActorOrigin(actor), map[string]any{"provider": provider})
+	return nil
+}
@@ -0,0 +1,241 @@
+package user
+
+import (
+	"example/hello/internal/audit"
+	"example/hello/internal/auth"
+	"example/hello/internal/mfa"
+	"example/hello/internal/policy"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// totpIssuer ditampilkan aplikasi authenticator sebagai nama akun.
+const totpIssuer = "MyApplication"
+
+// BeginTOTPEnrollment membuat secret TOTP baru untuk user. 2FA belum aktif
+// sampai user mengonfirmasi dengan kode dari aplikasi authenticator.
+func (s *service) BeginTOTPEnrollment(actor policy.Actor) (TOTPEnrollmentResponse, error) {
+	user, err := s.repository.FindByID(actor.UserID)
+	if err != nil {
+		return TOTPEnrollmentResponse{}, fmt.Errorf("error finding user: %w", err)
+	}
+	if user.TOTPEnabled {
+		return TOTPEnrollmentResponse{}, fmt.Errorf("2FA sudah aktif, nonaktifkan dulu untuk mengganti authenticator")
+	}
+
+	secret, err := mfa.GenerateSecret()
+	if err != nil {
+		return TOTPEnrollmentResponse{}, fmt.Errorf("gagal membuat secret 2FA")
+	}
+	user.TOTPSecret = &secret
+	user.TOTPLastUsedStep = 0
+	if _, err := s.repository.Update(user); err != nil {
+		return TOTPEnrollmentResponse{}, fmt.Errorf("gagal menyimpan secret 2FA: %w", err)
+	}
+
+	return TOTPEnrollmentResponse{
+		Secret:          secret,
+		ProvisioningURI: mfa.ProvisioningURI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTPEnrollment mengaktifkan 2FA jika kode cocok dengan secret yang
+// baru dibuat, lalu mengembalikan kode pemulihan (hanya ditampilkan sekali).
+func (s *service) ConfirmTOTPEnrollment(actor policy.Actor, code string) ([]string, error) {
+	user, err := s.repository.FindByID(actor.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding user: %w", err)
+	}
+	if user.TOTPEnabled {
+		return nil, fmt.Errorf("2FA sudah aktif")
+	}
+	if user.TOTPSecret == nil {
+		return nil, fmt.Errorf("mulai pendaftaran 2FA terlebih dahulu")
+	}
+
+	ok, err := s.useTOTPCode(&user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("kode 2FA tidak valid")
+	}
+
+	codes, err := s.replaceRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPEnabled = true
+	if _, err := s.repository.Update(user); err != nil {
+		return nil, fmt.Errorf("gagal mengaktifkan 2FA: %w", err)
+	}
+	s.invalidateUserCache(user.ID)
+	s.recordEvent(audit.ActionMFAEnabled, user.ID, user.ID, audit.ActorOrigin(actor), map[string]any{"method": "totp"})
+
+	return codes, nil
+}
+
+// DisableTOTP menonaktifkan 2FA. Kode TOTP atau kode pemulihan yang valid wajib diberikan
+// agar access token yang dicuri saja tidak cukup untuk mematikan 2FA.
+func (s *service) DisableTOTP(actor policy.Actor, code string) error {
+	user, err := s.repository.FindByID(actor.UserID)
+	if err != nil {
+		return fmt.Errorf("error finding user: %w", err)
+	}
+	if !user.TOTPEnabled {
+		return fmt.Errorf("2FA belum aktif")
+	}
+
+	ok, err := s.verifySecondFactor(&user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("kode 2FA tidak valid")
+	}
+
+	user.TOTPEnabled = false
+	user.TOTPSecret = nil
+	user.TOTPLastUsedStep = 0
+	if _, err := s.repository.Update(user); err != nil {
+		return fmt.Errorf("gagal menonaktifkan 2FA: %w", err)
+	}
+	if err := s.mfaRepository.DeleteRecoveryCodes(user.ID); err != nil {
+		return fmt.Errorf("gagal menghapus kode pemulihan: %w", err)
+	}
+	s.invalidateUserCache(user.ID)
+	s.recordEvent(audit.ActionMFADisabled, user.ID, user.ID, audit.ActorOrigin(actor), map[string]any{"method": "totp"})
+
+	return nil
+}
+
+// RegenerateRecoveryCodes mengganti semua kode pemulihan. Kode lama langsung tidak berlaku.
+func (s *service) RegenerateRecoveryCodes(actor policy.Actor, code string) ([]string, error) {
+	user, err := s.repository.FindByID(actor.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding user: %w", err)
+	}
+	if !user.TOTPEnabled || user.TOTPSecret == nil {
+		return nil, fmt.Errorf("2FA belum aktif")
+	}
+
+	ok, err := s.useTOTPCode(&user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("kode 2FA tidak valid")
+	}
+
+	codes, err := s.replaceRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.recordEvent(audit.ActionRecoveryCodesGenerated, user.ID, user.ID, audit.ActorOrigin(actor), nil)
+	return codes, nil
+}
+
+// VerifyMFALogin adalah langkah kedua login: menukar token tantangan dan kode
+// TOTP (atau kode pemulihan) dengan pasangan token. Kode yang salah dihitung
+// sebagai login gagal sehingga ikut dibatasi loginguard.
+func (s *service) VerifyMFALogin(mfaToken, code string, origin audit.Origin) (LoginResult, error) {
+	claims, err := auth.ValidateMFAToken(mfaToken)
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("token 2FA tidak valid atau sudah kadaluarsa")
+	}
+	userID, err := strconv.Atoi(claims.MFAUserID)
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("token 2FA tidak valid atau sudah kadaluarsa")
+	}
+
+	user, err := s.repository.FindByID(userID)
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("token 2FA tidak valid atau sudah kadaluarsa")
+	}
+
+	if err := s.loginGuard.Check(user.Email, origin.IP); err != nil {
+		return LoginResult{}, err
+	}
+
+	ok, err := s.verifySecondFactor(&user, code)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if !ok {
+		s.recordLoginFailure(user.Email, origin, &user, audit.ActionMFAFailed)
+		return LoginResult{}, fmt.Errorf("kode 2FA tidak valid")
+	}
+
+	if err := s.loginGuard.RecordSuccess(user.Email, origin.IP); err != nil {
+		log.Printf("Gagal mereset counter login untuk %s: %v", user.Email, err)
+	}
+
+	if err := s.cancelScheduledDeletion(&user, origin); err != nil {
+		return LoginResult{}, err
+	}
+
+	pair, err := s.sessionService.Issue(user.ID, user.Verivied, string(user.Role), "", origin)
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("failed to generate authentication token")
+	}
+	s.recordEvent(audit.ActionLoginSucceeded, user.ID, user.ID, origin, map[string]any{"method": "2fa"})
+
+	user.Password = ""
+	return LoginResult{Pair: pair, User: user}, nil
+}
+
+// verifySecondFactor menerima kode TOTP atau kode pemulihan.
+func (s *service) verifySecondFactor(user *User, code string) (bool, error) {
+	if user.TOTPSecret == nil {
+		return false, nil
+	}
+
+	ok, err := s.useTOTPCode(user, code)
+	if err != nil || ok {
+		return ok, err
+	}
+
+	used, err := s.mfaRepository.UseRecoveryCode(user.ID, mfa.HashRecoveryCode(code), time.Now())
+	if err != nil {
+		return false, fmt.Errorf("gagal memeriksa kode pemulihan: %w", err)
+	}
+	return used, nil
+}
+
+// useTOTPCode memeriksa kode TOTP lalu menyimpan langkah waktunya dengan update
+// bersyarat, sehingga dua request bersamaan dengan kode yang sama hanya satu
+// yang diterima.
+func (s *service) useTOTPCode(user *User, code string) (bool, error) {
+	step, ok := mfa.ValidateCode(*user.TOTPSecret, code, time.Now(), user.TOTPLastUsedStep)
+	if !ok {
+		return false, nil
+	}
+	used, err := s.repository.UseTOTPStep(user.ID, step)
+	if err != nil {
+		return false, fmt.Errorf("gagal memperbarui status 2FA: %w", err)
+	}
+	if !used {
+		return false, nil
+	}
+	user.TOTPLastUsedStep = step
+	return true, nil
+}
+
+// replaceRecoveryCodes membuat kode pemulihan baru dan menyimpan hash-nya.
+func (s *service) replaceRecoveryCodes(userID int) ([]string, error) {
+	codes, err := mfa.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat kode pemulihan")
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, mfa.HashRecoveryCode(code))
+	}
+	if err := s.mfaRepository.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, fmt.Errorf("gagal menyimpan kode pemulihan: %w", err)
+	}
+	return codes, nil
+}
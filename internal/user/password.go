@@ -0,0 +1,89 @@
+package user
+
+import (
+	"errors"
+	"example/hello/internal/password"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const bcryptCost = 10
+
+// PasswordPolicy ditampilkan ke client agar form bisa memeriksa password lebih awal.
+func (s *service) PasswordPolicy() password.Policy {
+	return s.passwords.Policy()
+}
+
+// hashNewPassword memeriksa password baru terhadap kebijakan password dan
+// riwayat password user, lalu mengembalikan hash bcrypt-nya. Penolakan
+// dikembalikan sebagai *password.PolicyError berisi semua alasannya.
+func (s *service) hashNewPassword(user User, newPassword string, info password.UserInfo) (string, error) {
+	err := s.passwords.Check(newPassword, info)
+	var policyErr *password.PolicyError
+	if err != nil && !errors.As(err, &policyErr) {
+		return "", err
+	}
+
+	if policyErr == nil && user.ID != 0 {
+		reused, err := s.isRecentPassword(user, newPassword)
+		if err != nil {
+			return "", err
+		}
+		if reused {
+			policyErr = &password.PolicyError{Violations: []password.Violation{
+				password.ReusedViolation(s.passwords.Policy().HistorySize),
+			}}
+		}
+	}
+	if policyErr != nil {
+		return "", policyErr
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcryptCost)
+	if err != nil {
+		return "", fmt.Errorf("error hashing password: %w", err)
+	}
+	return string(hashed), nil
+}
+
+// isRecentPassword membandingkan password dengan password saat ini dan riwayat.
+// Password saat ini ikut diperiksa karena user lama belum punya riwayat.
+func (s *service) isRecentPassword(user User, newPassword string) (bool, error) {
+	historySize := s.passwords.Policy().HistorySize
+	if historySize == 0 {
+		return false, nil
+	}
+
+	hashes := []string{}
+	if user.Password != "" {
+		hashes = append(hashes, user.Password)
+	}
+	history, err := s.repository.FindPasswordHistory(user.ID, historySize)
+	if err != nil {
+		return false, fmt.Errorf("error finding password history: %w", err)
+	}
+	for _, entry := range history {
+		hashes = append(hashes, entry.PasswordHash)
+	}
+
+	for _, hash := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(newPassword)) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// rememberPassword mencatat hash password baru ke riwayat, dipanggil di dalam
+// transaksi yang sama dengan penyimpanan password.
+func (s *service) rememberPassword(repo Repository, userID int, hash string) error {
+	historySize := s.passwords.Policy().HistorySize
+	if historySize == 0 {
+		return nil
+	}
+	if err := repo.AddPasswordHistory(PasswordHistory{UserID: userID, PasswordHash: hash}); err != nil {
+		return fmt.Errorf("error saving password history: %w", err)
+	}
+	return repo.TrimPasswordHistory(userID, historySize)
+}
@@ -0,0 +1,62 @@
+package user
+
+import (
+	"example/hello/internal/audit"
+	"example/hello/internal/phone"
+	"example/hello/internal/policy"
+	"fmt"
+	"time"
+)
+
+// SendPhoneVerification mengirim kode OTP ke nomor telepon user. Nomor lama yang
+// tersimpan sebelum dinormalisasi diubah ke format E.164 lebih dulu.
+func (s *service) SendPhoneVerification(actor policy.Actor) (time.Time, error) {
+	user, err := s.repository.FindByID(actor.UserID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error finding user: %w", err)
+	}
+	if user.Phone == "" {
+		return time.Time{}, fmt.Errorf("nomor telepon belum diisi")
+	}
+	if user.PhoneVerifiedAt != nil {
+		return time.Time{}, fmt.Errorf("nomor telepon sudah terverifikasi")
+	}
+
+	normalizedPhone, err := phone.Normalize(user.Phone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w, perbarui nomor di profil", err)
+	}
+	if normalizedPhone != user.Phone {
+		user.Phone = normalizedPhone
+		if _, err := s.repository.Update(user); err != nil {
+			return time.Time{}, fmt.Errorf("gagal menyimpan nomor telepon: %w", err)
+		}
+		s.invalidateUserCache(user.ID)
+	}
+
+	return s.phoneService.SendCode(user.ID, user.Phone, user.Language)
+}
+
+// VerifyPhone menandai nomor telepon terverifikasi jika kode OTP cocok.
+func (s *service) VerifyPhone(actor policy.Actor, code string) error {
+	user, err := s.repository.FindByID(actor.UserID)
+	if err != nil {
+		return fmt.Errorf("error finding user: %w", err)
+	}
+	if user.PhoneVerifiedAt != nil {
+		return fmt.Errorf("nomor telepon sudah terverifikasi")
+	}
+
+	if err := s.phoneService.VerifyCode(user.ID, user.Phone, code); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user.PhoneVerifiedAt = &now
+	if _, err := s.repository.Update(user); err != nil {
+		return fmt.Errorf("gagal menyimpan status verifikasi nomor telepon: %w", err)
+	}
+	s.invalidateUserCache(user.ID)
+	s.recordEvent(audit.ActionPhoneVerified, actor.UserID, user.ID, audit.ActorOrigin(actor), map[string]any{"phone": user.Phone})
+	return nil
+}
@@ -1,19 +1,45 @@
 package user
 
 import (
+	"example/hello/internal/outbox"
+	"example/hello/internal/pagination"
+
 	"gorm.io/gorm"
 )
 
 type Repository interface {
-	FindAll() ([]User, error)
+	// FindDirectory dan CountDirectory tidak menyertakan akun yang sedang menunggu penghapusan permanen.
+	FindDirectory(query DirectoryPageQuery) ([]User, error)
+	CountDirectory(filter DirectoryFilter) (int64, error)
 	FindByID(ID int) (User, error)
 	FindByEmail(email string) (User, error)
 	RegisterUser(user User) (User, error)
 	LoginUser(user User) (User, error)
 	Update(user User) (User, error)
-	Delete(ID int) error
+	CancelDeletion(userID int) (bool, error)
+	// UseTOTPStep menyimpan langkah TOTP hanya jika lebih baru dari yang
+	// tersimpan. false berarti kode tersebut sudah dipakai.
+	UseTOTPStep(userID int, step int64) (bool, error)
 	FindByVerificationToken(token string) (User, error)
+	FindByUnlockToken(token string) (User, error)
 	ResetPassword(user User) (User, error)
+	FindByPasswordResetToken(hash string) (User, error)
+	FindByEmailChangeToken(hash string) (User, error)
+	FindByEmailRevertToken(hash string) (User, error)
+	ConsumePasswordResetToken(userID int, hash string) (bool, error)
+	CountByRole(role Role) (int64, error)
+	FindIdentity(provider, subject string) (Identity, error)
+	FindIdentitiesByUser(userID int) ([]Identity, error)
+	CreateIdentity(identity Identity) (Identity, error)
+	UpdateIdentity(identity Identity) (Identity, error)
+	DeleteIdentity(userID int, provider string) (bool, error)
+	AddPasswordHistory(entry PasswordHistory) error
+	FindPasswordHistory(userID int, limit int) ([]PasswordHistory, error)
+	// TrimPasswordHistory menghapus riwayat selain keep entri terbaru.
+	TrimPasswordHistory(userID int, keep int) error
+	// Transaction menjalankan fn dalam satu transaksi database. Repository user dan
+	// outbox yang diberikan ke fn memakai transaksi yang sama.
+	Transaction(fn func(txRepo Repository, txOutbox outbox.Repository) error) error
 }
 
 type repository struct {
@@ -24,14 +50,53 @@ func NewRepository(db *gorm.DB) *repository {
 	return &repository{db}
 }
 
-func (r *repository) FindAll() ([]User, error) {
+func (r *repository) FindDirectory(query DirectoryPageQuery) ([]User, error) {
 	var users []User
-	if err := r.db.Find(&users).Error; err != nil {
+	// Column sudah divalidasi service, jadi aman disusun ke SQL.
+	keyset := pagination.Keyset{Column: query.Column, Descending: query.Descending}
+	db := r.db.Scopes(query.Filter.scope)
+	if query.AfterID > 0 {
+		db = db.Scopes(keyset.After(query.AfterValue, query.AfterID))
+	}
+
+	err := db.Order(keyset.Order()).
+		Limit(query.Limit).
+		Find(&users).Error
+	if err != nil {
 		return nil, err
 	}
 	return users, nil
 }
 
+func (r *repository) CountDirectory(filter DirectoryFilter) (int64, error) {
+	var count int64
+	if err := r.db.Model(&User{}).Scopes(filter.scope).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// scope menerapkan filter direktori user ke query.
+func (f DirectoryFilter) scope(db *gorm.DB) *gorm.DB {
+	db = db.Where("deletion_scheduled_at IS NULL")
+	if f.Verified != nil {
+		db = db.Where("verivied = ?", *f.Verified)
+	}
+	if !f.CreatedFrom.IsZero() {
+		db = db.Where("created_at >= ?", f.CreatedFrom)
+	}
+	if !f.CreatedTo.IsZero() {
+		db = db.Where("created_at < ?", f.CreatedTo)
+	}
+	if f.NamePrefix != "" {
+		db = db.Where("name LIKE ?", pagination.EscapeLike(f.NamePrefix)+"%")
+	}
+	if f.EmailPrefix != "" {
+		db = db.Where("email LIKE ?", pagination.EscapeLike(f.EmailPrefix)+"%")
+	}
+	return db
+}
+
 func (r *repository) FindByID(ID int) (User, error) {
 	var user User
 	if err := r.db.First(&user, ID).Error; err != nil {
@@ -70,11 +135,28 @@ func (r *repository) Update(user User) (User, error) {
 	return user, nil
 }
 
-func (r *repository) Delete(ID int) error {
-	if err := r.db.Delete(&User{}, ID).Error; err != nil {
-		return err
+// CancelDeletion hanya membatalkan penghapusan yang dijadwalkan pemilik akun
+// sendiri; jadwal lama tanpa deletion_scheduled_by juga dianggap dari pemiliknya,
+// sama seperti deletionSelfScheduled. false berarti tidak ada yang dibatalkan, misal admin baru saja
+// menjadwalkan ulang penghapusannya.
+func (r *repository) CancelDeletion(userID int) (bool, error) {
+	result := r.db.Model(&User{}).
+		Where("id = ? AND deletion_scheduled_at IS NOT NULL AND (deletion_scheduled_by = ? OR deletion_scheduled_by IS NULL)", userID, userID).
+		Updates(map[string]any{"deletion_scheduled_at": nil, "deletion_scheduled_by": nil})
+	if result.Error != nil {
+		return false, result.Error
 	}
-	return nil
+	return result.RowsAffected == 1, nil
+}
+
+func (r *repository) UseTOTPStep(userID int, step int64) (bool, error) {
+	result := r.db.Model(&User{}).
+		Where("id = ? AND totp_last_used_step < ?", userID, step).
+		Update("totp_last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
 }
 
 func (r *repository) FindByVerificationToken(token string) (User, error) {
@@ -85,6 +167,14 @@ func (r *repository) FindByVerificationToken(token string) (User, error) {
 	return user, nil
 }
 
+func (r *repository) FindByUnlockToken(token string) (User, error) {
+	var user User
+	if err := r.db.Where("unlock_token = ?", token).First(&user).Error; err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
 func (r *repository) ResetPassword(user User) (User, error) {
 	if err := r.db.Save(&user).Error; err != nil {
 		return User{},
@@ -92,3 +182,113 @@ func (r *repository) ResetPassword(user User) (User, error) {
 	}
 	return user, nil
 }
+
+func (r *repository) FindByPasswordResetToken(hash string) (User, error) {
+	var user User
+	if err := r.db.Where("password_reset_token_hash = ?", hash).First(&user).Error; err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+func (r *repository) FindByEmailChangeToken(hash string) (User, error) {
+	var user User
+	if err := r.db.Where("email_change_token_hash = ?", hash).First(&user).Error; err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+func (r *repository) FindByEmailRevertToken(hash string) (User, error) {
+	var user User
+	if err := r.db.Where("email_revert_token_hash = ?", hash).First(&user).Error; err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+// ConsumePasswordResetToken menghapus token reset hanya jika masih sama dengan hash,
+// sehingga dua request bersamaan tidak bisa memakai token yang sama.
+func (r *repository) ConsumePasswordResetToken(userID int, hash string) (bool, error) {
+	result := r.db.Model(&User{}).
+		Where("id = ? AND password_reset_token_hash = ?", userID, hash).
+		Updates(map[string]any{"password_reset_token_hash": nil, "password_reset_expires_at": nil})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *repository) CountByRole(role Role) (int64, error) {
+	var count int64
+	if err := r.db.Model(&User{}).Where("role = ?", role).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *repository) FindIdentity(provider, subject string) (Identity, error) {
+	var identity Identity
+	if err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return Identity{}, err
+	}
+	return identity, nil
+}
+
+func (r *repository) FindIdentitiesByUser(userID int) ([]Identity, error) {
+	var identities []Identity
+	if err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (r *repository) CreateIdentity(identity Identity) (Identity, error) {
+	if err := r.db.Create(&identity).Error; err != nil {
+		return Identity{}, err
+	}
+	return identity, nil
+}
+
+func (r *repository) UpdateIdentity(identity Identity) (Identity, error) {
+	if err := r.db.Save(&identity).Error; err != nil {
+		return Identity{}, err
+	}
+	return identity, nil
+}
+
+// DeleteIdentity melepas identitas provider dari user. Mengembalikan false jika tidak ada.
+func (r *repository) DeleteIdentity(userID int, provider string) (bool, error) {
+	result := r.db.Where("user_id = ? AND provider = ?", userID, provider).Delete(&Identity{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repository) AddPasswordHistory(entry PasswordHistory) error {
+	return r.db.Create(&entry).Error
+}
+
+func (r *repository) FindPasswordHistory(userID int, limit int) ([]PasswordHistory, error) {
+	var entries []PasswordHistory
+	if err := r.db.Where("user_id = ?", userID).Order("id desc").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *repository) TrimPasswordHistory(userID int, keep int) error {
+	var oldestKept PasswordHistory
+	err := r.db.Where("user_id = ?", userID).Order("id desc").Offset(keep - 1).Limit(1).Find(&oldestKept).Error
+	if err != nil || oldestKept.ID == 0 {
+		return err
+	}
+	return r.db.Where("user_id = ? AND id < ?", userID, oldestKept.ID).Delete(&PasswordHistory{}).Error
+}
+
+func (r *repository) Transaction(fn func(txRepo Repository, txOutbox outbox.Repository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewRepository(tx), outbox.NewRepository(tx))
+	})
+}
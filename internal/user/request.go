@@ -3,8 +3,9 @@ package user
 type UserRequest struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"` // Aturan lain diperiksa kebijakan password di service
 	Phone    string `json:"phone" binding:"required"`
+	Language string `json:"language" binding:"omitempty,oneof=id en"`
 }
 
 type UserRequestUpdate struct {
@@ -25,10 +26,18 @@ type UpdateUserInput struct {
 	Email string `json:"email" binding:"required,email"`
 }
 
-// GoogleLoginInput is used when creating or finding a user from Google OAuth.
-type GoogleLoginInput struct {
-	Email string `json:"email" binding:"required,email"`
-	Name  string `json:"name" binding:"required"`
+// ProviderLoginInput is the verified profile returned by an OAuth/OIDC provider.
+type ProviderLoginInput struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// LinkIdentityInput confirms linking a provider account to the logged-in user.
+type LinkIdentityInput struct {
+	LinkToken string `json:"link_token" binding:"required"`
 }
 
 type ResendVerificationInput struct {
@@ -36,5 +45,26 @@ type ResendVerificationInput struct {
 }
 
 type ResetPassword struct {
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
+}
+
+// UpdateRoleInput is used by admins to change a user's role.
+type UpdateRoleInput struct {
+	Role Role `json:"role" binding:"required,oneof=user moderator admin"`
+}
+
+// MFALoginInput is used for the second login step when 2FA is enabled.
+type MFALoginInput struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// TOTPCodeInput carries a code from the authenticator app.
+type TOTPCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// PhoneCodeInput carries the OTP code received by SMS.
+type PhoneCodeInput struct {
+	Code string `json:"code" binding:"required"`
 }
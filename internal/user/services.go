@@ -1,81 +1,149 @@
 package user
 
 import (
+	"errors"
+	"example/hello/internal/audit"
 	"example/hello/internal/auth"
+	"example/hello/internal/loginguard"
+	"example/hello/internal/mail"
+	"example/hello/internal/mfa"
+	"example/hello/internal/outbox"
+	"example/hello/internal/password"
+	"example/hello/internal/phone"
+	"example/hello/internal/policy"
+	"example/hello/internal/securetoken"
+	"example/hello/internal/session"
 	"fmt"
 	"log"
-	"net/smtp"
+	"net/url"
 	"os"
 	"regexp"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/patrickmn/go-cache"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+// LoginResult adalah hasil login. Jika 2FA aktif, Pair masih kosong dan
+// client harus menukar MFAToken beserta kode 2FA di VerifyMFALogin.
+//
+// LinkRequired berarti email dari provider sudah dipakai akun lain: user harus
+// login ke akun tersebut lalu mengonfirmasi penautan dengan LinkToken.
+type LoginResult struct {
+	Pair         session.Pair
+	User         User
+	MFARequired  bool
+	MFAToken     string
+	LinkRequired bool
+	LinkToken    string
+}
+
 type Service interface {
-	RegisterUser(user UserRequest) (User, error)
-	UserLogin(req UserLogin) (string, User, error)
-	FindAll() ([]User, error)
+	RegisterUser(user UserRequest, origin audit.Origin) (User, error)
+	UserLogin(req UserLogin, origin audit.Origin) (LoginResult, error)
+	CompleteLogin(user User, origin audit.Origin, method string) (LoginResult, error)
+	VerifyMFALogin(mfaToken, code string, origin audit.Origin) (LoginResult, error)
+	RefreshToken(rawRefreshToken string, origin audit.Origin) (session.Pair, error)
+	ListUsers(query DirectoryQuery) (DirectoryPage, error)
 	FindByID(ID int) (User, error)
-	Update(ID int, user UserRequest) (User, error)
-	Delete(ID int) error
-	FindOrCreateByGoogle(input GoogleLoginInput) (User, error)
+	Update(actor policy.Actor, ID int, user UserRequest) (User, error)
+	Delete(actor policy.Actor, ID int) (User, error)
+	LoginWithProvider(input ProviderLoginInput, origin audit.Origin) (LoginResult, error)
+	CreateLinkToken(input ProviderLoginInput) (string, error)
+	LinkIdentity(actor policy.Actor, linkToken string) (Identity, error)
+	FindIdentities(userID int) ([]Identity, error)
+	UnlinkIdentity(actor policy.Actor, provider string) error
 	VerifyEmail(token string) error
+	// IsEmailVerified dipakai middleware RequireVerified saat klaim di token masih false.
+	IsEmailVerified(userID int) (bool, error)
+	UnlockAccount(token string, origin audit.Origin) error
 	ResendVerificationEmail(email string) error
-	ForgotPassword(email string) error
-	ResetPassword(token string, newPassword string) error
+	ForgotPassword(email string, origin audit.Origin) error
+	ResetPassword(token string, newPassword string, origin audit.Origin) error
+	ConfirmEmailChange(token string, origin audit.Origin) error
+	RevertEmailChange(token string, origin audit.Origin) error
+	UpdateRole(actor policy.Actor, ID int, role Role) (User, error)
+	EnsureAdmin(email, name, password string) error
+	BeginTOTPEnrollment(actor policy.Actor) (TOTPEnrollmentResponse, error)
+	ConfirmTOTPEnrollment(actor policy.Actor, code string) ([]string, error)
+	DisableTOTP(actor policy.Actor, code string) error
+	RegenerateRecoveryCodes(actor policy.Actor, code string) ([]string, error)
+	PasswordPolicy() password.Policy
+	SendPhoneVerification(actor policy.Actor) (time.Time, error)
+	VerifyPhone(actor policy.Actor, code string) error
 }
 
 type service struct {
-	repository Repository
-	cache      *cache.Cache
+	repository     Repository
+	sessionService session.Service
+	loginGuard     loginguard.Service
+	mfaRepository  mfa.Repository
+	audit          audit.Recorder
+	renderer       *mail.Renderer
+	passwords      *password.Checker
+	phoneService   phone.Service
+	apiKeys        APIKeyRevoker
+	// directoryGeneration dinaikkan setiap data user berubah; lihat directoryCacheKey.
+	directoryGeneration atomic.Uint64
+	appURL              string
+	resetURL            string
+	cache               *cache.Cache
+}
+
+// APIKeyRevoker mencabut semua API key milik user. Dipenuhi apikey.Service;
+// didefinisikan di sini karena package apikey sudah bergantung pada user.
+type APIKeyRevoker interface {
+	RevokeAllForUser(actor policy.Actor, userID int) (int, error)
 }
 
+// passwordResetTTL adalah masa berlaku link reset password.
+const passwordResetTTL = time.Hour
+
+// directoryCacheTTL dibuat pendek karena perubahan dari instance lain tidak
+// menaikkan generasi cache di instance ini.
+const directoryCacheTTL = time.Minute
+
+// unverifiedCacheTTL adalah lama status "email belum terverifikasi" di-cache.
+const unverifiedCacheTTL = 30 * time.Second
+
 // Cache keys
 const (
-	allUsersCacheKey       = "all_users"
-	userByIDCacheKeyPrefix = "user_by_id_"
+	directoryCacheKeyPrefix     = "user_directory_"
+	userByIDCacheKeyPrefix      = "user_by_id_"
+	emailVerifiedCacheKeyPrefix = "email_verified_"
 )
 
-func NewService(repository Repository) *service {
+func NewService(repository Repository, sessionService session.Service, loginGuard loginguard.Service, mfaRepository mfa.Repository, auditRecorder audit.Recorder, renderer *mail.Renderer, passwords *password.Checker, phoneService phone.Service, apiKeys APIKeyRevoker) *service {
 	// Inisialisasi cache:
 	// - 5 menit (5*time.Minute) untuk default expiration
 	// - 10 menit (10*time.Minute) untuk cleanup interval (seberapa sering item kadaluarsa dihapus)
 	c := cache.New(5*time.Minute, 10*time.Minute)
-	return &service{
-		repository: repository,
-		cache:      c,
+
+	// URL untuk link di dalam email dibaca sekali saat service dibuat.
+	appURL := os.Getenv("APP_URL")
+	resetURL := os.Getenv("FRONTEND_RESET_URL")
+	if resetURL == "" {
+		resetURL = appURL + "/v1/reset-password"
 	}
-}
 
-func (s *service) FindOrCreateByGoogle(input GoogleLoginInput) (User, error) {
-	// Check if user with this email already exists.
-	user, err := s.repository.FindByEmail(input.Email)
-	if err != nil {
-		// If user not found, create a new one.
-		// It's better to check for gorm.ErrRecordNotFound specifically.
-		if err.Error() == "record not found" {
-			newUser := User{
-				Name:     input.Name,
-				Email:    input.Email,
-				Verivied: true, // Pengguna dari Google dianggap terverifikasi secara default
-				// Password can be left empty or set to a random string for Google users.
-				// This prevents them from logging in with a password.
-			}
-			createdUser, err := s.repository.RegisterUser(newUser)
-			if err != nil {
-				return User{}, fmt.Errorf("failed to create Google user: %w", err)
-			}
-			s.cache.Delete(allUsersCacheKey)
-			return createdUser, nil
-		}
-		// Handle other potential errors from the repository.
-		return User{}, err
+	return &service{
+		repository:     repository,
+		sessionService: sessionService,
+		loginGuard:     loginGuard,
+		mfaRepository:  mfaRepository,
+		audit:          auditRecorder,
+		renderer:       renderer,
+		passwords:      passwords,
+		phoneService:   phoneService,
+		apiKeys:        apiKeys,
+		appURL:         appURL,
+		resetURL:       resetURL,
+		cache:          c,
 	}
-	// If user exists, return them.
-	return user, nil
 }
 
 // FindByID implements Service.
@@ -101,7 +169,7 @@ func (s *service) FindByID(ID int) (User, error) {
 	return user, nil
 }
 
-func (s *service) RegisterUser(userRequest UserRequest) (User, error) {
+func (s *service) RegisterUser(userRequest UserRequest, origin audit.Origin) (User, error) {
 	// Validate email format
 	if !isValidEmail(userRequest.Email) {
 		return User{}, fmt.Errorf("invalid email format")
@@ -113,18 +181,26 @@ func (s *service) RegisterUser(userRequest UserRequest) (User, error) {
 		return User{}, fmt.Errorf("email already registered")
 	}
 
-	// Define a constant for bcrypt cost
-	const bcryptCost = 10
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(userRequest.Password), bcryptCost)
+	normalizedPhone, err := phone.Normalize(userRequest.Phone)
 	if err != nil {
-		return User{}, fmt.Errorf("error hashing password: %w", err)
+		return User{}, err
+	}
+
+	hashedPassword, err := s.hashNewPassword(User{}, userRequest.Password, password.UserInfo{
+		Emails: []string{userRequest.Email},
+		Name:   userRequest.Name,
+	})
+	if err != nil {
+		return User{}, err
 	}
 	user := User{
 		Name:     userRequest.Name,
 		Email:    userRequest.Email,
-		Password: string(hashedPassword),
-		Phone:    userRequest.Phone,
+		Password: hashedPassword,
+		Phone:    normalizedPhone,
 		Verivied: false,
+		Role:     RoleUser,
+		Language: userRequest.Language,
 	}
 
 	// token verifikasi
@@ -133,103 +209,373 @@ func (s *service) RegisterUser(userRequest UserRequest) (User, error) {
 	user.VerificationToken = &token
 	user.VerificationTokenExpiresAt = &expiresAt
 
-	createdUser, err := s.repository.RegisterUser(user)
+	// Simpan user dan email verifikasi dalam satu transaksi agar email tidak hilang
+	var createdUser User
+	err = s.repository.Transaction(func(txRepo Repository, txOutbox outbox.Repository) error {
+		created, err := txRepo.RegisterUser(user)
+		if err != nil {
+			return err
+		}
+		if err := s.rememberPassword(txRepo, created.ID, created.Password); err != nil {
+			return err
+		}
+		msg, err := s.verificationEmail(created)
+		if err != nil {
+			return err
+		}
+		createdUser = created
+		return outbox.Enqueue(txOutbox, msg)
+	})
 	if err != nil {
 		return User{}, fmt.Errorf("error registering user: %w", err)
 	}
 
-	go sendVerificationEmail(createdUser)
-
-	s.cache.Delete(allUsersCacheKey)
+	s.invalidateDirectory()
+	s.recordEvent(audit.ActionUserRegistered, createdUser.ID, createdUser.ID, origin, map[string]any{"method": "password"})
 
 	return createdUser, nil
 }
 
-func (s *service) UserLogin(req UserLogin) (string, User, error) {
+func (s *service) UserLogin(req UserLogin, origin audit.Origin) (LoginResult, error) {
 	user := User{
 		Email:    req.Email,
 		Password: req.Password,
 	}
 
+	// Tolak lebih awal jika akun/IP sedang dikunci atau masih dalam masa jeda
+	if err := s.loginGuard.Check(req.Email, origin.IP); err != nil {
+		return LoginResult{}, err
+	}
+
 	foundUser, err := s.repository.LoginUser(user)
 	if err != nil {
-		return "", User{}, fmt.Errorf("invalid email or password")
+		s.recordLoginFailure(req.Email, origin, nil, audit.ActionLoginFailed)
+		return LoginResult{}, fmt.Errorf("invalid email or password")
 	}
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(foundUser.Password), []byte(user.Password)); err != nil {
-		return "", User{}, fmt.Errorf("invalid email or password")
+		s.recordLoginFailure(req.Email, origin, &foundUser, audit.ActionLoginFailed)
+		return LoginResult{}, fmt.Errorf("invalid email or password")
+	}
+
+	// Dengan 2FA aktif, counter login gagal baru direset setelah kode 2FA benar
+	// agar kode tidak bisa ditebak berulang kali dengan password yang sudah bocor.
+	if !foundUser.TOTPEnabled {
+		if err := s.loginGuard.RecordSuccess(req.Email, origin.IP); err != nil {
+			log.Printf("Gagal mereset counter login untuk %s: %v", req.Email, err)
+		}
+	}
+
+	return s.CompleteLogin(foundUser, origin, "password")
+}
+
+// CompleteLogin dipanggil setelah identitas user terbukti (password atau provider OAuth).
+// Jika 2FA aktif, yang dikembalikan hanya token tantangan 2FA. method dicatat di audit log.
+func (s *service) CompleteLogin(user User, origin audit.Origin, method string) (LoginResult, error) {
+	user.Password = "" // Clear password before returning
+
+	if err := checkScheduledDeletion(user); err != nil {
+		return LoginResult{}, err
+	}
+
+	if user.TOTPEnabled {
+		mfaToken, err := auth.GenerateMFAToken(strconv.Itoa(user.ID))
+		if err != nil {
+			return LoginResult{}, fmt.Errorf("failed to generate 2FA challenge")
+		}
+		return LoginResult{User: user, MFARequired: true, MFAToken: mfaToken}, nil
 	}
 
-	token, err := auth.GenerateToken(fmt.Sprintf("%d", foundUser.ID), foundUser.Verivied)
+	if err := s.cancelScheduledDeletion(&user, origin); err != nil {
+		return LoginResult{}, err
+	}
+
+	pair, err := s.sessionService.Issue(user.ID, user.Verivied, string(user.Role), "", origin)
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("failed to generate authentication token")
+	}
+	s.recordEvent(audit.ActionLoginSucceeded, user.ID, user.ID, origin, map[string]any{"method": method})
+	return LoginResult{Pair: pair, User: user}, nil
+}
+
+// recordLoginFailure mencatat login gagal ke loginguard dan audit log. Jika akun
+// baru saja dikunci dan user-nya ada, email berisi link untuk membuka kunci diantrekan.
+func (s *service) recordLoginFailure(email string, origin audit.Origin, user *User, action audit.Action) {
+	targetID := 0
+	if user != nil {
+		targetID = user.ID
+	}
+	s.recordEvent(action, 0, targetID, origin, map[string]any{"email": email})
+
+	locked, err := s.loginGuard.RecordFailure(email, origin.IP)
+	if err != nil {
+		log.Printf("Gagal mencatat login gagal untuk %s: %v", email, err)
+		return
+	}
+	if !locked || user == nil {
+		return
+	}
+	s.recordEvent(audit.ActionAccountLocked, 0, user.ID, origin, map[string]any{"email": email})
+
+	// Sama seperti token verifikasi email: UUID dengan masa berlaku 24 jam
+	token := uuid.New().String()
+	expiresAt := time.Now().Add(24 * time.Hour)
+	user.UnlockToken = &token
+	user.UnlockTokenExpiresAt = &expiresAt
+
+	msg, err := s.renderEmail(*user, "unlock_account", map[string]any{
+		"Name":           user.Name,
+		"Link":           fmt.Sprintf("%s/v1/unlock-account?token=%s", s.appURL, url.QueryEscape(token)),
+		"LockMinutes":    int(loginguard.LockDuration.Minutes()),
+		"ExpiresInHours": 24,
+	})
 	if err != nil {
-		return "", User{}, fmt.Errorf("failed to generate authentication token")
+		log.Printf("Gagal menyusun email buka kunci untuk %s: %v", email, err)
+		return
 	}
 
-	foundUser.Password = "" // Clear password before returning
-	return token, foundUser, nil
+	err = s.repository.Transaction(func(txRepo Repository, txOutbox outbox.Repository) error {
+		if _, err := txRepo.Update(*user); err != nil {
+			return err
+		}
+		return outbox.Enqueue(txOutbox, msg)
+	})
+	if err != nil {
+		log.Printf("Gagal mengantrekan email buka kunci untuk %s: %v", email, err)
+	}
 }
 
-func (s *service) FindAll() ([]User, error) {
-	if x, found := s.cache.Get(allUsersCacheKey); found {
-		return x.([]User), nil // Type assert ke []User
+// UnlockAccount membuka kunci akun memakai token dari email "akun dikunci".
+func (s *service) UnlockAccount(token string, origin audit.Origin) error {
+	user, err := s.repository.FindByUnlockToken(token)
+	if err != nil {
+		return fmt.Errorf("token buka kunci tidak valid atau sudah digunakan")
 	}
 
-	// Fetch all users from the repository
-	users, err := s.repository.FindAll()
+	if user.UnlockTokenExpiresAt == nil || user.UnlockTokenExpiresAt.Before(time.Now()) {
+		return fmt.Errorf("token buka kunci sudah kadaluarsa")
+	}
+
+	user.UnlockToken = nil
+	user.UnlockTokenExpiresAt = nil
+	if _, err := s.repository.Update(user); err != nil {
+		return fmt.Errorf("gagal memperbarui token buka kunci: %w", err)
+	}
+
+	if err := s.loginGuard.Unlock(user.Email); err != nil {
+		return fmt.Errorf("gagal membuka kunci akun: %w", err)
+	}
+	s.recordEvent(audit.ActionAccountUnlocked, 0, user.ID, origin, nil)
+	return nil
+}
+
+// RefreshToken menukar refresh token dengan pasangan token baru.
+// Data user diambil ulang supaya klaim (misal status verifikasi) selalu terbaru.
+func (s *service) RefreshToken(rawRefreshToken string, origin audit.Origin) (session.Pair, error) {
+	refreshToken, err := s.sessionService.Rotate(rawRefreshToken)
 	if err != nil {
-		return nil, fmt.Errorf("error finding all users: %w", err)
+		return session.Pair{}, err
 	}
 
-	// Store the result in cache
-	s.cache.Set(allUsersCacheKey, users, cache.DefaultExpiration)
-	// Clear passwords before returning
-	for i := range users {
-		users[i].Password = ""
+	user, err := s.repository.FindByID(refreshToken.UserID)
+	if err != nil {
+		return session.Pair{}, fmt.Errorf("pengguna untuk refresh token ini tidak ditemukan")
+	}
+
+	pair, err := s.sessionService.Issue(user.ID, user.Verivied, string(user.Role), refreshToken.FamilyID, origin)
+	if err != nil {
+		return session.Pair{}, fmt.Errorf("failed to generate authentication token")
 	}
-	return users, nil
+	return pair, nil
 }
 
-func (s *service) Update(ID int, userRequest UserRequest) (User, error) {
+func (s *service) Update(actor policy.Actor, ID int, userRequest UserRequest) (User, error) {
+	if err := policy.CanModify(actor, ID, "account"); err != nil {
+		return User{}, err
+	}
+
 	user, err := s.repository.FindByID(ID)
 	if err != nil {
 		return User{}, fmt.Errorf("error finding user for update: %w", err)
 	}
+	var changes []string
+	if userRequest.Name != user.Name {
+		changes = append(changes, "name")
+	}
 	user.Name = userRequest.Name
-	user.Email = userRequest.Email
-	// Hash the password if it's being updated
-	if userRequest.Password != "" {
-		const bcryptCost = 10
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(userRequest.Password), bcryptCost)
+	// Email tidak langsung diganti: alamat baru harus dikonfirmasi lebih dulu.
+	var emailChangeMsg *mail.Message
+	if userRequest.Email != user.Email {
+		msg, err := s.startEmailChange(&user, userRequest.Email)
+		if err != nil {
+			return User{}, err
+		}
+		emailChangeMsg = &msg
+	}
+	// Hash the password if it's being updated. Form profil selalu mengirim
+	// password, jadi password yang sama dengan saat ini dianggap tidak berubah.
+	passwordChanged := userRequest.Password != "" &&
+		bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(userRequest.Password)) != nil
+	if passwordChanged {
+		hashedPassword, err := s.hashNewPassword(user, userRequest.Password, password.UserInfo{
+			Emails: []string{user.Email, userRequest.Email},
+			Name:   userRequest.Name,
+		})
 		if err != nil {
-			return User{}, fmt.Errorf("error hashing password: %w", err)
+			return User{}, err
 		}
-		user.Password = string(hashedPassword)
+		user.Password = hashedPassword
+		// Token reset yang masih beredar tidak berlaku lagi setelah password diganti
+		user.PasswordResetTokenHash = nil
+		user.PasswordResetExpiresAt = nil
 	}
-	user.Phone = userRequest.Phone
-	updatedUser, err := s.repository.Update(user)
+	normalizedPhone, err := phone.Normalize(userRequest.Phone)
+	if err != nil {
+		return User{}, err
+	}
+	if normalizedPhone != user.Phone {
+		changes = append(changes, "phone")
+		// Nomor baru harus diverifikasi ulang.
+		user.PhoneVerifiedAt = nil
+	}
+	user.Phone = normalizedPhone
+	if userRequest.Language != "" && userRequest.Language != user.Language {
+		changes = append(changes, "language")
+		user.Language = userRequest.Language
+	}
+	var updatedUser User
+	err = s.repository.Transaction(func(txRepo Repository, txOutbox outbox.Repository) error {
+		updated, err := txRepo.Update(user)
+		if err != nil {
+			return err
+		}
+		updatedUser = updated
+		if passwordChanged {
+			if err := s.rememberPassword(txRepo, ID, user.Password); err != nil {
+				return err
+			}
+		}
+		if emailChangeMsg == nil {
+			return nil
+		}
+		return outbox.Enqueue(txOutbox, *emailChangeMsg)
+	})
 	if err != nil {
 		return User{}, fmt.Errorf("error updating user: %w", err)
 	}
 
 	// Setelah operasi tulis, invalidate cache yang relevan
-	s.cache.Delete(allUsersCacheKey) // Cache daftar semua user tidak valid lagi
-	s.cache.Delete(fmt.Sprintf("%s%d", userByIDCacheKeyPrefix, ID))
+	s.invalidateUserCache(ID)
+
+	origin := audit.ActorOrigin(actor)
+	if len(changes) > 0 {
+		s.recordEvent(audit.ActionUserUpdated, actor.UserID, ID, origin, map[string]any{"fields": changes})
+	}
+	if passwordChanged {
+		s.recordEvent(audit.ActionPasswordChanged, actor.UserID, ID, origin, nil)
+	}
+	if emailChangeMsg != nil {
+		s.recordEvent(audit.ActionEmailChangeRequested, actor.UserID, ID, origin, map[string]any{"new_email": userRequest.Email})
+	}
 
 	return updatedUser, nil
 }
 
-func (s *service) Delete(ID int) error {
-	if _, err := s.repository.FindByID(ID); err != nil {
-		return fmt.Errorf("error finding user for deletion: %w", err)
+// UpdateRole mengganti role user. Admin terakhir tidak boleh diturunkan
+// agar sistem tidak kehilangan akses administrasi.
+func (s *service) UpdateRole(actor policy.Actor, ID int, role Role) (User, error) {
+	user, err := s.repository.FindByID(ID)
+	if err != nil {
+		return User{}, fmt.Errorf("error finding user for role update: %w", err)
 	}
 
-	if err := s.repository.Delete(ID); err != nil {
-		return fmt.Errorf("error deleting user: %w", err)
+	if user.Role == RoleAdmin && role != RoleAdmin {
+		admins, err := s.repository.CountByRole(RoleAdmin)
+		if err != nil {
+			return User{}, fmt.Errorf("error counting admins: %w", err)
+		}
+		if admins <= 1 {
+			return User{}, fmt.Errorf("cannot demote the last admin")
+		}
 	}
-	// Setelah operasi tulis, invalidate cache yang relevan
-	s.cache.Delete(allUsersCacheKey)
-	s.cache.Delete(fmt.Sprintf("%s%d", userByIDCacheKeyPrefix, ID))
+
+	previousRole := user.Role
+	user.Role = role
+	updatedUser, err := s.repository.Update(user)
+	if err != nil {
+		return User{}, fmt.Errorf("error updating user role: %w", err)
+	}
+	s.recordEvent(audit.ActionUserRoleChanged, actor.UserID, ID, audit.ActorOrigin(actor), map[string]any{"from": previousRole, "to": role})
+
+	s.invalidateUserCache(ID)
+
+	updatedUser.Password = ""
+	return updatedUser, nil
+}
+
+// ErrAdminEmailNotVerified dikembalikan EnsureAdmin jika ADMIN_EMAIL sudah dipakai
+// akun yang belum diverifikasi. Akun itu bisa saja didaftarkan orang lain
+// sebelum operator mengatur ADMIN_EMAIL, jadi tidak dinaikkan menjadi admin.
+var ErrAdminEmailNotVerified = errors.New("email admin sudah terdaftar tetapi belum diverifikasi; verifikasi email akun tersebut, hapus akunnya, atau gunakan ADMIN_EMAIL lain")
+
+// EnsureAdmin membuat admin pertama (bootstrap) jika belum ada admin sama sekali.
+// Jika email sudah terdaftar dan terverifikasi, user tersebut dinaikkan menjadi
+// admin dengan password miliknya sendiri; akun yang belum terverifikasi ditolak.
+// Jika belum terdaftar, akun admin baru yang sudah terverifikasi dibuat dengan
+// password yang diberikan.
+func (s *service) EnsureAdmin(email, name, adminPassword string) error {
+	admins, err := s.repository.CountByRole(RoleAdmin)
+	if err != nil {
+		return fmt.Errorf("error counting admins: %w", err)
+	}
+	if admins > 0 {
+		return nil
+	}
+
+	existingUser, err := s.repository.FindByEmail(email)
+	if err == nil {
+		if !existingUser.Verivied {
+			return fmt.Errorf("%w (user %d)", ErrAdminEmailNotVerified, existingUser.ID)
+		}
+		existingUser.Role = RoleAdmin
+		if _, err := s.repository.Update(existingUser); err != nil {
+			return fmt.Errorf("error promoting user to admin: %w", err)
+		}
+		s.invalidateUserCache(existingUser.ID)
+		log.Printf("User %s yang sudah terdaftar dijadikan admin pertama; ADMIN_PASSWORD tidak dipakai", email)
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("error finding user for admin bootstrap: %w", err)
+	}
+
+	if name == "" {
+		name = "Administrator"
+	}
+	hashedPassword, err := s.hashNewPassword(User{}, adminPassword, password.UserInfo{Emails: []string{email}, Name: name})
+	if err != nil {
+		return fmt.Errorf("admin password rejected: %w", err)
+	}
+
+	err = s.repository.Transaction(func(txRepo Repository, txOutbox outbox.Repository) error {
+		created, err := txRepo.RegisterUser(User{
+			Name:     name,
+			Email:    email,
+			Password: hashedPassword,
+			Verivied: true,
+			Role:     RoleAdmin,
+		})
+		if err != nil {
+			return err
+		}
+		return s.rememberPassword(txRepo, created.ID, created.Password)
+	})
+	if err != nil {
+		return fmt.Errorf("error creating admin user: %w", err)
+	}
+	s.invalidateDirectory()
+	log.Printf("Admin pertama %s berhasil dibuat", email)
 	return nil
 }
 
@@ -263,12 +609,41 @@ func (s *service) VerifyEmail(token string) error {
 		return fmt.Errorf("gagal memperbarui status verifikasi: %w", err)
 	}
 
-	s.cache.Delete(allUsersCacheKey)
+	s.invalidateDirectory()
 	s.cache.Delete(fmt.Sprintf("%s%d", userByIDCacheKeyPrefix, user.ID))
+	s.cache.Set(fmt.Sprintf("%s%d", emailVerifiedCacheKeyPrefix, user.ID), true, cache.DefaultExpiration)
 
 	return nil
 }
 
+// IsEmailVerified membaca status verifikasi dari database. Status "belum" hanya
+// di-cache sebentar agar verifikasi lewat instance lain cepat berlaku.
+func (s *service) IsEmailVerified(userID int) (bool, error) {
+	cacheKey := fmt.Sprintf("%s%d", emailVerifiedCacheKeyPrefix, userID)
+	if x, found := s.cache.Get(cacheKey); found {
+		return x.(bool), nil
+	}
+
+	user, err := s.repository.FindByID(userID)
+	if err != nil {
+		return false, fmt.Errorf("error finding user: %w", err)
+	}
+
+	ttl := cache.DefaultExpiration
+	if !user.Verivied {
+		ttl = unverifiedCacheTTL
+	}
+	s.cache.Set(cacheKey, user.Verivied, ttl)
+	return user.Verivied, nil
+}
+
+// invalidateUserCache menghapus cache yang memuat data user tersebut.
+func (s *service) invalidateUserCache(userID int) {
+	s.invalidateDirectory()
+	s.cache.Delete(fmt.Sprintf("%s%d", userByIDCacheKeyPrefix, userID))
+	s.cache.Delete(fmt.Sprintf("%s%d", emailVerifiedCacheKeyPrefix, userID))
+}
+
 func (s *service) ResendVerificationEmail(email string) error {
 	user, err := s.repository.FindByEmail(email)
 	if err != nil {
@@ -285,118 +660,150 @@ func (s *service) ResendVerificationEmail(email string) error {
 	user.VerificationToken = &token
 	user.VerificationTokenExpiresAt = &expiresAt
 
-	// Save the new token to the database
-	_, err = s.repository.Update(user)
+	// Save the new token to the database together with the queued email
+	err = s.repository.Transaction(func(txRepo Repository, txOutbox outbox.Repository) error {
+		if _, err := txRepo.Update(user); err != nil {
+			return err
+		}
+		msg, err := s.verificationEmail(user)
+		if err != nil {
+			return err
+		}
+		return outbox.Enqueue(txOutbox, msg)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to update verification token: %w", err)
 	}
-
-	go sendVerificationEmail(user)
 	return nil
 }
 
-func (s *service) ForgotPassword(email string) error {
+func (s *service) ForgotPassword(email string, origin audit.Origin) error {
 	user, err := s.repository.FindByEmail(email)
 	if err != nil {
 		return fmt.Errorf("pengguna dengan email tersebut tidak ditemukan")
 	}
 
-	// Hasilkan token baru yang berisi email pengguna.
-	token, err := auth.GenerateTokenPassword(user.Email)
+	// Hasilkan token acak sekali pakai. Hanya hash-nya yang disimpan, dan token
+	// baru otomatis menggantikan token reset sebelumnya.
+	token, err := securetoken.Generate()
 	if err != nil {
 		return fmt.Errorf("gagal memulai proses reset password")
 	}
+	tokenHash := securetoken.Hash(token)
+	expiresAt := time.Now().Add(passwordResetTTL)
+	user.PasswordResetTokenHash = &tokenHash
+	user.PasswordResetExpiresAt = &expiresAt
 
-	go sendForgotPasswordEmail(user.Email, token)
+	msg, err := s.forgotPasswordEmail(user, token)
+	if err != nil {
+		return fmt.Errorf("gagal menyusun email reset password: %w", err)
+	}
+
+	err = s.repository.Transaction(func(txRepo Repository, txOutbox outbox.Repository) error {
+		if _, err := txRepo.Update(user); err != nil {
+			return err
+		}
+		return outbox.Enqueue(txOutbox, msg)
+	})
+	if err != nil {
+		return fmt.Errorf("gagal mengantrekan email reset password: %w", err)
+	}
+	s.recordEvent(audit.ActionPasswordResetRequested, 0, user.ID, origin, nil)
 	return nil
 }
 
-func (s *service) ResetPassword(token string, newPassword string) error {
-	claims, err := auth.ValidateTokenPassword(token)
+// errResetTokenUsed membatalkan transaksi reset saat token sudah dipakai
+// permintaan lain.
+var errResetTokenUsed = errors.New("token reset password sudah digunakan")
+
+func (s *service) ResetPassword(token string, newPassword string, origin audit.Origin) error {
+	tokenHash := securetoken.Hash(token)
+	user, err := s.repository.FindByPasswordResetToken(tokenHash)
 	if err != nil {
-		return fmt.Errorf("token reset password tidak valid atau kadaluarsa: %w", err)
+		return fmt.Errorf("token reset password tidak valid atau sudah digunakan")
 	}
 
-	user, err := s.repository.FindByEmail(claims.Email)
-	if err != nil {
-		return fmt.Errorf("pengguna terkait dengan token ini tidak ditemukan")
+	if user.PasswordResetExpiresAt == nil || user.PasswordResetExpiresAt.Before(time.Now()) {
+		return fmt.Errorf("token reset password sudah kadaluarsa")
 	}
 
-	const bcryptCost = 10
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcryptCost)
+	// Password diperiksa sebelum token dipakai agar user bisa mencoba password lain.
+	hashedPassword, err := s.hashNewPassword(user, newPassword, password.UserInfo{Emails: []string{user.Email}, Name: user.Name})
 	if err != nil {
+		var policyErr *password.PolicyError
+		if errors.As(err, &policyErr) {
+			return err
+		}
 		return fmt.Errorf("gagal memproses password baru")
 	}
 
-	user.Password = string(hashedPassword)
-	if _, err := s.repository.ResetPassword(user); err != nil {
+	user.Password = hashedPassword
+	user.PasswordResetTokenHash = nil
+	user.PasswordResetExpiresAt = nil
+	// Token dipakai di transaksi yang sama dengan penyimpanan password: jika
+	// penyimpanan gagal, token tetap berlaku dan user bisa mencoba lagi.
+	err = s.repository.Transaction(func(txRepo Repository, txOutbox outbox.Repository) error {
+		consumed, err := txRepo.ConsumePasswordResetToken(user.ID, tokenHash)
+		if err != nil {
+			return err
+		}
+		if !consumed {
+			return errResetTokenUsed
+		}
+		if _, err := txRepo.ResetPassword(user); err != nil {
+			return err
+		}
+		return s.rememberPassword(txRepo, user.ID, user.Password)
+	})
+	if errors.Is(err, errResetTokenUsed) {
+		return fmt.Errorf("token reset password tidak valid atau sudah digunakan")
+	}
+	if err != nil {
 		return fmt.Errorf("gagal memperbarui password: %w", err)
 	}
 
+	// Password baru berarti semua sesi lama harus login ulang.
+	s.recordEvent(audit.ActionPasswordReset, 0, user.ID, origin, nil)
+	if err := s.sessionService.RevokeAllForUser(user.ID); err != nil {
+		return fmt.Errorf("password diperbarui, tetapi gagal mencabut sesi lama: %w", err)
+	}
+	if _, err := s.apiKeys.RevokeAllForUser(originActor(origin), user.ID); err != nil {
+		return fmt.Errorf("password diperbarui, tetapi gagal mencabut API key: %w", err)
+	}
+
+	s.invalidateUserCache(user.ID)
+
 	return nil
 }
 
-// sendVerificationEmail adalah helper untuk mengirim email menggunakan SMTP.
-func sendVerificationEmail(user User) {
+// verificationEmail menyusun email verifikasi untuk user.
+func (s *service) verificationEmail(user User) (mail.Message, error) {
 	if user.VerificationToken == nil {
-		log.Printf("Tidak ada token verifikasi untuk pengguna %s, email tidak dikirim.", user.Email)
-		return
+		return mail.Message{}, fmt.Errorf("tidak ada token verifikasi untuk pengguna %s", user.Email)
 	}
 
-	// Ambil konfigurasi dari environment variables
-	host := os.Getenv("SMTP_HOST")
-	port := os.Getenv("SMTP_PORT")
-	smtpUser := os.Getenv("SMTP_USER")
-	pass := os.Getenv("SMTP_PASS")
-	from := os.Getenv("SMTP_SENDER_EMAIL")
-	appURL := os.Getenv("APP_URL")
-
-	addr := fmt.Sprintf("%s:%s", host, port)
-	auth := smtp.PlainAuth("", smtpUser, pass, host)
-	verificationLink := fmt.Sprintf("%s/v1/verify-email?token=%s", appURL, *user.VerificationToken)
-
-	subject := "Subject: Verifikasi Akun Anda\r\n"
-	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
-	body := fmt.Sprintf(`<html><body><h2>Selamat Datang!</h2><p>Terima kasih telah mendaftar. Silakan klik link di bawah ini untuk memverifikasi alamat email Anda:</p><p><a href="%s">Verifikasi Email Saya</a></p><p>Link ini akan kedaluwarsa dalam 24 jam.</p></body></html>`, verificationLink)
-	msg := []byte(subject + mime + body)
+	return s.renderEmail(user, "verification", map[string]any{
+		"Name":           user.Name,
+		"Link":           fmt.Sprintf("%s/v1/verify-email?token=%s", s.appURL, url.QueryEscape(*user.VerificationToken)),
+		"ExpiresInHours": 24,
+	})
+}
 
-	err := smtp.SendMail(addr, auth, from, []string{user.Email}, msg)
-	if err != nil {
-		log.Printf("Gagal mengirim email verifikasi ke %s: %v", user.Email, err)
-	} else {
-		log.Printf("Email verifikasi berhasil dikirim ke %s", user.Email)
-	}
+// forgotPasswordEmail menyusun email berisi link reset password.
+func (s *service) forgotPasswordEmail(user User, token string) (mail.Message, error) {
+	return s.renderEmail(user, "reset_password", map[string]any{
+		"Name":             user.Name,
+		"Link":             fmt.Sprintf("%s?token=%s", s.resetURL, url.QueryEscape(token)),
+		"ExpiresInMinutes": int(passwordResetTTL.Minutes()),
+	})
 }
 
-// sendVerificationEmail adalah helper untuk mengirim email menggunakan SMTP.
-func sendForgotPasswordEmail(email, token string) {
-	// Ambil konfigurasi dari environment variables
-	host := os.Getenv("SMTP_HOST")
-	port := os.Getenv("SMTP_PORT")
-	smtpUser := os.Getenv("SMTP_USER")
-	pass := os.Getenv("SMTP_PASS")
-	from := os.Getenv("SMTP_SENDER_EMAIL")
-	resetAppURL := os.Getenv("FRONTEND_RESET_URL")
-	if resetAppURL == "" {
-		resetAppURL = os.Getenv("APP_URL") + "/v1/reset-password"
-	}
-
-	addr := fmt.Sprintf("%s:%s", host, port)
-	auth := smtp.PlainAuth("", smtpUser, pass, host)
-	resetLink := fmt.Sprintf("%s?token=%s", resetAppURL, token)
-
-	subject := "Subject: Reset Password\r\n"
-	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
-	body := fmt.Sprintf(`<html><body><h2>Reset Password</h2>
-	<p>Anda meminta untuk mereset password Anda. Klik link di bawah ini untuk melanjutkan:</p>
-	<p><a href="%s">Reset Password</a></p>
-	<p>Jika Anda tidak meminta ini, abaikan email ini. Link ini akan kedaluwarsa dalam 24 jam.</p></body></html>`, resetLink)
-	msg := []byte(subject + mime + body)
-
-	err := smtp.SendMail(addr, auth, from, []string{email}, msg)
-	if err != nil {
-		log.Printf("Passeord %s gagal di perbarui karena: %v", email, err)
-	} else {
-		log.Printf("Password %s berhasil di ganti", email)
+// renderEmail merender template dalam bahasa pilihan user dengan user sebagai penerima.
+func (s *service) renderEmail(user User, templateName string, data map[string]any) (mail.Message, error) {
+	msg, err := s.renderer.Render(templateName, user.Language, data)
+	if err != nil {
+		return mail.Message{}, err
 	}
+	msg.To = []string{user.Email}
+	return msg, nil
 }